@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"texas_yu/internal/ai"
@@ -28,11 +29,13 @@ func main() {
 	if aiRuntimeConfigPath == "" {
 		aiRuntimeConfigPath = "data/ai_runtime.json"
 	}
-	ms := store.NewMemoryStore(store.Options{AI: aiSvc, AIConfig: aiCfg, StrategyConfigPath: strategyConfigPath, AIRuntimeConfigPath: aiRuntimeConfigPath})
+	keptHands, _ := strconv.Atoi(strings.TrimSpace(os.Getenv("KEPT_HANDS")))
+	ms := store.NewMemoryStore(store.Options{AI: aiSvc, AIConfig: aiCfg, StrategyConfigPath: strategyConfigPath, AIRuntimeConfigPath: aiRuntimeConfigPath, KeptHands: keptHands})
 	authH := &api.AuthHandler{Store: ms}
 	roomH := &api.RoomHandler{Store: ms}
 	gameH := &api.GameHandler{Store: ms}
 	benchmarkH := &api.BenchmarkHandler{Store: ms}
+	tournamentH := &api.TournamentHandler{Store: ms}
 
 	mux := http.NewServeMux()
 
@@ -56,6 +59,13 @@ func main() {
 		http.ServeFile(w, r, "web/static/ai_benchmark.html")
 	})
 
+	mux.HandleFunc("/api/v1/fairness/verify", api.RequireSession(ms, func(w http.ResponseWriter, r *http.Request, s *store.Session) {
+		gameH.VerifyDeck(w, r, s)
+	}))
+	mux.HandleFunc("/api/v1/tools/equity", api.RequireSession(ms, func(w http.ResponseWriter, r *http.Request, s *store.Session) {
+		gameH.Equity(w, r, s)
+	}))
+
 	mux.HandleFunc("/api/v1/rooms", api.RequireSession(ms, func(w http.ResponseWriter, r *http.Request, s *store.Session) {
 		switch r.Method {
 		case http.MethodGet:
@@ -98,6 +108,20 @@ func main() {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		case "ai-managed":
 			roomH.ToggleAIManaged(w, r, s)
+		case "sit-out":
+			roomH.SitOut(w, r, s)
+		case "sit-in":
+			roomH.SitIn(w, r, s)
+		case "post-blind":
+			roomH.SetPostBlind(w, r, s)
+		case "top-up":
+			roomH.TopUp(w, r, s)
+		case "rebuy":
+			roomH.Rebuy(w, r, s)
+		case "ledger":
+			roomH.ChipLedger(w, r, s)
+		case "bomb-pot":
+			roomH.BombPot(w, r, s)
 		case "chip-refresh":
 			if len(parts) == 2 && r.Method == http.MethodPost {
 				roomH.StartChipRefreshVote(w, r, s)
@@ -112,6 +136,10 @@ func main() {
 			gameH.GetState(w, r, s)
 		case "actions":
 			gameH.Action(w, r, s)
+		case "hand-history":
+			gameH.HandHistory(w, r, s)
+		case "replay":
+			gameH.Replay(w, r, s)
 		case "quick-chats":
 			if r.Method == http.MethodGet {
 				gameH.GetQuickChats(w, r, s)
@@ -127,6 +155,40 @@ func main() {
 		}
 	}))
 
+	mux.HandleFunc("/api/v1/tournaments", api.RequireSession(ms, func(w http.ResponseWriter, r *http.Request, s *store.Session) {
+		switch r.Method {
+		case http.MethodGet:
+			tournamentH.ListTournaments(w, r, s)
+		case http.MethodPost:
+			tournamentH.CreateTournament(w, r, s)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/api/v1/tournaments/", api.RequireSession(ms, func(w http.ResponseWriter, r *http.Request, s *store.Session) {
+		path := strings.TrimPrefix(r.URL.Path, "/api/v1/tournaments/")
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		if len(parts) == 1 && parts[0] != "" {
+			tournamentH.GetTournament(w, r, s)
+			return
+		}
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		switch parts[1] {
+		case "register":
+			tournamentH.Register(w, r, s)
+		case "unregister":
+			tournamentH.Unregister(w, r, s)
+		case "start":
+			tournamentH.Start(w, r, s)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
 	fs := http.FileServer(http.Dir("web/static"))
 	mux.Handle("/", fs)
 
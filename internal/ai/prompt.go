@@ -18,7 +18,7 @@ func BuildDecisionPrompt(input DecisionInput) (string, string) {
 6) 强牌优先争取价值，听牌可半诈唬，边缘牌关注控池和弃牌率，短码时可提高全压频率
 7) 下注尺度要和当前街、SPR、牌面湿度、范围优势、极化程度匹配；不要无理由极端 overbet
 	8) diagnostics 中的 activeOpponents、potOdds、equityEstimate、pressureScore、boardWetness、spr、hasInitiative、rangeAdvantage、scareCardScore、lineCapScore、pairStrengthScore、blockerScore、missedDrawScore、showdownValueScore、stationScore、visibleTags 都可以直接使用
-	9) opponentRanges 中的 preflopBucket、currentLine、likelyHandClass、foldToPressure、trapRisk、drawWeight、confidence 是对各对手当前范围的显式提示；若 confidence 较高，应优先用它来做 exploit
+	9) opponentRanges 中的 preflopBucket、currentLine、likelyHandClass、foldToPressure、trapRisk、drawWeight、confidence 是对各对手当前范围的显式提示；若 confidence 较高，应优先用它来做 exploit；preflopRange 是该分类对应的标准范围写法（如 "TT+, AKs, KQo:0.5"），rangeCombos 是去掉你可见的底牌与公共牌后剩余的加权组合数
 	10) 若 decisionOptions 非空，优先直接从中选择；选择候选动作时，optionId 必须填写对应 id，action/amount 必须与该候选完全一致
 	11) decisionOptions 中的 evEstimate 代表本地近似筹码 EV，localScore 代表综合战略评分，riskScore 代表风险暴露；若无清晰 exploit 证据，优先更高 evEstimate / localScore、风险更合理、且接近 baselineDecision 的方案
 	12) baselineDecision 是强规则基线；如果没有清晰 exploit 或更高 EV 证据，不要为了“看起来随机”而故意偏离它
@@ -132,7 +132,11 @@ func validateDecision(input DecisionInput, d Decision) error {
 		if input.RoundBet > 0 {
 			min = input.MinRaise
 		}
-		if d.Amount < min || d.Amount > input.Stack {
+		max := input.Stack
+		if input.MaxRaise > 0 && input.MaxRaise < max {
+			max = input.MaxRaise
+		}
+		if d.Amount < min || d.Amount > max {
 			return fmt.Errorf("bet amount out of range")
 		}
 		return nil
@@ -32,6 +32,8 @@ type OpponentRangeHint struct {
 	PreflopBucket   string   `json:"preflopBucket"`
 	CurrentLine     string   `json:"currentLine"`
 	LikelyHandClass string   `json:"likelyHandClass"`
+	PreflopRange    string   `json:"preflopRange,omitempty"`
+	RangeCombos     float64  `json:"rangeCombos,omitempty"`
 	FoldToPressure  float64  `json:"foldToPressure"`
 	TrapRisk        float64  `json:"trapRisk"`
 	DrawWeight      float64  `json:"drawWeight"`
@@ -92,6 +94,7 @@ type DecisionInput struct {
 	StateVersion       int64                    `json:"stateVersion"`
 	AIUserID           string                   `json:"aiUserId"`
 	AIUsername         string                   `json:"aiUsername"`
+	Variant            string                   `json:"variant"`
 	Stage              string                   `json:"stage"`
 	Pot                int                      `json:"pot"`
 	RoundBet           int                      `json:"roundBet"`
@@ -100,6 +103,8 @@ type DecisionInput struct {
 	CallAmount         int                      `json:"callAmount"`
 	MinBet             int                      `json:"minBet"`
 	MinRaise           int                      `json:"minRaise"`
+	MaxRaise           int                      `json:"maxRaise"`
+	BettingStructure   string                   `json:"bettingStructure"`
 	Stack              int                      `json:"stack"`
 	AllowedActions     []string                 `json:"allowedActions"`
 	CommunityCards     []string                 `json:"communityCards"`
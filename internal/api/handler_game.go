@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"texas_yu/internal/domain"
+	"texas_yu/internal/equity"
+	"texas_yu/internal/handhistory"
 	"texas_yu/internal/store"
 )
 
@@ -21,6 +23,26 @@ type actionReq struct {
 	ExpectedVersion int64  `json:"expectedVersion"`
 }
 
+type verifyDeckReq struct {
+	Seed       string `json:"seed"`
+	Commitment string `json:"commitment"`
+	Variant    string `json:"variant"`
+}
+
+type equityPlayerReq struct {
+	Cards []string `json:"cards"`
+	Range string   `json:"range"`
+}
+
+type equityReq struct {
+	Variant string            `json:"variant"`
+	Board   []string          `json:"board"`
+	Dead    []string          `json:"dead"`
+	Players []equityPlayerReq `json:"players"`
+	Trials  int               `json:"trials"`
+	Seed    int64             `json:"seed"`
+}
+
 type quickChatReq struct {
 	ActionID string `json:"actionId"`
 	PhraseID string `json:"phraseId"`
@@ -37,8 +59,11 @@ type gamePlayerView struct {
 	LastAction   string         `json:"lastAction"`
 	Won          int            `json:"won"`
 	Contributed  int            `json:"contributed"`
+	Rake         int            `json:"rake"`
 	BestHandName string         `json:"bestHandName,omitempty"`
+	BestLowName  string         `json:"bestLowName,omitempty"`
 	RevealMask   int            `json:"revealMask"`
+	Exposed      bool           `json:"exposed"`
 	CanReveal    bool           `json:"canReveal"`
 	HoleCards    []*domain.Card `json:"holeCards,omitempty"`
 	IsTurn       bool           `json:"isTurn"`
@@ -46,10 +71,13 @@ type gamePlayerView struct {
 	CanCall      bool           `json:"canCall"`
 	CanBet       bool           `json:"canBet"`
 	CanRaise     bool           `json:"canRaise"`
+	CanAllIn     bool           `json:"canAllIn"`
 	CanFold      bool           `json:"canFold"`
 	CallAmount   int            `json:"callAmount"`
 	MinBet       int            `json:"minBet"`
 	MinRaise     int            `json:"minRaise"`
+	MaxRaise     int            `json:"maxRaise"`
+	CanRunIt     bool           `json:"canRunIt"`
 }
 
 func (h *GameHandler) GetQuickChats(w http.ResponseWriter, r *http.Request, s *store.Session) {
@@ -207,15 +235,25 @@ func (h *GameHandler) GetState(w http.ResponseWriter, r *http.Request, s *store.
 	}
 
 	roomPlayers := make([]map[string]any, 0, len(room.Players))
+	sittingOut := []string{}
 	for _, p := range room.Players {
 		roomPlayers = append(roomPlayers, map[string]any{
-			"userId":    p.UserID,
-			"username":  p.Username,
-			"seat":      p.Seat,
-			"stack":     p.Stack,
-			"isAi":      p.IsAI,
-			"aiManaged": p.AIManaged,
+			"userId":           p.UserID,
+			"username":         p.Username,
+			"seat":             p.Seat,
+			"stack":            p.Stack,
+			"isAi":             p.IsAI,
+			"aiManaged":        p.AIManaged,
+			"sittingOut":       p.SittingOut,
+			"sitOutHands":      p.SitOutHands,
+			"waitingForHand":   p.WaitingForHand,
+			"postBlind":        p.PostBlind,
+			"missedSmallBlind": p.MissedSmallBlind,
+			"missedBigBlind":   p.MissedBigBlind,
 		})
+		if p.SittingOut {
+			sittingOut = append(sittingOut, p.UserID)
+		}
 	}
 
 	resp := map[string]any{
@@ -225,10 +263,31 @@ func (h *GameHandler) GetState(w http.ResponseWriter, r *http.Request, s *store.
 		"ownerUserId":      room.OwnerUserID,
 		"stateVersion":     room.StateVersion,
 		"roomPlayers":      roomPlayers,
-		"canStartNextHand": room.OwnerUserID == s.UserID && room.Game != nil && room.Game.Stage == domain.StageFinished,
+		"sittingOut":       sittingOut,
+		"sitOutHands":      room.SitOutHands,
+		"minBuyIn":         room.MinBuyIn,
+		"maxBuyIn":         room.MaxBuyIn,
+		"rake":             room.Rake,
+		"rakeTotal":        room.RakeTotal,
+		"bombPotEvery":     room.BombPotEvery,
+		"bombPot":          room.BombPot,
+		"nextBombPot":      room.NextBombPot,
+		"canStartNextHand": room.OwnerUserID == s.UserID && room.Game != nil && room.Game.Stage == domain.StageFinished && room.TournamentRules == nil,
 		"aiMemory":         room.AIMemory,
 		"chipRefreshVote":  room.ChipRefreshVote,
 		"viewerRole":       viewerRole,
+		"actionClock":      room.ActionClock,
+		"timeBanks":        room.TimeBanks,
+		"serverTimeMs":     h.Store.Now().UnixMilli(),
+		"tournamentRules":  room.TournamentRules,
+		"tournament":       room.Tournament,
+	}
+	if room.Tournament != nil && room.Tournament.TournamentID != "" {
+		// The tables of a multi-table tournament show where everyone left
+		// in the field is seated.
+		if mt, ok := h.Store.GetMultiTableTournament(room.Tournament.TournamentID); ok {
+			resp["multiTable"] = mt
+		}
 	}
 	if room.Game == nil {
 		resp["game"] = nil
@@ -243,28 +302,31 @@ func (h *GameHandler) GetState(w http.ResponseWriter, r *http.Request, s *store.
 		canCall := false
 		canBet := false
 		canRaise := false
+		canAllIn := false
 		canFold := false
 		callAmount := 0
 		minBet := 0
 		minRaise := 0
-		if isPlayer && isTurn && !p.Folded && !p.AIManaged {
+		maxRaise := 0
+		if isPlayer && isTurn && !p.Folded && !p.AllIn && !p.AIManaged && !room.Game.RunoutPending {
 			diff := room.Game.RoundBet - p.RoundContrib
 			canCheck = diff == 0
 			canCall = diff > 0 && p.Stack >= diff
 			if canCall {
 				callAmount = diff
 			}
-			canBet = room.Game.RoundBet == 0 && p.Stack >= room.Game.OpenBetMin
-			if canBet {
-				minBet = room.Game.OpenBetMin
-			}
-			if room.Game.RoundBet > 0 {
-				need := diff + room.Game.BetMin
-				canRaise = p.Stack >= need
-				if canRaise {
+			need := room.Game.MinRaiseFor(p)
+			if need > 0 && need <= room.Game.MaxRaiseFor(p) {
+				if room.Game.RoundBet == 0 {
+					canBet = true
+					minBet = need
+				} else {
+					canRaise = true
 					minRaise = need
 				}
+				maxRaise = room.Game.MaxRaiseFor(p)
 			}
+			canAllIn = room.Game.CanAllIn(p)
 			canFold = true
 		}
 
@@ -279,45 +341,78 @@ func (h *GameHandler) GetState(w http.ResponseWriter, r *http.Request, s *store.
 			LastAction:   p.LastAction,
 			Won:          p.Won,
 			Contributed:  p.Contributed,
+			Rake:         p.Rake,
 			BestHandName: p.BestHandName,
+			BestLowName:  p.BestLowName,
 			RevealMask:   p.RevealMask,
+			Exposed:      p.Exposed,
 			CanReveal:    isPlayer && p.UserID == s.UserID && room.Game.Stage == domain.StageFinished,
 			IsTurn:       isTurn,
 			CanCheck:     canCheck,
 			CanCall:      canCall,
 			CanBet:       canBet,
 			CanRaise:     canRaise,
+			CanAllIn:     canAllIn,
 			CanFold:      canFold,
 			CallAmount:   callAmount,
 			MinBet:       minBet,
 			MinRaise:     minRaise,
+			MaxRaise:     maxRaise,
 		}
+		if room.Game.RunoutPending && isPlayer && p.UserID == s.UserID && !p.Folded && !p.IsAI && !p.AIManaged {
+			_, voted := room.Game.RunoutVotes[p.UserID]
+			pv.CanRunIt = !voted
+		}
+		// Hands turned over at showdown or exposed all-in are public as soon
+		// as they are shown; the rest stay hidden until the hand is over.
+		shown := room.Game.Stage == domain.StageFinished || p.RevealMask != 0
 		if viewerRole == "spectator" {
-			if room.Game.Stage == domain.StageFinished {
+			if shown {
 				pv.HoleCards = visibleHoleCards(p.HoleCards, p.RevealMask)
 			} else {
-				pv.HoleCards = []*domain.Card{nil, nil}
+				pv.HoleCards = make([]*domain.Card, len(p.HoleCards))
 			}
 		} else if p.UserID == s.UserID {
-			pv.HoleCards = visibleHoleCards(p.HoleCards, 3)
-		} else if room.Game.Stage == domain.StageFinished {
+			pv.HoleCards = visibleHoleCards(p.HoleCards, domain.FullRevealMask(len(p.HoleCards)))
+		} else if shown {
 			pv.HoleCards = visibleHoleCards(p.HoleCards, p.RevealMask)
 		}
 		players = append(players, pv)
 	}
 
 	resp["game"] = map[string]any{
+		"variant":        room.Game.Variant,
 		"stage":          room.Game.Stage,
 		"pot":            room.Game.Pot,
+		"pots":           potViews(room.Game.Pots),
 		"dealerPos":      room.Game.DealerPos,
 		"smallBlindPos":  room.Game.SmallBlindPos,
 		"bigBlindPos":    room.Game.BigBlindPos,
+		"deadButton":     room.Game.DeadButton,
+		"buttonSeat":     room.ButtonSeat,
 		"turnPos":        room.Game.TurnPos,
 		"communityCards": room.Game.CommunityCards,
+		"bombPot":        room.Game.BombPot,
+		"doubleBoard":    room.Game.DoubleBoard,
+		"secondBoard":    room.Game.SecondBoard,
 		"players":        players,
 		"result":         room.Game.Result,
 		"openBetMin":     room.Game.OpenBetMin,
 		"betMin":         room.Game.BetMin,
+		"lastRaiseSize":  room.Game.LastRaiseSize,
+		"ante":           room.Game.Ante,
+		"straddlePos":    room.Game.StraddlePos,
+		"betting":        room.Game.BettingStructure(),
+		"betUnit":        room.Game.BetUnit(),
+		"raiseCount":     room.Game.RaiseCount,
+		"aggressor":      room.Game.Aggressor,
+		"runItTwice":     room.Game.RunItTwice,
+		"runoutPending":  room.Game.RunoutPending,
+		"runoutVotes":    room.Game.RunoutVotes,
+		"runs":           room.Game.Runs,
+		"runouts":        runoutViews(room.Game.Runouts),
+		"commitment":     room.Game.Commitment,
+		"seed":           room.Game.RevealedSeed(),
 		"actionLogs":     room.Game.ActionLogs,
 	}
 	writeJSON(w, http.StatusOK, resp)
@@ -366,15 +461,267 @@ func (h *GameHandler) Action(w http.ResponseWriter, r *http.Request, s *store.Se
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "stateVersion": room.StateVersion})
 }
 
+// HandHistory exports finished hands of the room as PokerStars hand history
+// text: /hand-history/{handId} for one hand, /hand-history for the session.
+func (h *GameHandler) HandHistory(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	handID := int64(0)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 5 {
+		v, err := strconv.ParseInt(parts[5], 10, 64)
+		if err != nil || v <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid hand id"})
+			return
+		}
+		handID = v
+	}
+	text, err := h.Store.HandHistory(roomID, s.UserID, handID)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "room not found", "hand not found":
+			status = http.StatusNotFound
+		case "user not in room":
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	if handID == 0 {
+		w.Header().Set("Content-Disposition", `attachment; filename="`+roomID+`-hands.txt"`)
+	}
+	writeText(w, http.StatusOK, text)
+}
+
+type replayPlayerView struct {
+	UserID       string         `json:"userId"`
+	Username     string         `json:"username"`
+	SeatIndex    int            `json:"seatIndex"`
+	StartStack   int            `json:"startStack"`
+	Stack        int            `json:"stack"`
+	Won          int            `json:"won"`
+	Folded       bool           `json:"folded"`
+	BestHandName string         `json:"bestHandName,omitempty"`
+	BestLowName  string         `json:"bestLowName,omitempty"`
+	HoleCards    []*domain.Card `json:"holeCards"`
+}
+
+// Replay lists the finished hands the room keeps (/replay) or steps through
+// one of them action by action (/replay/{handId}).
+func (h *GameHandler) Replay(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	handID := int64(0)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 5 {
+		v, err := strconv.ParseInt(parts[5], 10, 64)
+		if err != nil || v <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid hand id"})
+			return
+		}
+		handID = v
+	}
+	hands, err := h.Store.FinishedHands(roomID, s.UserID, handID)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "room not found", "hand not found":
+			status = http.StatusNotFound
+		case "user not in room":
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+
+	if handID == 0 {
+		list := make([]map[string]any, 0, len(hands))
+		for _, fh := range hands {
+			var winners []string
+			if fh.Game.Result != nil {
+				winners = fh.Game.Result.Winners
+			}
+			list = append(list, map[string]any{
+				"handId":      fh.HandID,
+				"startedAtMs": fh.StartedAt.UnixMilli(),
+				"pot":         fh.Game.Pot,
+				"winners":     winners,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"hands": list})
+		return
+	}
+
+	fh := hands[0]
+	g := fh.Game
+	frames, err := handhistory.Replay(g)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	startStacks := map[string]int{}
+	for _, seat := range g.Events[0].Table.Seats {
+		startStacks[seat.UserID] = seat.Stack
+	}
+	players := make([]replayPlayerView, 0, len(g.Players))
+	for _, p := range g.Players {
+		mask := p.RevealMask
+		if p.UserID == s.UserID {
+			mask = domain.FullRevealMask(len(p.HoleCards))
+		}
+		players = append(players, replayPlayerView{
+			UserID:       p.UserID,
+			Username:     p.Username,
+			SeatIndex:    p.SeatIndex,
+			StartStack:   startStacks[p.UserID],
+			Stack:        p.Stack,
+			Won:          p.Won,
+			Folded:       p.Folded,
+			BestHandName: p.BestHandName,
+			BestLowName:  p.BestLowName,
+			HoleCards:    visibleHoleCards(p.HoleCards, mask),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"handId":      fh.HandID,
+		"startedAtMs": fh.StartedAt.UnixMilli(),
+		"variant":     g.Variant,
+		"buttonSeat":  fh.ButtonSeat,
+		"players":     players,
+		"board":       g.CommunityCards,
+		"secondBoard": g.SecondBoard,
+		"runouts":     runoutViews(g.Runouts),
+		"pots":        potViews(g.Pots),
+		"result":      g.Result,
+		"frames":      frames,
+	})
+}
+
+// VerifyDeck rebuilds a finished hand's deck from its revealed seed and checks
+// it against the commitment shown when the hand started.
+func (h *GameHandler) VerifyDeck(w http.ResponseWriter, r *http.Request, _ *store.Session) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	var req verifyDeckReq
+	if err := readJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	variant := domain.Variant(strings.TrimSpace(strings.ToLower(req.Variant)))
+	deck, err := domain.VerifyDeck(strings.TrimSpace(req.Seed), req.Commitment, variant)
+	if deck == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	resp := map[string]any{
+		"valid":    err == nil,
+		"deck":     deck,
+		"deckCode": domain.DeckCode(deck),
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Equity works out each player's share of the pot from known hole cards or
+// weighted ranges: exactly on the turn and river, by sampling before.
+func (h *GameHandler) Equity(w http.ResponseWriter, r *http.Request, _ *store.Session) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	var req equityReq
+	if err := readJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	calc, err := req.toRequest()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	res, err := equity.Calculate(calc)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"players":  res.Seats,
+		"exact":    res.Exact,
+		"outcomes": res.Outcomes,
+	})
+}
+
+func (req equityReq) toRequest() (equity.Request, error) {
+	out := equity.Request{
+		Variant: domain.Variant(strings.TrimSpace(strings.ToLower(req.Variant))),
+		Trials:  req.Trials,
+		Seed:    req.Seed,
+	}
+	if out.Variant == "" {
+		out.Variant = domain.VariantHoldem
+	}
+	var err error
+	if out.Board, err = domain.ParseCards(req.Board); err != nil {
+		return out, err
+	}
+	if out.Dead, err = domain.ParseCards(req.Dead); err != nil {
+		return out, err
+	}
+	for _, p := range req.Players {
+		var seat equity.Seat
+		if seat.Hole, err = domain.ParseCards(p.Cards); err != nil {
+			return out, err
+		}
+		if len(seat.Hole) == 0 {
+			if seat.Range, err = domain.ParseRange(p.Range); err != nil {
+				return out, err
+			}
+		}
+		out.Seats = append(out.Seats, seat)
+	}
+	return out, nil
+}
+
+func potViews(pots []domain.Pot) []domain.Pot {
+	if pots == nil {
+		return []domain.Pot{}
+	}
+	return pots
+}
+
+func runoutViews(runouts []domain.Runout) []domain.Runout {
+	if runouts == nil {
+		return []domain.Runout{}
+	}
+	return runouts
+}
+
 func visibleHoleCards(holeCards []domain.Card, revealMask int) []*domain.Card {
-	visible := []*domain.Card{nil, nil}
-	if len(holeCards) > 0 && (revealMask&1) != 0 {
-		c := holeCards[0]
-		visible[0] = &c
-	}
-	if len(holeCards) > 1 && (revealMask&2) != 0 {
-		c := holeCards[1]
-		visible[1] = &c
+	visible := make([]*domain.Card, len(holeCards))
+	for i := range holeCards {
+		if (revealMask & (1 << i)) != 0 {
+			c := holeCards[i]
+			visible[i] = &c
+		}
 	}
 	return visible
 }
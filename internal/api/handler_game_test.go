@@ -1,11 +1,13 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"texas_yu/internal/domain"
 	"texas_yu/internal/store"
 )
 
@@ -81,6 +83,66 @@ func TestGameHandler_GetState_RevealMaskShowsSelectedCards(t *testing.T) {
 	}
 }
 
+func TestGameHandler_GetState_ShowsExposedAllInHands(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "room", 10, 10, store.RoomRules{RunItTwice: true})
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ms.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := r.Game.Players[r.Game.TurnPos].UserID
+	second := owner.UserID
+	if first == owner.UserID {
+		second = guest.UserID
+	}
+	if r, err = ms.ApplyAction(room.RoomID, first, "", "allin", 0, r.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = ms.ApplyAction(room.RoomID, second, "", "call", 0, r.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Game.RunoutPending {
+		t.Fatalf("expected the hand to wait for the runout vote")
+	}
+
+	h := &GameHandler{Store: ms}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room.RoomID+"/state", nil)
+	w := httptest.NewRecorder()
+	h.GetState(w, req, owner)
+
+	var resp struct {
+		Game struct {
+			Stage   string `json:"stage"`
+			Players []struct {
+				UserID    string         `json:"userId"`
+				Exposed   bool           `json:"exposed"`
+				HoleCards []*domain.Card `json:"holeCards"`
+			} `json:"players"`
+			ActionLogs []domain.ActionLog `json:"actionLogs"`
+		} `json:"game"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Game.Stage != string(domain.StagePreflop) {
+		t.Fatalf("expected the hand still preflop, got %s", resp.Game.Stage)
+	}
+	for _, p := range resp.Game.Players {
+		if !p.Exposed || len(p.HoleCards) != 2 || p.HoleCards[0] == nil || p.HoleCards[1] == nil {
+			t.Fatalf("expected %s face up to everyone, got %+v", p.UserID, p)
+		}
+	}
+	logs := resp.Game.ActionLogs
+	if n := len(logs); n < 2 || logs[n-2].UserID != first || logs[n-2].Action != domain.ActionShow || logs[n-1].Action != domain.ActionShow {
+		t.Fatalf("expected the all-in player shown first, got %+v", logs)
+	}
+}
+
 func TestGameHandler_ActionRevealValidationAndVersionConflict(t *testing.T) {
 	ms := store.NewMemoryStore()
 	owner := ms.CreateSession("owner")
@@ -420,3 +482,328 @@ func TestGameHandler_GetStateIncludesChipRefreshVote(t *testing.T) {
 		t.Fatalf("expected vote decisions in state body=%s", body)
 	}
 }
+
+func TestGameHandler_GetStateIncludesPotsWithWinners(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "room", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.StartGame(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := ms.GetRoom(room.RoomID)
+	turnUser := r.Game.Players[r.Game.TurnPos].UserID
+	if _, err := ms.ApplyAction(room.RoomID, turnUser, "pots-fold", "fold", 0, r.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &GameHandler{Store: ms}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room.RoomID+"/state", nil)
+	w := httptest.NewRecorder()
+	h.GetState(w, req, owner)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"pots":[{"amount":10,"eligibleUserIds":[`) {
+		t.Fatalf("expected single main pot without the uncalled big blind in state body=%s", body)
+	}
+	if !strings.Contains(body, `"winnerUserIds":["`) {
+		t.Fatalf("expected pot winners in state body=%s", body)
+	}
+}
+
+func TestGameHandler_GetStatePLOShowsFourCardsAndPotLimit(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "room", 10, 10, store.RoomRules{Variant: domain.VariantPLO})
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.StartGame(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	r, _ := ms.GetRoom(room.RoomID)
+	turn := r.Game.Players[r.Game.TurnPos]
+	viewer := owner
+	if turn.UserID == guest.UserID {
+		viewer = guest
+	}
+
+	h := &GameHandler{Store: ms}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room.RoomID+"/state", nil)
+	w := httptest.NewRecorder()
+	h.GetState(w, req, viewer)
+
+	var resp struct {
+		Game struct {
+			Variant string           `json:"variant"`
+			Players []gamePlayerView `json:"players"`
+		} `json:"game"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Game.Variant != "plo" {
+		t.Fatalf("expected plo variant, got %q", resp.Game.Variant)
+	}
+	for _, p := range resp.Game.Players {
+		if p.UserID != viewer.UserID {
+			continue
+		}
+		if len(p.HoleCards) != 4 || p.HoleCards[3] == nil {
+			t.Fatalf("expected four visible hole cards, got %+v", p.HoleCards)
+		}
+		// heads-up button: call 5, then raise the 20 in the pot
+		if !p.CanRaise || p.MaxRaise != 25 || p.CanAllIn {
+			t.Fatalf("expected pot-limit raise cap 25 without all-in, got %+v", p)
+		}
+	}
+}
+
+func TestGameHandler_SeedRevealedAfterHandAndVerifies(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "room", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	started, err := ms.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &GameHandler{Store: ms}
+	type fairnessState struct {
+		Game struct {
+			Commitment string `json:"commitment"`
+			Seed       string `json:"seed"`
+		} `json:"game"`
+	}
+	readState := func() fairnessState {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room.RoomID+"/state", nil)
+		w := httptest.NewRecorder()
+		h.GetState(w, req, owner)
+		var resp fairnessState
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	during := readState()
+	if during.Game.Commitment == "" || during.Game.Seed != "" {
+		t.Fatalf("expected commitment without seed during the hand, got %+v", during.Game)
+	}
+
+	turn := started.Game.Players[started.Game.TurnPos].UserID
+	if _, err := ms.ApplyAction(room.RoomID, turn, "", "fold", 0, started.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	after := readState()
+	if after.Game.Seed == "" || after.Game.Commitment != during.Game.Commitment {
+		t.Fatalf("expected seed revealed under the same commitment, got %+v", after.Game)
+	}
+
+	body := `{"seed":"` + after.Game.Seed + `","commitment":"` + after.Game.Commitment + `","variant":"holdem"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/fairness/verify", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.VerifyDeck(w, req, owner)
+	var verify struct {
+		Valid bool          `json:"valid"`
+		Deck  []domain.Card `json:"deck"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &verify); err != nil {
+		t.Fatal(err)
+	}
+	if !verify.Valid || len(verify.Deck) != 52 {
+		t.Fatalf("expected verified 52-card deck, got %s", w.Body.String())
+	}
+}
+
+func TestGameHandler_EquityEnumeratesTheTurn(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	h := &GameHandler{Store: ms}
+
+	body := `{"board":["2h","7h","9c","Js"],"players":[{"cards":["Ah","Kh"]},{"range":"QsQd, 2s2d:0.5"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tools/equity", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.Equity(w, req, owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Players []struct {
+			Equity float64 `json:"equity"`
+		} `json:"players"`
+		Exact    bool `json:"exact"`
+		Outcomes int  `json:"outcomes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Exact || resp.Outcomes != 88 || len(resp.Players) != 2 {
+		t.Fatalf("expected exact equity over two holdings and 44 rivers, got %s", w.Body.String())
+	}
+	if sum := resp.Players[0].Equity + resp.Players[1].Equity; sum < 0.999 || sum > 1.001 {
+		t.Fatalf("expected equities to add up to one, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/tools/equity", strings.NewReader(`{"players":[{"cards":["Ah","Kh"]},{"cards":["Ah","Qd"]}]}`))
+	w = httptest.NewRecorder()
+	h.Equity(w, req, owner)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected duplicate card to be rejected, got %d", w.Code)
+	}
+}
+
+func TestGameHandler_HandHistoryExportsFinishedHands(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "history", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ms.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hand := 1; hand <= 2; hand++ {
+		turn := r.Game.Players[r.Game.TurnPos].UserID
+		if r, err = ms.ApplyAction(room.RoomID, turn, "", "fold", 0, r.StateVersion); err != nil {
+			t.Fatal(err)
+		}
+		if hand == 1 {
+			if r, err = ms.NextHand(room.RoomID, owner.UserID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var guestCards, ownerCards string
+	for _, p := range r.Game.Players {
+		text := ""
+		for i, c := range p.HoleCards {
+			if i > 0 {
+				text += " "
+			}
+			text += string("??23456789TJQKA"[c.Rank]) + string("cdhs"[c.Suit])
+		}
+		if p.UserID == guest.UserID {
+			guestCards = text
+		} else {
+			ownerCards = text
+		}
+	}
+
+	h := &GameHandler{Store: ms}
+	get := func(path string, s *store.Session) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room.RoomID+"/hand-history"+path, nil)
+		w := httptest.NewRecorder()
+		h.HandHistory(w, req, s)
+		return w
+	}
+
+	w := get("/2", guest)
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("expected plain text, got %d %s", w.Code, body)
+	}
+	if !strings.HasPrefix(body, "PokerStars Hand #2:") || !strings.Contains(body, "Dealt to guest ["+guestCards+"]") {
+		t.Fatalf("expected hand #2 with the guest's cards, got %s", body)
+	}
+	if strings.Contains(body, ownerCards) {
+		t.Fatalf("expected the owner's cards hidden from the guest, got %s", body)
+	}
+
+	w = get("", owner)
+	if w.Code != http.StatusOK || strings.Count(w.Body.String(), "PokerStars Hand #") != 2 {
+		t.Fatalf("expected both hands in the session export, got %d %s", w.Code, w.Body.String())
+	}
+	if w = get("/9", owner); w.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown hand to be 404, got %d", w.Code)
+	}
+	if w = get("", ms.CreateSession("outsider")); w.Code != http.StatusForbidden {
+		t.Fatalf("expected outsiders to be refused, got %d", w.Code)
+	}
+}
+
+func TestGameHandler_ReplayStepsThroughKeptHands(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "replay", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	r, err := ms.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := r.Game.Players[r.Game.TurnPos].UserID
+	if r, err = ms.ApplyAction(room.RoomID, turn, "", "fold", 0, r.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &GameHandler{Store: ms}
+	get := func(path string, s *store.Session) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room.RoomID+"/replay"+path, nil)
+		w := httptest.NewRecorder()
+		h.Replay(w, req, s)
+		return w
+	}
+
+	w := get("", guest)
+	var list struct {
+		Hands []struct {
+			HandID int64 `json:"handId"`
+		} `json:"hands"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK || len(list.Hands) != 1 || list.Hands[0].HandID != 1 {
+		t.Fatalf("expected hand 1 listed, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = get("/1", guest)
+	var replay struct {
+		Players []struct {
+			UserID    string         `json:"userId"`
+			HoleCards []*domain.Card `json:"holeCards"`
+		} `json:"players"`
+		Frames []struct {
+			Turn   string         `json:"turn"`
+			Action string         `json:"action"`
+			Pot    int            `json:"pot"`
+			Stacks map[string]int `json:"stacks"`
+		} `json:"frames"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &replay); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected a replay, got %d %s", w.Code, w.Body.String())
+	}
+	if len(replay.Frames) != 3 {
+		t.Fatalf("expected blinds and the fold as frames, got %+v", replay.Frames)
+	}
+	if last := replay.Frames[2]; last.Turn != turn || last.Action != "fold" || last.Pot != 15 {
+		t.Fatalf("unexpected last frame %+v", last)
+	}
+	for _, p := range replay.Players {
+		hidden := p.HoleCards[0] == nil && p.HoleCards[1] == nil
+		if p.UserID == guest.UserID && hidden {
+			t.Fatalf("expected the guest to see their own cards")
+		}
+		if p.UserID == owner.UserID && !hidden {
+			t.Fatalf("expected the owner's mucked cards hidden from the guest, got %+v", p.HoleCards)
+		}
+	}
+
+	if w = get("/2", guest); w.Code != http.StatusNotFound {
+		t.Fatalf("expected an unplayed hand to be 404, got %d", w.Code)
+	}
+	if w = get("/x", guest); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a bad hand id to be 400, got %d", w.Code)
+	}
+	if w = get("", ms.CreateSession("outsider")); w.Code != http.StatusForbidden {
+		t.Fatalf("expected outsiders to be refused, got %d", w.Code)
+	}
+}
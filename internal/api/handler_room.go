@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"texas_yu/internal/domain"
 	"texas_yu/internal/store"
 )
 
@@ -16,6 +19,39 @@ type createRoomReq struct {
 	Name       string `json:"name"`
 	OpenBetMin int    `json:"openBetMin"`
 	BetMin     int    `json:"betMin"`
+	Variant    string `json:"variant"`
+	AnteMode   string `json:"anteMode"`
+	Ante       int    `json:"ante"`
+	Straddle   bool   `json:"straddle"`
+	FixedLimit bool   `json:"fixedLimit"`
+	SmallBet   int    `json:"smallBet"`
+	BigBet     int    `json:"bigBet"`
+	RunItTwice bool   `json:"runItTwice"`
+	// ActionSeconds and TimeBankSeconds set the per-decision clock.
+	ActionSeconds   int `json:"actionSeconds"`
+	TimeBankSeconds int `json:"timeBankSeconds"`
+	// SitOutHands is how many hands a player may sit out before losing
+	// their seat; 0 keeps the default.
+	SitOutHands int `json:"sitOutHands"`
+	// Tournament, when set, makes the room a single-table tournament.
+	Tournament *store.TournamentRules `json:"tournament"`
+	// MinBuyIn and MaxBuyIn bound cash-game buy-ins; 0 keeps the defaults.
+	MinBuyIn int `json:"minBuyIn"`
+	MaxBuyIn int `json:"maxBuyIn"`
+	// Rake is the house's cut of each pot; tournaments take none.
+	Rake domain.RakeRules `json:"rake"`
+	// BombPotEvery deals every so many hands as a bomb pot with BombPot's
+	// ante and board.
+	BombPotEvery int           `json:"bombPotEvery"`
+	BombPot      store.BombPot `json:"bombPot"`
+}
+
+type joinRoomReq struct {
+	BuyIn int `json:"buyIn"`
+}
+
+type chipsReq struct {
+	Amount int `json:"amount"`
 }
 
 type addAIReq struct {
@@ -26,6 +62,10 @@ type aiManagedReq struct {
 	Enabled bool `json:"enabled"`
 }
 
+type postBlindReq struct {
+	Enabled bool `json:"enabled"`
+}
+
 type chipRefreshVoteReq struct {
 	Decision string `json:"decision"`
 }
@@ -69,7 +109,89 @@ func (h *RoomHandler) CreateRoom(w http.ResponseWriter, r *http.Request, s *stor
 	if req.BetMin <= 0 {
 		req.BetMin = 10
 	}
-	room := h.Store.CreateRoom(s, req.Name, req.OpenBetMin, req.BetMin)
+	variant := domain.Variant(strings.TrimSpace(strings.ToLower(req.Variant)))
+	if !domain.ValidVariant(variant) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid variant"})
+		return
+	}
+	anteMode := domain.AnteMode(strings.TrimSpace(strings.ToLower(req.AnteMode)))
+	if !domain.ValidAnteMode(anteMode) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid ante mode"})
+		return
+	}
+	if req.Ante < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "ante must not be negative"})
+		return
+	}
+	if anteMode == domain.AntePerPlayer && req.Ante == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "per-player ante needs a positive ante"})
+		return
+	}
+	if req.FixedLimit && domain.IsPotLimit(variant) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "fixed limit is not available for pot-limit variants"})
+		return
+	}
+	if req.SmallBet < 0 || req.BigBet < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "limit bet sizes must not be negative"})
+		return
+	}
+	if req.SmallBet > 0 && req.BigBet > 0 && req.BigBet < req.SmallBet {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "big bet must not be smaller than small bet"})
+		return
+	}
+	if req.ActionSeconds < 0 || req.TimeBankSeconds < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "action clock must not be negative"})
+		return
+	}
+	if req.TimeBankSeconds > 0 && req.ActionSeconds == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "time bank needs an action clock"})
+		return
+	}
+	if req.SitOutHands < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "sit out hands must not be negative"})
+		return
+	}
+	if req.MinBuyIn < 0 || req.MaxBuyIn < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "buy-in must not be negative"})
+		return
+	}
+	if req.MaxBuyIn > 0 && (req.MaxBuyIn < req.MinBuyIn || req.MaxBuyIn < req.OpenBetMin) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "max buy-in must cover the min buy-in and the big blind"})
+		return
+	}
+	if err := req.Rake.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	if req.BombPotEvery < 0 || req.BombPot.Ante < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "bomb pot settings must not be negative"})
+		return
+	}
+	if req.Tournament != nil {
+		if err := req.Tournament.Validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+			return
+		}
+	}
+	room := h.Store.CreateRoom(s, req.Name, req.OpenBetMin, req.BetMin, store.RoomRules{
+		Variant:         variant,
+		AnteMode:        anteMode,
+		Ante:            req.Ante,
+		Straddle:        req.Straddle,
+		FixedLimit:      req.FixedLimit,
+		SmallBet:        req.SmallBet,
+		BigBet:          req.BigBet,
+		RunItTwice:      req.RunItTwice,
+		ActionSeconds:   req.ActionSeconds,
+		TimeBankSeconds: req.TimeBankSeconds,
+		SitOutHands:     req.SitOutHands,
+		TournamentRules: req.Tournament,
+		MinBuyIn:        req.MinBuyIn,
+		MaxBuyIn:        req.MaxBuyIn,
+		Rake:            req.Rake,
+		BombPotEvery:    req.BombPotEvery,
+		BombPot:         req.BombPot,
+	})
 	writeJSON(w, http.StatusOK, room)
 }
 
@@ -99,7 +221,13 @@ func (h *RoomHandler) JoinRoom(w http.ResponseWriter, r *http.Request, s *store.
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
 		return
 	}
-	room, err := h.Store.JoinRoom(roomID, s)
+	// The body is optional; without one the player buys in for the maximum.
+	var req joinRoomReq
+	if err := readJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	room, err := h.Store.JoinRoom(roomID, s, req.BuyIn)
 	if err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
 		return
@@ -252,6 +380,71 @@ func (h *RoomHandler) ToggleAIManaged(w http.ResponseWriter, r *http.Request, s
 	writeJSON(w, http.StatusOK, room)
 }
 
+func (h *RoomHandler) SitOut(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.setSittingOut(w, r, s, true)
+}
+
+func (h *RoomHandler) SitIn(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.setSittingOut(w, r, s, false)
+}
+
+func (h *RoomHandler) setSittingOut(w http.ResponseWriter, r *http.Request, s *store.Session, sittingOut bool) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	var (
+		room *store.Room
+		err  error
+	)
+	if sittingOut {
+		room, err = h.Store.SitOut(roomID, s.UserID)
+	} else {
+		room, err = h.Store.SitIn(roomID, s.UserID)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "spectator is read-only" || err.Error() == "user not in room" {
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (h *RoomHandler) SetPostBlind(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	var req postBlindReq
+	if err := readJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	room, err := h.Store.SetPostBlind(roomID, s.UserID, req.Enabled)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "spectator is read-only" || err.Error() == "user not in room" {
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
 func (h *RoomHandler) StartChipRefreshVote(w http.ResponseWriter, r *http.Request, s *store.Session) {
 	if r.Method != http.MethodPost {
 		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
@@ -300,3 +493,108 @@ func (h *RoomHandler) CastChipRefreshVote(w http.ResponseWriter, r *http.Request
 	}
 	writeJSON(w, http.StatusOK, room)
 }
+
+func (h *RoomHandler) TopUp(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.addChips(w, r, s, h.Store.TopUp)
+}
+
+func (h *RoomHandler) Rebuy(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.addChips(w, r, s, h.Store.Rebuy)
+}
+
+func (h *RoomHandler) addChips(w http.ResponseWriter, r *http.Request, s *store.Session, add func(roomID, userID string, amount int) (*store.Room, error)) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	var req chipsReq
+	if err := readJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	room, err := add(roomID, s.UserID, req.Amount)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "spectator is read-only" || err.Error() == "user not in room" {
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+// BombPot calls a bomb pot for the next hand (POST) or takes it back
+// (DELETE). The body is optional; without one the room's ante and board are
+// used.
+func (h *RoomHandler) BombPot(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	var (
+		room *store.Room
+		err  error
+	)
+	if r.Method == http.MethodDelete {
+		room, err = h.Store.CancelBombPot(roomID, s.UserID)
+	} else {
+		var req store.BombPot
+		if err := readJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+			return
+		}
+		room, err = h.Store.ScheduleBombPot(roomID, s.UserID, req)
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "room not found":
+			status = http.StatusNotFound
+		case "spectator is read-only", "user not in room":
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (h *RoomHandler) ChipLedger(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	roomID := roomIDFromPath(r.URL.Path)
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid room id"})
+		return
+	}
+	entries, totals, err := h.Store.ChipLedger(roomID, s.UserID)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case "room not found":
+			status = http.StatusNotFound
+		case "user not in room":
+			status = http.StatusForbidden
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error()})
+		return
+	}
+	rake := 0
+	for _, t := range totals {
+		rake += t.Rake
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": entries, "totals": totals, "rakeTotal": rake})
+}
@@ -2,12 +2,14 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"texas_yu/internal/ai"
+	"texas_yu/internal/domain"
 	"texas_yu/internal/store"
 )
 
@@ -284,3 +286,280 @@ func TestRoomHandler_ChipRefreshVote_AllowedWhenHandFinished(t *testing.T) {
 		t.Fatalf("expected owner start vote success after finished hand, got %d body=%s", startW.Code, startW.Body.String())
 	}
 }
+
+func TestRoomHandler_CreateRoomWithAnteAndStraddle(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	h := &RoomHandler{Store: ms}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"home","openBetMin":20,"betMin":20,"anteMode":"big_blind","straddle":true}`))
+	w := httptest.NewRecorder()
+	h.CreateRoom(w, req, owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected create success, got %d body=%s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"anteMode":"big_blind"`) || !strings.Contains(body, `"straddle":true`) {
+		t.Fatalf("expected room rules in response, got %s", body)
+	}
+
+	badReq := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"home","anteMode":"sometimes"}`))
+	badW := httptest.NewRecorder()
+	h.CreateRoom(badW, badReq, owner)
+	if badW.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid ante mode rejected, got %d", badW.Code)
+	}
+}
+
+func TestRoomHandler_CreateFixedLimitRoom(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	h := &RoomHandler{Store: ms}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"limit","openBetMin":20,"betMin":20,"fixedLimit":true}`))
+	w := httptest.NewRecorder()
+	h.CreateRoom(w, req, owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected create success, got %d body=%s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"fixedLimit":true`) || !strings.Contains(body, `"smallBet":20`) || !strings.Contains(body, `"bigBet":40`) {
+		t.Fatalf("expected default 20/40 limit in response, got %s", body)
+	}
+
+	badReq := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"limit","variant":"plo","fixedLimit":true}`))
+	badW := httptest.NewRecorder()
+	h.CreateRoom(badW, badReq, owner)
+	if badW.Code != http.StatusBadRequest {
+		t.Fatalf("expected fixed-limit plo rejected, got %d", badW.Code)
+	}
+}
+
+func TestRoomHandler_CreateTournamentRoom(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	h := &RoomHandler{Store: ms}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"sng","tournament":{"startingStack":1500,"levels":[{"bigBlind":20},{"bigBlind":40,"ante":5}],"levelHands":10,"buyIn":50,"payouts":[65,35]}}`))
+	w := httptest.NewRecorder()
+	h.CreateRoom(w, req, owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected create success, got %d body=%s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"tournamentRules":{"startingStack":1500`) || !strings.Contains(body, `"openBetMin":20`) {
+		t.Fatalf("expected tournament rules and first level blinds in response, got %s", body)
+	}
+
+	badReq := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"sng","tournament":{"startingStack":1500,"levels":[{"bigBlind":20}],"payouts":[50,40]}}`))
+	badW := httptest.NewRecorder()
+	h.CreateRoom(badW, badReq, owner)
+	if badW.Code != http.StatusBadRequest || !strings.Contains(badW.Body.String(), "payout") {
+		t.Fatalf("expected payouts not adding up to 100 rejected, got %d %s", badW.Code, badW.Body.String())
+	}
+}
+
+func TestRoomHandler_JoinMidHandHidesTheGame(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	late := ms.CreateSession("late")
+	room := ms.CreateRoom(owner, "room", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ms.StartGame(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	h := &RoomHandler{Store: ms}
+
+	w := httptest.NewRecorder()
+	h.JoinRoom(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/join", strings.NewReader(`{}`)), late)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected join success mid-hand, got %d body=%s", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp["Game"]; ok || strings.Contains(w.Body.String(), "HoleCards") {
+		t.Fatalf("expected no hand in the join response, got %s", w.Body.String())
+	}
+}
+
+func TestRoomHandler_ResponsesKeepTheSeedSecret(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "room", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	h := &RoomHandler{Store: ms}
+	noSeed := func(what string, body []byte, g *domain.GameState) {
+		t.Helper()
+		if g.Seed == "" || strings.Contains(string(body), g.Seed) {
+			t.Fatalf("expected %s to hide seed %q, got %s", what, g.Seed, body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.StartRoom(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/start", nil), owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected start success, got %d body=%s", w.Code, w.Body.String())
+	}
+	r, _ := ms.GetRoom(room.RoomID)
+	g := r.Game
+	noSeed("the start response", w.Body.Bytes(), g)
+	live, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noSeed("the game mid-hand", live, g)
+
+	turn := g.Players[g.TurnPos].UserID
+	r, err = ms.ApplyAction(room.RoomID, turn, "", "fold", 0, r.StateVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finished, err := json.Marshal(r.Game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(finished), g.Seed) {
+		t.Fatalf("expected the finished hand to reveal its seed, got %s", finished)
+	}
+
+	w = httptest.NewRecorder()
+	h.NextHand(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/next-hand", nil), owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected next hand success, got %d body=%s", w.Code, w.Body.String())
+	}
+	r, _ = ms.GetRoom(room.RoomID)
+	noSeed("the next hand response", w.Body.Bytes(), r.Game)
+}
+
+func TestRoomHandler_JoinWithBuyInAndLedger(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	h := &RoomHandler{Store: ms}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"name":"cash","openBetMin":10,"betMin":10,"minBuyIn":200,"maxBuyIn":1000}`))
+	w := httptest.NewRecorder()
+	h.CreateRoom(w, req, owner)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"minBuyIn":200`) {
+		t.Fatalf("expected create success with buy-in limits, got %d body=%s", w.Code, w.Body.String())
+	}
+	var created store.Room
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	room := created.RoomID
+
+	bad := httptest.NewRecorder()
+	h.JoinRoom(bad, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room+"/join", strings.NewReader(`{"buyIn":5000}`)), guest)
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("expected a buy-in over the max refused, got %d", bad.Code)
+	}
+	joinW := httptest.NewRecorder()
+	h.JoinRoom(joinW, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room+"/join", strings.NewReader(`{"buyIn":300}`)), guest)
+	if joinW.Code != http.StatusOK {
+		t.Fatalf("expected join success, got %d body=%s", joinW.Code, joinW.Body.String())
+	}
+	topW := httptest.NewRecorder()
+	h.TopUp(topW, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room+"/top-up", strings.NewReader(`{"amount":200}`)), guest)
+	if topW.Code != http.StatusOK {
+		t.Fatalf("expected top-up success, got %d body=%s", topW.Code, topW.Body.String())
+	}
+
+	ledgerW := httptest.NewRecorder()
+	h.ChipLedger(ledgerW, httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+room+"/ledger", nil), guest)
+	var ledger struct {
+		Entries []store.ChipLedgerEntry `json:"entries"`
+		Totals  []store.ChipTotals      `json:"totals"`
+	}
+	if err := json.Unmarshal(ledgerW.Body.Bytes(), &ledger); err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger.Entries) != 3 || ledger.Totals[1].BoughtIn != 500 || ledger.Totals[1].Stack != 500 {
+		t.Fatalf("expected buy-ins and the top-up in the ledger, got %s", ledgerW.Body.String())
+	}
+}
+
+func TestRoomHandler_CreateRoomWithRake(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	h := &RoomHandler{Store: ms}
+
+	bad := httptest.NewRecorder()
+	h.CreateRoom(bad, httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"openBetMin":10,"betMin":10,"rake":{"percent":12}}`)), owner)
+	if bad.Code != http.StatusBadRequest {
+		t.Fatalf("expected a rake over 10%% refused, got %d", bad.Code)
+	}
+	w := httptest.NewRecorder()
+	h.CreateRoom(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms", strings.NewReader(`{"openBetMin":10,"betMin":10,"rake":{"percent":5,"cap":30,"headsUpCap":10,"noFlopNoDrop":true}}`)), owner)
+	var room store.Room
+	if err := json.Unmarshal(w.Body.Bytes(), &room); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || room.Rake.Percent != 5 || room.Rake.HeadsUpCap != 10 || !room.Rake.NoFlopNoDrop {
+		t.Fatalf("expected the rake rules kept, got %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestRoomHandler_CallAndCancelBombPot(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	guest := ms.CreateSession("guest")
+	room := ms.CreateRoom(owner, "home", 10, 10)
+	if _, err := ms.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	h := &RoomHandler{Store: ms}
+
+	guestW := httptest.NewRecorder()
+	h.BombPot(guestW, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/bomb-pot", nil), guest)
+	if guestW.Code != http.StatusBadRequest {
+		t.Fatalf("expected only the owner to call a bomb pot, got %d", guestW.Code)
+	}
+	w := httptest.NewRecorder()
+	h.BombPot(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/bomb-pot", strings.NewReader(`{"ante":50,"doubleBoard":true}`)), owner)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"nextBombPot":{"ante":50,"doubleBoard":true}`) {
+		t.Fatalf("expected the bomb pot scheduled, got %d body=%s", w.Code, w.Body.String())
+	}
+	cancelW := httptest.NewRecorder()
+	h.BombPot(cancelW, httptest.NewRequest(http.MethodDelete, "/api/v1/rooms/"+room.RoomID+"/bomb-pot", nil), owner)
+	if cancelW.Code != http.StatusOK || strings.Contains(cancelW.Body.String(), "nextBombPot") {
+		t.Fatalf("expected the bomb pot cancelled, got %d body=%s", cancelW.Code, cancelW.Body.String())
+	}
+}
+
+func TestRoomHandler_SitOutAndSitIn(t *testing.T) {
+	ms := store.NewMemoryStore()
+	owner := ms.CreateSession("owner")
+	watcher := ms.CreateSession("watcher")
+	room := ms.CreateRoom(owner, "r", 10, 10)
+	if _, err := ms.SpectateRoom(room.RoomID, watcher); err != nil {
+		t.Fatal(err)
+	}
+	h := &RoomHandler{Store: ms}
+
+	w := httptest.NewRecorder()
+	h.SitOut(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/sit-out", nil), owner)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"sittingOut":true`) {
+		t.Fatalf("expected sit out success, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.SitIn(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/sit-in", nil), owner)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"sittingOut":true`) {
+		t.Fatalf("expected sit in success, got %d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.SitOut(w, httptest.NewRequest(http.MethodPost, "/api/v1/rooms/"+room.RoomID+"/sit-out", nil), watcher)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected spectator forbidden, got %d", w.Code)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"texas_yu/internal/store"
+)
+
+type TournamentHandler struct {
+	Store *store.MemoryStore
+}
+
+type createTournamentReq struct {
+	Name      string                `json:"name"`
+	TableSize int                   `json:"tableSize"`
+	StartAtMs int64                 `json:"startAtMs"`
+	Rules     store.TournamentRules `json:"rules"`
+}
+
+func (h *TournamentHandler) ListTournaments(w http.ResponseWriter, r *http.Request, _ *store.Session) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tournaments": h.Store.ListMultiTableTournaments()})
+}
+
+func (h *TournamentHandler) CreateTournament(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	var req createTournamentReq
+	if err := readJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid json"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = "Tournament"
+	}
+	if req.TableSize == 0 {
+		req.TableSize = store.MaxTournamentTableSize
+	}
+	if req.StartAtMs < 0 || (req.StartAtMs > 0 && req.StartAtMs < h.Store.Now().UnixMilli()) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "start time must be in the future"})
+		return
+	}
+	t, err := h.Store.CreateMultiTableTournament(s, req.Name, req.Rules, req.TableSize, req.StartAtMs)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *TournamentHandler) GetTournament(w http.ResponseWriter, r *http.Request, _ *store.Session) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	t, ok := h.Store.GetMultiTableTournament(tournamentIDFromPath(r.URL.Path))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": "tournament not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *TournamentHandler) Register(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.lobbyAction(w, r, func(id string) (*store.MultiTableTournament, error) {
+		return h.Store.RegisterTournament(id, s)
+	})
+}
+
+func (h *TournamentHandler) Unregister(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.lobbyAction(w, r, func(id string) (*store.MultiTableTournament, error) {
+		return h.Store.UnregisterTournament(id, s.UserID)
+	})
+}
+
+func (h *TournamentHandler) Start(w http.ResponseWriter, r *http.Request, s *store.Session) {
+	h.lobbyAction(w, r, func(id string) (*store.MultiTableTournament, error) {
+		return h.Store.StartMultiTableTournament(id, s.UserID)
+	})
+}
+
+func (h *TournamentHandler) lobbyAction(w http.ResponseWriter, r *http.Request, do func(id string) (*store.MultiTableTournament, error)) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"})
+		return
+	}
+	id := tournamentIDFromPath(r.URL.Path)
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid tournament id"})
+		return
+	}
+	t, err := do(id)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func tournamentIDFromPath(path string) string {
+	return roomIDFromPath(path)
+}
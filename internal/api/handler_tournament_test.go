@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"texas_yu/internal/store"
+)
+
+func TestTournamentHandler_RegisterStartAndShowField(t *testing.T) {
+	ms := store.NewMemoryStore()
+	director := ms.CreateSession("director")
+	h := &TournamentHandler{Store: ms}
+	gameH := &GameHandler{Store: ms}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tournaments", strings.NewReader(`{"name":"sunday","tableSize":3,"rules":{"startingStack":1000,"levels":[{"bigBlind":20},{"bigBlind":40}],"levelMinutes":10}}`))
+	w := httptest.NewRecorder()
+	h.CreateTournament(w, req, director)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected create success, got %d body=%s", w.Code, w.Body.String())
+	}
+	var mt store.MultiTableTournament
+	if err := json.Unmarshal(w.Body.Bytes(), &mt); err != nil {
+		t.Fatal(err)
+	}
+
+	players := make([]*store.Session, 5)
+	for i := range players {
+		players[i] = ms.CreateSession("player")
+		regW := httptest.NewRecorder()
+		h.Register(regW, httptest.NewRequest(http.MethodPost, "/api/v1/tournaments/"+mt.TournamentID+"/register", nil), players[i])
+		if regW.Code != http.StatusOK {
+			t.Fatalf("expected register success, got %d body=%s", regW.Code, regW.Body.String())
+		}
+	}
+	startW := httptest.NewRecorder()
+	h.Start(startW, httptest.NewRequest(http.MethodPost, "/api/v1/tournaments/"+mt.TournamentID+"/start", nil), director)
+	if startW.Code != http.StatusOK {
+		t.Fatalf("expected start success, got %d body=%s", startW.Code, startW.Body.String())
+	}
+	if err := json.Unmarshal(startW.Body.Bytes(), &mt); err != nil {
+		t.Fatal(err)
+	}
+	if len(mt.Tables) != 2 || len(mt.Field) != 5 {
+		t.Fatalf("expected five players at two tables, got %+v", mt)
+	}
+
+	var seat store.TournamentFieldEntry
+	for _, p := range mt.Field {
+		if p.UserID == players[0].UserID {
+			seat = p
+		}
+	}
+	stateW := httptest.NewRecorder()
+	gameH.GetState(stateW, httptest.NewRequest(http.MethodGet, "/api/v1/rooms/"+seat.RoomID+"/state", nil), players[0])
+	var state struct {
+		Tournament store.TournamentState       `json:"tournament"`
+		MultiTable *store.MultiTableTournament `json:"multiTable"`
+	}
+	if err := json.Unmarshal(stateW.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Tournament.TournamentID != mt.TournamentID || state.Tournament.Table != seat.Table || state.MultiTable == nil || len(state.MultiTable.Field) != 5 {
+		t.Fatalf("expected the table state to show the field, got %s", stateW.Body.String())
+	}
+
+	lateW := httptest.NewRecorder()
+	h.Register(lateW, httptest.NewRequest(http.MethodPost, "/api/v1/tournaments/"+mt.TournamentID+"/register", nil), ms.CreateSession("late"))
+	if lateW.Code != http.StatusBadRequest {
+		t.Fatalf("expected registration closed after the start, got %d", lateW.Code)
+	}
+}
+
+func TestTournamentHandler_CreateRejectsBadRules(t *testing.T) {
+	ms := store.NewMemoryStore()
+	director := ms.CreateSession("director")
+	h := &TournamentHandler{Store: ms}
+	for _, body := range []string{
+		`{"tableSize":2,"rules":{"startingStack":1000,"levels":[{"bigBlind":20}]}}`,
+		`{"tableSize":6,"rules":{"startingStack":1000,"levels":[{"bigBlind":20},{"bigBlind":40}],"levelHands":10}}`,
+		`{"tableSize":6,"startAtMs":1,"rules":{"startingStack":1000,"levels":[{"bigBlind":20}]}}`,
+	} {
+		w := httptest.NewRecorder()
+		h.CreateTournament(w, httptest.NewRequest(http.MethodPost, "/api/v1/tournaments", strings.NewReader(body)), director)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s rejected, got %d", body, w.Code)
+		}
+	}
+}
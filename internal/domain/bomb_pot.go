@@ -0,0 +1,30 @@
+package domain
+
+// Bomb pot antes are live, so a short stack only wins what it matched.
+func (g *GameState) dealBombPot() {
+	g.Ante = g.BombPot
+	g.emitHandStart(SmallBlindFor(g.OpenBetMin), g.OpenBetMin, g.BombPot)
+	for _, p := range g.Players {
+		g.postAnte(p, g.BombPot, false)
+	}
+	for _, p := range g.Players {
+		g.emit(Event{Type: EventHoleCards, UserID: p.UserID, Username: p.Username, Cards: append([]Card(nil), p.HoleCards...)})
+	}
+	g.RoundBet = 0
+	g.RaiseCount = 0
+	g.advanceStage()
+}
+
+func (g *GameState) emitStreet(dealt int) {
+	ev := Event{Type: EventStreet, Cards: append([]Card(nil), g.CommunityCards[dealt:]...), Board: append([]Card(nil), g.CommunityCards...)}
+	if !g.DoubleBoard {
+		g.emit(ev)
+		return
+	}
+	ev.Run = 1
+	g.emit(ev)
+	for len(g.SecondBoard) < len(g.CommunityCards) {
+		g.SecondBoard = append(g.SecondBoard, g.draw())
+	}
+	g.emit(Event{Type: EventStreet, Cards: append([]Card(nil), g.SecondBoard[dealt:]...), Board: append([]Card(nil), g.SecondBoard...), Run: 2})
+}
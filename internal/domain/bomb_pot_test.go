@@ -0,0 +1,93 @@
+package domain
+
+import "testing"
+
+func threePlayers() []*GamePlayer {
+	return []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 200},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 200},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 200},
+	}
+}
+
+func TestBombPot_AntesAndStartsOnTheFlop(t *testing.T) {
+	g, err := NewGame(threePlayers(), 0, 10, 10, GameOptions{BombPot: 20, AnteMode: AntePerPlayer, Ante: 5, Straddle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop || len(g.CommunityCards) != 3 || g.Pot != 60 || g.RoundBet != 0 {
+		t.Fatalf("expected a 60 pot on the flop, got %s with %d", g.Stage, g.Pot)
+	}
+	for _, p := range g.Players {
+		if p.Stack != 180 || p.Contributed != 20 {
+			t.Fatalf("expected only the bomb pot ante posted, got %+v", p)
+		}
+	}
+	for _, l := range g.ActionLogs {
+		if l.Action != "ante" {
+			t.Fatalf("expected no blinds in a bomb pot, got %+v", l)
+		}
+	}
+	if g.Players[g.TurnPos].UserID != "u2" {
+		t.Fatalf("expected the player after the button first to act, got %s", g.Players[g.TurnPos].UserID)
+	}
+	if g.Events[0].Table.Ante != 20 {
+		t.Fatalf("expected the bomb pot ante in the hand start")
+	}
+}
+
+func TestBombPot_DoubleBoardSplitsEachPot(t *testing.T) {
+	deck := stackedDeck(
+		Card{14, Spades}, Card{14, Hearts}, // u1
+		Card{13, Spades}, Card{13, Hearts}, // u2
+		Card{7, Clubs}, Card{2, Diamonds}, // u3
+		Card{3, Clubs}, Card{8, Diamonds}, Card{9, Hearts}, // first flop: aces hold
+		Card{13, Clubs}, Card{5, Diamonds}, Card{6, Hearts}, // second flop: kings make a set
+		Card{4, Spades}, Card{10, Spades},
+		Card{11, Clubs}, Card{2, Clubs},
+	)
+	g, err := NewGameWithDeck(threePlayers(), 0, 10, 10, deck, GameOptions{BombPot: 5, DoubleBoard: true, RunItTwice: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.SecondBoard) != 3 || g.RunItTwice {
+		t.Fatalf("expected two flops and no runout agreement, got %v", g.SecondBoard)
+	}
+	for g.Stage != StageFinished {
+		if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "check", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(g.CommunityCards) != 5 || len(g.SecondBoard) != 5 || len(g.Runouts) != 2 {
+		t.Fatalf("expected two full boards, got %v and %v", g.CommunityCards, g.SecondBoard)
+	}
+	// The odd chip of the 15 pot goes to the first board.
+	won := map[string]int{}
+	for _, p := range g.Players {
+		won[p.UserID] = p.Won
+	}
+	if won["u1"] != 8 || won["u2"] != 7 || won["u3"] != 0 {
+		t.Fatalf("expected the boards split 8 and 7, got %v", won)
+	}
+	if g.Runouts[1].Hands["u2"] != "three_of_a_kind" {
+		t.Fatalf("expected each board evaluated on its own, got %v", g.Runouts[1].Hands)
+	}
+	streets := 0
+	for _, ev := range g.Events {
+		if ev.Type == EventStreet && ev.Run == 2 {
+			streets++
+		}
+	}
+	if streets != 3 {
+		t.Fatalf("expected the second board's streets in the event stream, got %d", streets)
+	}
+}
+
+func TestBombPot_DoubleBoardNeedsABombPot(t *testing.T) {
+	if _, err := NewGame(threePlayers(), 0, 10, 10, GameOptions{DoubleBoard: true}); err == nil {
+		t.Fatalf("expected a double board outside a bomb pot refused")
+	}
+	if _, err := NewGame(threePlayers(), 0, 10, 10, GameOptions{BombPot: -1}); err == nil {
+		t.Fatalf("expected a negative bomb pot refused")
+	}
+}
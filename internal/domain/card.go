@@ -1,8 +1,9 @@
 package domain
 
 import (
-	"math/rand"
-	"time"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
 type Suit int
@@ -19,19 +20,81 @@ type Card struct {
 	Suit Suit
 }
 
+// String writes c as rank then suit, such as "Ah" or "Td", the form ParseCard
+// reads.
+func (c Card) String() string {
+	const ranks = "??23456789TJQKA"
+	const suits = "cdhs"
+	if c.Rank < 2 || c.Rank > 14 || c.Suit < Clubs || c.Suit > Spades {
+		return "??"
+	}
+	return ranks[c.Rank:c.Rank+1] + suits[c.Suit:c.Suit+1]
+}
+
+// ParseCard reads a card written as rank then suit, such as "Ah", "td" or
+// "10S".
+func ParseCard(text string) (Card, error) {
+	value := strings.ToUpper(strings.TrimSpace(text))
+	if len(value) < 2 {
+		return Card{}, fmt.Errorf("invalid card %q", text)
+	}
+	suit := strings.IndexByte("CDHS", value[len(value)-1])
+	rank := strings.Index("??23456789TJQKA", value[:len(value)-1])
+	if len(value) > 2 {
+		rank, _ = strconv.Atoi(value[:len(value)-1])
+	}
+	if suit < 0 || rank < 2 || rank > 14 {
+		return Card{}, fmt.Errorf("invalid card %q", text)
+	}
+	return Card{Rank: rank, Suit: Suit(suit)}, nil
+}
+
+// ParseCards reads each of texts with ParseCard.
+func ParseCards(texts []string) ([]Card, error) {
+	cards := make([]Card, 0, len(texts))
+	for _, text := range texts {
+		c, err := ParseCard(text)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, nil
+}
+
 func NewDeck() []Card {
-	deck := make([]Card, 0, 52)
+	return newDeckFrom(2)
+}
+
+// NewShortDeck builds the 36-card short-deck pack: every 2, 3, 4 and 5 removed.
+func NewShortDeck() []Card {
+	return newDeckFrom(6)
+}
+
+// NewDeckFor builds the unshuffled deck a variant is dealt from.
+func NewDeckFor(v Variant) []Card {
+	if v == VariantShortDeck {
+		return NewShortDeck()
+	}
+	return NewDeck()
+}
+
+func newDeckFrom(lowRank int) []Card {
+	deck := make([]Card, 0, 4*(15-lowRank))
 	for s := Clubs; s <= Spades; s++ {
-		for r := 2; r <= 14; r++ {
+		for r := lowRank; r <= 14; r++ {
 			deck = append(deck, Card{Rank: r, Suit: s})
 		}
 	}
 	return deck
 }
 
-func Shuffle(cards []Card) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(cards), func(i, j int) {
-		cards[i], cards[j] = cards[j], cards[i]
-	})
+// Shuffle shuffles cards with a fresh CSPRNG seed and returns that seed.
+func Shuffle(cards []Card) (string, error) {
+	seed, err := NewSeed()
+	if err != nil {
+		return "", err
+	}
+	ShuffleWithSeed(cards, seed)
+	return seed, nil
 }
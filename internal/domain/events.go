@@ -0,0 +1,136 @@
+package domain
+
+// EventType names one kind of entry in a hand's event stream.
+type EventType string
+
+const (
+	// EventHandStart opens every hand with the table as it was dealt.
+	EventHandStart EventType = "hand_start"
+	// EventBlind is a forced bet: an ante, a blind, a straddle or a post.
+	EventBlind EventType = "blind"
+	// EventHoleCards deals one player their hole cards.
+	EventHoleCards EventType = "hole_cards"
+	// EventAction is a player decision, including a runout vote.
+	EventAction EventType = "action"
+	// EventStreet deals community cards; on a multi-board runout Run tells
+	// the boards apart.
+	EventStreet EventType = "street"
+	// EventRefund returns the uncalled part of a bet to its owner.
+	EventRefund EventType = "refund"
+	// EventRake takes the house's cut out of the pot before it is awarded.
+	EventRake EventType = "rake"
+	// EventPotAwarded pays one winner their share of a pot.
+	EventPotAwarded EventType = "pot_awarded"
+	// EventShow and EventMuck tell whether a player still in the hand at the
+	// end showed their cards.
+	EventShow EventType = "show"
+	EventMuck EventType = "muck"
+)
+
+// Event is one step of a hand in the order it happened. Seq counts from 1
+// within the hand; HandID is left for the store to fill in. Fields that do
+// not apply to a type are empty.
+type Event struct {
+	HandID   int64     `json:"handId,omitempty"`
+	Seq      int       `json:"seq"`
+	Type     EventType `json:"type"`
+	Stage    GameStage `json:"stage"`
+	UserID   string    `json:"userId,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Action   string    `json:"action,omitempty"`
+	Amount   int       `json:"amount,omitempty"`
+	// Cards are the hole cards dealt or shown, or the new community cards;
+	// Board is the whole board after a street.
+	Cards []Card `json:"cards,omitempty"`
+	Board []Card `json:"board,omitempty"`
+	// Pot is the index of the pot awarded, 0 for the main pot, and Run the
+	// board it was won on when the hand was run more than once.
+	Pot      int    `json:"pot,omitempty"`
+	Run      int    `json:"run,omitempty"`
+	HandName string `json:"handName,omitempty"`
+	// Low marks the award of the low half of a hi-lo pot; HandName is then
+	// the low, such as "8-6-4-2-A".
+	Low   bool        `json:"low,omitempty"`
+	Table *EventTable `json:"table,omitempty"`
+}
+
+// EventTable describes the table at the start of a hand.
+type EventTable struct {
+	Variant    Variant     `json:"variant"`
+	ButtonSeat int         `json:"buttonSeat"`
+	DeadButton bool        `json:"deadButton"`
+	SmallBlind int         `json:"smallBlind"`
+	BigBlind   int         `json:"bigBlind"`
+	Ante       int         `json:"ante"`
+	Seats      []EventSeat `json:"seats"`
+}
+
+// EventSeat is one player dealt into the hand with their stack before any
+// forced bet.
+type EventSeat struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Seat     int    `json:"seat"`
+	Stack    int    `json:"stack"`
+	IsAI     bool   `json:"isAi"`
+}
+
+func (g *GameState) emit(ev Event) {
+	ev.Seq = len(g.Events) + 1
+	if ev.Stage == "" {
+		ev.Stage = g.Stage
+	}
+	g.Events = append(g.Events, ev)
+}
+
+func (g *GameState) logAction(p *GamePlayer, action string, amount int) {
+	g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: p.UserID, Username: p.Username, Action: action, Amount: amount, Stage: string(g.Stage)})
+	typ := EventAction
+	switch action {
+	case "ante", "small_blind", "big_blind", "straddle", "post", "dead_blind":
+		typ = EventBlind
+	}
+	g.emit(Event{Type: typ, UserID: p.UserID, Username: p.Username, Action: action, Amount: amount})
+}
+
+func (g *GameState) emitHandStart(smallBlind, bigBlind, ante int) {
+	table := &EventTable{
+		Variant:    g.Variant,
+		ButtonSeat: -1,
+		DeadButton: g.DeadButton,
+		SmallBlind: smallBlind,
+		BigBlind:   bigBlind,
+		Ante:       ante,
+		Seats:      make([]EventSeat, 0, len(g.Players)),
+	}
+	if !g.DeadButton && g.DealerPos >= 0 && g.DealerPos < len(g.Players) {
+		table.ButtonSeat = g.Players[g.DealerPos].SeatIndex
+	}
+	for _, p := range g.Players {
+		table.Seats = append(table.Seats, EventSeat{UserID: p.UserID, Username: p.Username, Seat: p.SeatIndex, Stack: p.Stack, IsAI: p.IsAI})
+	}
+	g.emit(Event{Type: EventHandStart, Table: table})
+}
+
+func (g *GameState) emitShowdown() {
+	for _, p := range g.Players {
+		if p.Folded {
+			continue
+		}
+		if p.RevealMask == 0 {
+			g.emit(Event{Type: EventMuck, UserID: p.UserID, Username: p.Username})
+			continue
+		}
+		g.emit(Event{Type: EventShow, UserID: p.UserID, Username: p.Username, Cards: revealedCards(p), HandName: p.BestHandName})
+	}
+}
+
+func revealedCards(p *GamePlayer) []Card {
+	cards := make([]Card, 0, len(p.HoleCards))
+	for i, c := range p.HoleCards {
+		if p.RevealMask&(1<<i) != 0 {
+			cards = append(cards, c)
+		}
+	}
+	return cards
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const seedBytes = 32
+
+// NewSeed draws a fresh server seed from the operating system CSPRNG and
+// returns it hex encoded.
+func NewSeed() (string, error) {
+	buf := make([]byte, seedBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ShuffleWithSeed shuffles cards with Fisher-Yates driven by a SHA-256 counter
+// stream keyed by seed, so the same seed always yields the same order.
+func ShuffleWithSeed(cards []Card, seed string) {
+	stream := &seedStream{seed: []byte(seed)}
+	for i := len(cards) - 1; i > 0; i-- {
+		j := stream.intn(i + 1)
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+}
+
+// DeckCommitment is the hash published at the start of a hand: SHA-256 of the
+// seed and the dealt deck order. Nobody can work out the deck from it, and the
+// server cannot change either once it is shown.
+func DeckCommitment(seed string, deck []Card) string {
+	sum := sha256.Sum256([]byte(seed + "|" + DeckCode(deck)))
+	return hex.EncodeToString(sum[:])
+}
+
+// DeckCode writes a deck as comma separated rank and suit codes such as
+// "14s,2h,10c".
+func DeckCode(deck []Card) string {
+	parts := make([]string, len(deck))
+	for i, c := range deck {
+		parts[i] = strconv.Itoa(c.Rank) + string("cdhs"[c.Suit])
+	}
+	return strings.Join(parts, ",")
+}
+
+// VerifyDeck rebuilds the deck a variant was dealt from with seed and checks it
+// against the commitment published at the start of the hand.
+func VerifyDeck(seed, commitment string, variant Variant) ([]Card, error) {
+	if raw, err := hex.DecodeString(seed); err != nil || len(raw) != seedBytes {
+		return nil, errors.New("invalid seed")
+	}
+	if !ValidVariant(variant) {
+		return nil, errors.New("invalid variant")
+	}
+	deck := NewDeckFor(variant)
+	ShuffleWithSeed(deck, seed)
+	if DeckCommitment(seed, deck) != strings.ToLower(strings.TrimSpace(commitment)) {
+		return deck, errors.New("commitment does not match seed")
+	}
+	return deck, nil
+}
+
+type seedStream struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (s *seedStream) uint64() uint64 {
+	if len(s.buf) < 8 {
+		block := make([]byte, len(s.seed)+8)
+		copy(block, s.seed)
+		binary.BigEndian.PutUint64(block[len(s.seed):], s.counter)
+		s.counter++
+		sum := sha256.Sum256(block)
+		s.buf = sum[:]
+	}
+	v := binary.BigEndian.Uint64(s.buf[:8])
+	s.buf = s.buf[8:]
+	return v
+}
+
+func (s *seedStream) intn(n int) int {
+	bound := uint64(n)
+	limit := ^uint64(0) - ^uint64(0)%bound
+	for {
+		if v := s.uint64(); v < limit {
+			return int(v % bound)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "testing"
+
+func TestShuffleWithSeed_IsDeterministicPermutation(t *testing.T) {
+	seed, err := NewSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewDeck()
+	b := NewDeck()
+	ShuffleWithSeed(a, seed)
+	ShuffleWithSeed(b, seed)
+	if DeckCode(a) != DeckCode(b) {
+		t.Fatalf("expected the same seed to give the same order")
+	}
+	if DeckCode(a) == DeckCode(NewDeck()) {
+		t.Fatalf("expected the deck to be shuffled")
+	}
+	seen := map[Card]bool{}
+	for _, c := range a {
+		seen[c] = true
+	}
+	if len(seen) != 52 {
+		t.Fatalf("expected a permutation of 52 cards, got %d distinct", len(seen))
+	}
+}
+
+func TestVerifyDeck_ReproducesCommittedDeck(t *testing.T) {
+	g, err := NewGame(newPlayers(), 0, 10, 10, GameOptions{Variant: VariantShortDeck})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Commitment == "" || g.RevealedSeed() != "" {
+		t.Fatalf("expected commitment published and seed hidden during the hand")
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	seed := g.RevealedSeed()
+	if seed == "" {
+		t.Fatalf("expected seed revealed after the hand")
+	}
+	deck, err := VerifyDeck(seed, g.Commitment, VariantShortDeck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DeckCode(deck) != DeckCode(g.Deck) {
+		t.Fatalf("expected verifier to rebuild the dealt deck")
+	}
+	if g.Players[0].HoleCards[0] != deck[0] {
+		t.Fatalf("expected first hole card to come off the top of the deck")
+	}
+
+	other, _ := NewSeed()
+	if _, err := VerifyDeck(other, g.Commitment, VariantShortDeck); err == nil {
+		t.Fatalf("expected a different seed to fail verification")
+	}
+	if _, err := VerifyDeck(seed, g.Commitment, VariantHoldem); err == nil {
+		t.Fatalf("expected the wrong deck to fail verification")
+	}
+}
@@ -3,7 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
-	"sort"
+	"strings"
 )
 
 type GameStage string
@@ -34,6 +34,15 @@ type GamePlayer struct {
 	LastAction    string
 	BestHandName  string
 	BestHandCards []Card
+	// BestLowName is the player's eight-or-better low in a hi-lo game, such
+	// as "7-5-3-2-A", or "" without one.
+	BestLowName string
+	// Exposed means the rules turned the hand face up during play, so it
+	// stays shown whatever the player later selects.
+	Exposed bool
+	// Rake is this player's share of the hand's rake, in proportion to what
+	// they put into the pot.
+	Rake int
 }
 
 type ActionLog struct {
@@ -47,29 +56,190 @@ type ActionLog struct {
 type GameResult struct {
 	Winners []string
 	Reason  string
+	// Rake is the chips taken from the pot before it was awarded.
+	Rake int
+	// Seed is the hand's shuffle seed, revealed once it is finished.
+	Seed string
 }
 
 type GameState struct {
-	Stage          GameStage
-	DealerPos      int
-	SmallBlindPos  int
-	BigBlindPos    int
+	Variant       Variant
+	Stage         GameStage
+	DealerPos     int
+	SmallBlindPos int
+	BigBlindPos   int
+	// DeadButton means the button sits on an empty seat just after DealerPos;
+	// SmallBlindPos is -1 when the small blind is dead.
+	DeadButton bool
+	// StraddlePos is the seat that posted a straddle this hand, or -1.
+	StraddlePos    int
+	Straddle       int
 	TurnPos        int
 	Pot            int
+	Pots           []Pot
 	CommunityCards []Card
 	Players        []*GamePlayer
 
-	Deck       []Card
-	DeckPos    int
+	Deck    []Card `json:"-"`
+	DeckPos int
+	// Seed shuffled Deck and is never serialized; Result.Seed reveals it once
+	// the hand is finished. Commitment is DeckCommitment(Seed, Deck), published
+	// from the start. Both are empty for games dealt from a fixed deck.
+	Seed       string `json:"-"`
+	Commitment string
 	RoundBet   int
 	OpenBetMin int
 	BetMin     int
-	HasActed   map[string]bool
+	// Ante is the ante size in effect for this hand, 0 when there is none.
+	Ante int
+	// DeadMoney is pot money owned by no player, such as a big-blind ante.
+	DeadMoney int
+	// Departed are players who left the table during the hand; they are
+	// folded, but what they put in stays in the pots.
+	Departed []*GamePlayer
+	// LastRaiseSize is the size of the last full bet or raise in the current
+	// betting round; a new street starts it at OpenBetMin.
+	LastRaiseSize int
+	// RaiseCount counts the full bets and raises of the current round; the
+	// big blind counts as the preflop bet.
+	RaiseCount int
+	// Aggressor is the player who made the last bet or raise of the current
+	// round, empty until someone bets; the last river aggressor shows first.
+	Aggressor string
+	// FixedLimit games bet in SmallBet units preflop and on the flop and in
+	// BigBet units on the turn and river.
+	FixedLimit bool
+	SmallBet   int
+	BigBet     int
+	// RunItTwice enables the runout agreement. While RunoutPending is set the
+	// hand waits for RunoutVotes; Runs is the agreed number of boards and
+	// Runouts lists them once more than one was dealt.
+	RunItTwice    bool
+	RunoutPending bool
+	RunoutVotes   map[string]int
+	Runs          int
+	Runouts       []Runout
+	// RakeRules is the house's cut taken when the hand is awarded.
+	RakeRules RakeRules
+	// BombPot is the ante every player put in for a bomb pot, 0 for a
+	// regular hand. A DoubleBoard bomb pot deals SecondBoard alongside
+	// CommunityCards and splits every pot between the two.
+	BombPot     int
+	DoubleBoard bool
+	SecondBoard []Card
+	HasActed    map[string]bool
+	// RaiseOpen tells whether a player may still raise this round. It closes
+	// once the player acts and only reopens when they face a full raise.
+	RaiseOpen  map[string]bool
 	Result     *GameResult
 	ActionLogs []ActionLog
+	// Events is the ordered event stream of the hand.
+	Events []Event
 }
 
-func NewGame(players []*GamePlayer, dealerPos int, openBetMin int, betMin int) (*GameState, error) {
+type AnteMode string
+
+const (
+	AnteNone      AnteMode = "none"
+	AntePerPlayer AnteMode = "per_player"
+	AnteBigBlind  AnteMode = "big_blind"
+)
+
+// ValidAnteMode reports whether mode is a known ante mode; empty means none.
+func ValidAnteMode(mode AnteMode) bool {
+	switch mode {
+	case "", AnteNone, AntePerPlayer, AnteBigBlind:
+		return true
+	}
+	return false
+}
+
+// GameOptions holds the variant and the optional forced bets of a hand on top
+// of the blinds.
+type GameOptions struct {
+	Variant  Variant
+	AnteMode AnteMode
+	// Ante is posted by every player (per_player) or by the big blind alone
+	// for the whole table (big_blind). A big-blind ante defaults to the big
+	// blind when left at zero.
+	Ante int
+	// Straddle makes the player after the big blind post a blind raise of two
+	// big blinds; only used with three or more players.
+	Straddle bool
+	// FixedLimit switches betting to fixed-limit: SmallBet preflop and on the
+	// flop, BigBet on the turn and river. They default to the big blind and
+	// twice the small bet.
+	FixedLimit bool
+	SmallBet   int
+	BigBet     int
+	// RunItTwice lets players who are all-in before the river agree to deal
+	// the rest of the board up to MaxRunouts times.
+	RunItTwice bool
+	// Rake is taken from every pot before it is awarded.
+	Rake RakeRules
+	// BombPot makes the hand a bomb pot: every player antes BombPot instead
+	// of the blinds and other forced bets, and play starts on the flop.
+	// DoubleBoard deals it on two boards that each win half of every pot.
+	BombPot     int
+	DoubleBoard bool
+	// Posts are blinds owed on top of the regular blinds, such as a new
+	// player who chose to post a big blind instead of waiting for it.
+	Posts []BlindPost
+	// Blinds places the blinds explicitly instead of next to the dealer.
+	Blinds *BlindPositions
+}
+
+// BlindPost is a blind one player posts out of position before the cards are
+// dealt. Amount is live and counts towards the preflop bet like the big
+// blind; Dead goes straight to the pot, as for a missed small blind.
+type BlindPost struct {
+	UserID string
+	Amount int
+	Dead   int
+}
+
+// BlindPositions seats the blinds for the dead button rule. SmallBlind is -1
+// when nobody posts the small blind.
+type BlindPositions struct {
+	SmallBlind int
+	BigBlind   int
+	DeadButton bool
+}
+
+// A fixed-limit round allows a bet plus three raises.
+const limitRaiseCap = 4
+
+func NewGame(players []*GamePlayer, dealerPos int, openBetMin int, betMin int, opts ...GameOptions) (*GameState, error) {
+	var variant Variant
+	if len(opts) > 0 {
+		variant = opts[0].Variant
+	}
+	deck := NewDeckFor(variant)
+	seed, err := Shuffle(deck)
+	if err != nil {
+		return nil, err
+	}
+	gs, err := newGame(players, dealerPos, openBetMin, betMin, deck, opts)
+	if err != nil {
+		return nil, err
+	}
+	gs.Seed = seed
+	gs.Commitment = DeckCommitment(seed, deck)
+	return gs, nil
+}
+
+func NewGameWithDeck(players []*GamePlayer, dealerPos int, openBetMin int, betMin int, deck []Card, opts ...GameOptions) (*GameState, error) {
+	var variant Variant
+	if len(opts) > 0 {
+		variant = opts[0].Variant
+	}
+	if want := len(NewDeckFor(variant)); len(deck) < want {
+		return nil, fmt.Errorf("deck must contain %d cards", want)
+	}
+	return newGame(players, dealerPos, openBetMin, betMin, append([]Card(nil), deck...), opts)
+}
+
+func newGame(players []*GamePlayer, dealerPos int, openBetMin int, betMin int, deck []Card, opts []GameOptions) (*GameState, error) {
 	if len(players) < 2 {
 		return nil, errors.New("at least 2 players required")
 	}
@@ -79,12 +249,40 @@ func NewGame(players []*GamePlayer, dealerPos int, openBetMin int, betMin int) (
 	if betMin <= 0 {
 		return nil, errors.New("bet min must be positive")
 	}
+	var opt GameOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if !ValidVariant(opt.Variant) {
+		return nil, errors.New("invalid variant")
+	}
+	if opt.Variant == "" {
+		opt.Variant = VariantHoldem
+	}
+	if !ValidAnteMode(opt.AnteMode) {
+		return nil, errors.New("invalid ante mode")
+	}
+	if opt.Ante < 0 {
+		return nil, errors.New("ante must not be negative")
+	}
+	if opt.FixedLimit && IsPotLimit(opt.Variant) {
+		return nil, errors.New("fixed limit is not available for pot-limit variants")
+	}
+	if opt.SmallBet < 0 || opt.BigBet < 0 {
+		return nil, errors.New("limit bet sizes must not be negative")
+	}
+	if err := opt.Rake.Validate(); err != nil {
+		return nil, err
+	}
+	if opt.BombPot < 0 {
+		return nil, errors.New("bomb pot ante must not be negative")
+	}
+	if opt.DoubleBoard && opt.BombPot == 0 {
+		return nil, errors.New("double board is only dealt in bomb pots")
+	}
 
 	bigBlind := openBetMin
-	smallBlind := openBetMin / 2
-	if smallBlind < 1 {
-		smallBlind = 1
-	}
+	smallBlind := SmallBlindFor(bigBlind)
 
 	var sbPos, bbPos int
 	if len(players) == 2 {
@@ -94,24 +292,52 @@ func NewGame(players []*GamePlayer, dealerPos int, openBetMin int, betMin int) (
 		sbPos = nextEligibleSeat(players, dealerPos)
 		bbPos = nextEligibleSeat(players, sbPos)
 	}
+	if b := opt.Blinds; b != nil {
+		if b.BigBlind < 0 || b.BigBlind >= len(players) || b.SmallBlind < -1 || b.SmallBlind >= len(players) || b.SmallBlind == b.BigBlind {
+			return nil, errors.New("invalid blind positions")
+		}
+		sbPos, bbPos = b.SmallBlind, b.BigBlind
+	}
 
 	gs := &GameState{
+		Variant:        opt.Variant,
 		Stage:          StagePreflop,
 		DealerPos:      dealerPos,
 		SmallBlindPos:  sbPos,
 		BigBlindPos:    bbPos,
+		DeadButton:     opt.Blinds != nil && opt.Blinds.DeadButton,
+		StraddlePos:    -1,
 		CommunityCards: make([]Card, 0, 5),
 		Players:        players,
-		Deck:           NewDeck(),
+		Deck:           deck,
 		RoundBet:       bigBlind,
 		OpenBetMin:     openBetMin,
 		BetMin:         betMin,
+		LastRaiseSize:  bigBlind,
+		RaiseCount:     1,
+		RunItTwice:     opt.RunItTwice && !opt.DoubleBoard,
+		RakeRules:      opt.Rake,
+		BombPot:        opt.BombPot,
+		DoubleBoard:    opt.DoubleBoard,
+		RunoutVotes:    map[string]int{},
 		HasActed:       map[string]bool{},
+		RaiseOpen:      map[string]bool{},
 		ActionLogs:     make([]ActionLog, 0),
 	}
-	Shuffle(gs.Deck)
+	if opt.FixedLimit {
+		gs.FixedLimit = true
+		gs.SmallBet = opt.SmallBet
+		if gs.SmallBet == 0 {
+			gs.SmallBet = bigBlind
+		}
+		gs.BigBet = opt.BigBet
+		if gs.BigBet == 0 {
+			gs.BigBet = gs.SmallBet * 2
+		}
+	}
 	for _, p := range gs.Players {
 		p.RevealMask = 0
+		p.Exposed = false
 		p.Folded = false
 		p.AllIn = false
 		p.Contributed = 0
@@ -120,144 +346,175 @@ func NewGame(players []*GamePlayer, dealerPos int, openBetMin int, betMin int) (
 		p.LastAction = ""
 		p.BestHandName = ""
 		p.BestHandCards = nil
-		p.HoleCards = []Card{gs.draw(), gs.draw()}
+		p.BestLowName = ""
+		p.HoleCards = make([]Card, 0, HoleCardCount(gs.Variant))
+		for i := 0; i < HoleCardCount(gs.Variant); i++ {
+			p.HoleCards = append(p.HoleCards, gs.draw())
+		}
 		gs.HasActed[p.UserID] = false
+		gs.RaiseOpen[p.UserID] = true
+	}
+	if opt.BombPot > 0 {
+		gs.dealBombPot()
+		return gs, nil
+	}
+
+	ante := 0
+	switch opt.AnteMode {
+	case AntePerPlayer:
+		ante = opt.Ante
+	case AnteBigBlind:
+		ante = opt.Ante
+		if ante <= 0 {
+			ante = bigBlind
+		}
+	}
+	gs.emitHandStart(smallBlind, bigBlind, ante)
+
+	if opt.AnteMode == AntePerPlayer && opt.Ante > 0 {
+		gs.Ante = opt.Ante
+		for _, p := range gs.Players {
+			gs.postAnte(p, opt.Ante, false)
+		}
+	}
+
+	if sbPos >= 0 {
+		gs.postBlind(gs.Players[sbPos], smallBlind, "small_blind")
 	}
+	gs.postBlind(gs.Players[bbPos], bigBlind, "big_blind")
+	lastForced := bbPos
 
-	sb := gs.Players[sbPos]
-	sbAmount := smallBlind
-	if sb.Stack < sbAmount {
-		sbAmount = sb.Stack
+	// The big blind covers its blind before the table ante.
+	if opt.AnteMode == AnteBigBlind {
+		gs.Ante = opt.Ante
+		if gs.Ante <= 0 {
+			gs.Ante = bigBlind
+		}
+		gs.postAnte(gs.Players[bbPos], gs.Ante, true)
 	}
-	sb.Stack -= sbAmount
-	sb.Contributed = sbAmount
-	sb.RoundContrib = sbAmount
-	if sb.Stack == 0 {
-		sb.AllIn = true
+
+	if opt.Straddle && len(gs.Players) > 2 {
+		pos := nextTurnSeat(gs.Players, bbPos)
+		if pos != bbPos && pos != sbPos {
+			gs.StraddlePos = pos
+			amount := gs.postBlind(gs.Players[pos], bigBlind*2, "straddle")
+			gs.Straddle = amount
+			// A full straddle plays like a bigger big blind: raises go up in
+			// straddle-sized steps.
+			if amount > gs.RoundBet {
+				if amount >= bigBlind*2 {
+					gs.LastRaiseSize = amount
+					gs.RaiseCount++
+				}
+				gs.RoundBet = amount
+			}
+			lastForced = pos
+		}
 	}
-	sb.LastAction = "small_blind"
-	gs.Pot += sbAmount
-	gs.ActionLogs = append(gs.ActionLogs, ActionLog{UserID: sb.UserID, Username: sb.Username, Action: "small_blind", Amount: sbAmount, Stage: string(StagePreflop)})
 
-	bb := gs.Players[bbPos]
-	bbAmount := bigBlind
-	if bb.Stack < bbAmount {
-		bbAmount = bb.Stack
+	// Players already in the blinds owe nothing extra.
+	for _, post := range opt.Posts {
+		for pos, p := range gs.Players {
+			if p.UserID != post.UserID || pos == sbPos || pos == bbPos {
+				continue
+			}
+			if post.Amount > p.RoundContrib {
+				gs.postBlind(p, post.Amount-p.RoundContrib, "post")
+			}
+			gs.postDeadBlind(p, post.Dead)
+		}
 	}
-	bb.Stack -= bbAmount
-	bb.Contributed = bbAmount
-	bb.RoundContrib = bbAmount
-	if bb.Stack == 0 {
-		bb.AllIn = true
+
+	for _, p := range gs.Players {
+		gs.emit(Event{Type: EventHoleCards, UserID: p.UserID, Username: p.Username, Cards: append([]Card(nil), p.HoleCards...)})
 	}
-	bb.LastAction = "big_blind"
-	gs.Pot += bbAmount
-	gs.ActionLogs = append(gs.ActionLogs, ActionLog{UserID: bb.UserID, Username: bb.Username, Action: "big_blind", Amount: bbAmount, Stage: string(StagePreflop)})
 
-	gs.TurnPos = nextTurnSeat(gs.Players, bbPos)
+	gs.TurnPos = nextTurnSeat(gs.Players, lastForced)
 	gs.ensureTurnPlayable()
 
 	return gs, nil
 }
 
-func NewGameWithDeck(players []*GamePlayer, dealerPos int, openBetMin int, betMin int, deck []Card) (*GameState, error) {
-	if len(deck) < 52 {
-		return nil, errors.New("deck must contain 52 cards")
-	}
-	if len(players) < 2 {
-		return nil, errors.New("at least 2 players required")
-	}
-	if openBetMin <= 0 {
-		return nil, errors.New("open bet min must be positive")
-	}
-	if betMin <= 0 {
-		return nil, errors.New("bet min must be positive")
+// SmallBlindFor is the small blind that goes with a big blind: half of it,
+// but at least one chip.
+func SmallBlindFor(bigBlind int) int {
+	if bigBlind/2 < 1 {
+		return 1
 	}
+	return bigBlind / 2
+}
 
-	bigBlind := openBetMin
-	smallBlind := openBetMin / 2
-	if smallBlind < 1 {
-		smallBlind = 1
+// An ante never counts towards the preflop bet. A big-blind ante pays for the
+// whole table and is dead money in the main pot.
+func (g *GameState) postAnte(p *GamePlayer, ante int, dead bool) {
+	amount := ante
+	if p.Stack < amount {
+		amount = p.Stack
+	}
+	if amount <= 0 {
+		return
 	}
-
-	var sbPos, bbPos int
-	if len(players) == 2 {
-		sbPos = dealerPos
-		bbPos = nextEligibleSeat(players, dealerPos)
+	p.Stack -= amount
+	if dead {
+		g.DeadMoney += amount
 	} else {
-		sbPos = nextEligibleSeat(players, dealerPos)
-		bbPos = nextEligibleSeat(players, sbPos)
+		p.Contributed += amount
 	}
-
-	deckCopy := append([]Card(nil), deck...)
-	gs := &GameState{
-		Stage:          StagePreflop,
-		DealerPos:      dealerPos,
-		SmallBlindPos:  sbPos,
-		BigBlindPos:    bbPos,
-		CommunityCards: make([]Card, 0, 5),
-		Players:        players,
-		Deck:           deckCopy,
-		RoundBet:       bigBlind,
-		OpenBetMin:     openBetMin,
-		BetMin:         betMin,
-		HasActed:       map[string]bool{},
-		ActionLogs:     make([]ActionLog, 0),
+	if p.Stack == 0 {
+		p.AllIn = true
 	}
-	for _, p := range gs.Players {
-		p.RevealMask = 0
-		p.Folded = false
-		p.AllIn = false
-		p.Contributed = 0
-		p.RoundContrib = 0
-		p.Won = 0
-		p.LastAction = ""
-		p.BestHandName = ""
-		p.BestHandCards = nil
-		p.HoleCards = []Card{gs.draw(), gs.draw()}
-		gs.HasActed[p.UserID] = false
+	if p.LastAction == "" {
+		p.LastAction = "ante"
 	}
+	g.Pot += amount
+	g.logAction(p, "ante", amount)
+}
 
-	sb := gs.Players[sbPos]
-	sbAmount := smallBlind
-	if sb.Stack < sbAmount {
-		sbAmount = sb.Stack
-	}
-	sb.Stack -= sbAmount
-	sb.Contributed = sbAmount
-	sb.RoundContrib = sbAmount
-	if sb.Stack == 0 {
-		sb.AllIn = true
-	}
-	sb.LastAction = "small_blind"
-	gs.Pot += sbAmount
-	gs.ActionLogs = append(gs.ActionLogs, ActionLog{UserID: sb.UserID, Username: sb.Username, Action: "small_blind", Amount: sbAmount, Stage: string(StagePreflop)})
+func (g *GameState) postBlind(p *GamePlayer, blind int, action string) int {
+	amount := blind
+	if p.Stack < amount {
+		amount = p.Stack
+	}
+	p.Stack -= amount
+	p.Contributed += amount
+	p.RoundContrib += amount
+	if p.Stack == 0 {
+		p.AllIn = true
+	}
+	p.LastAction = action
+	g.Pot += amount
+	g.logAction(p, action, amount)
+	return amount
+}
 
-	bb := gs.Players[bbPos]
-	bbAmount := bigBlind
-	if bb.Stack < bbAmount {
-		bbAmount = bb.Stack
+// A dead blind neither counts towards the bet nor caps what the player can win.
+func (g *GameState) postDeadBlind(p *GamePlayer, blind int) {
+	amount := blind
+	if p.Stack < amount {
+		amount = p.Stack
 	}
-	bb.Stack -= bbAmount
-	bb.Contributed = bbAmount
-	bb.RoundContrib = bbAmount
-	if bb.Stack == 0 {
-		bb.AllIn = true
+	if amount <= 0 {
+		return
 	}
-	bb.LastAction = "big_blind"
-	gs.Pot += bbAmount
-	gs.ActionLogs = append(gs.ActionLogs, ActionLog{UserID: bb.UserID, Username: bb.Username, Action: "big_blind", Amount: bbAmount, Stage: string(StagePreflop)})
-
-	gs.TurnPos = nextTurnSeat(gs.Players, bbPos)
-	gs.ensureTurnPlayable()
-
-	return gs, nil
+	p.Stack -= amount
+	if p.Stack == 0 {
+		p.AllIn = true
+	}
+	g.DeadMoney += amount
+	g.Pot += amount
+	g.logAction(p, "dead_blind", amount)
 }
 
 func (g *GameState) ApplyAction(userID, action string, amount int) error {
 	if g.Stage == StageFinished || g.Stage == StageShowdown {
 		return errors.New("game already ended")
 	}
+	if action == ActionRunIt {
+		return g.voteRunout(userID, amount)
+	}
+	if g.RunoutPending {
+		return errors.New("waiting for players to agree on the runout")
+	}
 	current := g.Players[g.TurnPos]
 	if current.UserID != userID {
 		return errors.New("not your turn")
@@ -275,7 +532,7 @@ func (g *GameState) ApplyAction(userID, action string, amount int) error {
 			return errors.New("cannot check when bet exists")
 		}
 		current.LastAction = "check"
-		g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: current.UserID, Username: current.Username, Action: "check", Amount: 0, Stage: string(g.Stage)})
+		g.logAction(current, "check", 0)
 	case "call":
 		diff := g.RoundBet - current.RoundContrib
 		if diff <= 0 {
@@ -295,10 +552,10 @@ func (g *GameState) ApplyAction(userID, action string, amount int) error {
 		if current.Stack == 0 {
 			current.AllIn = true
 			current.LastAction = "allin"
-			g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: current.UserID, Username: current.Username, Action: "allin", Amount: pay, Stage: string(g.Stage)})
+			g.logAction(current, "allin", pay)
 		} else {
 			current.LastAction = "call"
-			g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: current.UserID, Username: current.Username, Action: "call", Amount: pay, Stage: string(g.Stage)})
+			g.logAction(current, "call", pay)
 		}
 	case "bet", "allin":
 		if current.Stack <= 0 {
@@ -307,6 +564,9 @@ func (g *GameState) ApplyAction(userID, action string, amount int) error {
 		commit := amount
 		if action == "allin" {
 			commit = current.Stack
+		} else if g.FixedLimit {
+			// Limit bets are always exactly one unit over the current bet.
+			commit = g.RoundBet - current.RoundContrib + g.betUnit()
 		}
 		if commit <= 0 {
 			return errors.New("bet amount must be positive")
@@ -314,51 +574,73 @@ func (g *GameState) ApplyAction(userID, action string, amount int) error {
 		if commit > current.Stack {
 			return errors.New("not enough stack to bet")
 		}
+		if limit := g.maxCommit(current); commit > limit {
+			return fmt.Errorf("bet exceeds %s of %d", strings.ReplaceAll(g.BettingStructure(), "_", " "), limit)
+		}
 		targetRoundContrib := current.RoundContrib + commit
 		raises := targetRoundContrib > g.RoundBet
-		if action != "allin" {
+		if raises && g.FixedLimit && g.RaiseCount >= limitRaiseCap {
+			return errors.New("betting is capped for this round")
+		}
+		if raises && !g.RaiseOpen[current.UserID] {
+			return errors.New("raise not allowed after a short all-in")
+		}
+		if action != "allin" && !g.FixedLimit {
 			if g.RoundBet == 0 {
 				if commit < g.OpenBetMin {
 					return fmt.Errorf("open bet must be at least %d", g.OpenBetMin)
 				}
 			} else {
-				need := (g.RoundBet - current.RoundContrib) + g.BetMin
+				need := g.minRaiseCommit(current)
 				if commit < need {
 					return fmt.Errorf("raise must be at least %d", need)
 				}
 			}
 		}
+		raiseSize := targetRoundContrib - g.RoundBet
+		fullRaise := raises && raiseSize >= g.fullRaiseSize()
 		current.Stack -= commit
 		current.Contributed += commit
 		current.RoundContrib += commit
 		g.Pot += commit
 		if raises {
 			g.RoundBet = current.RoundContrib
+			g.Aggressor = current.UserID
+			if fullRaise {
+				g.LastRaiseSize = raiseSize
+				g.RaiseCount++
+			}
 		}
 		if current.Stack == 0 || action == "allin" {
 			current.AllIn = true
 			current.LastAction = "allin"
-			g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: current.UserID, Username: current.Username, Action: "allin", Amount: commit, Stage: string(g.Stage)})
+			g.logAction(current, "allin", commit)
 		} else {
 			current.LastAction = "bet"
-			g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: current.UserID, Username: current.Username, Action: "bet", Amount: commit, Stage: string(g.Stage)})
+			g.logAction(current, "bet", commit)
 		}
 		if raises {
 			for _, p := range g.activePlayers() {
 				if p.UserID != current.UserID && !p.AllIn {
 					g.HasActed[p.UserID] = false
+					// Short all-ins only reopen the raise once they add up to a
+					// full raise over what this player last matched.
+					if fullRaise || g.RoundBet-p.RoundContrib >= g.fullRaiseSize() {
+						g.RaiseOpen[p.UserID] = true
+					}
 				}
 			}
 		}
 	case "fold":
 		current.Folded = true
 		current.LastAction = "fold"
-		g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: current.UserID, Username: current.Username, Action: "fold", Amount: 0, Stage: string(g.Stage)})
+		g.logAction(current, "fold", 0)
 	default:
 		return fmt.Errorf("unsupported action: %s", action)
 	}
 
 	g.HasActed[userID] = true
+	g.RaiseOpen[userID] = false
 
 	if g.countActive() == 1 {
 		g.finishByLastStanding()
@@ -375,6 +657,135 @@ func (g *GameState) ApplyAction(userID, action string, amount int) error {
 	return nil
 }
 
+// RevealedSeed returns the shuffle seed once the hand is finished, or an empty
+// string while it is still being played.
+func (g *GameState) RevealedSeed() string {
+	if g.Stage != StageFinished || g.Result == nil {
+		return ""
+	}
+	return g.Result.Seed
+}
+
+// ForcedBet is the preflop bet set by the blinds and straddle before anyone
+// acts voluntarily.
+func (g *GameState) ForcedBet() int {
+	if g.Straddle > g.OpenBetMin {
+		return g.Straddle
+	}
+	return g.OpenBetMin
+}
+
+// A full raise is at least the last full bet or raise of the round, and never
+// below BetMin.
+func (g *GameState) fullRaiseSize() int {
+	if g.FixedLimit {
+		return g.betUnit()
+	}
+	if g.RoundBet == 0 {
+		return g.OpenBetMin
+	}
+	if g.LastRaiseSize < g.BetMin {
+		return g.BetMin
+	}
+	return g.LastRaiseSize
+}
+
+func (g *GameState) betUnit() int {
+	if g.Stage == StageTurn || g.Stage == StageRiver {
+		return g.BigBet
+	}
+	return g.SmallBet
+}
+
+// BetUnit returns the fixed bet size of the current street, or 0 when the
+// game is not fixed-limit.
+func (g *GameState) BetUnit() int {
+	if !g.FixedLimit {
+		return 0
+	}
+	return g.betUnit()
+}
+
+// BettingStructure names how bets are sized: no_limit, pot_limit or fixed_limit.
+func (g *GameState) BettingStructure() string {
+	switch {
+	case g.FixedLimit:
+		return "fixed_limit"
+	case IsPotLimit(g.Variant):
+		return "pot_limit"
+	}
+	return "no_limit"
+}
+
+func (g *GameState) minRaiseCommit(p *GamePlayer) int {
+	return (g.RoundBet - p.RoundContrib) + g.fullRaiseSize()
+}
+
+// CanRaise reports whether p may still put in a bet or raise this round.
+func (g *GameState) CanRaise(p *GamePlayer) bool {
+	if p == nil || p.Folded || p.AllIn {
+		return false
+	}
+	if g.FixedLimit && g.RaiseCount >= limitRaiseCap {
+		return false
+	}
+	return g.RaiseOpen[p.UserID]
+}
+
+// MinRaiseFor returns the chips p must commit for the smallest legal bet
+// (no bet yet) or full raise, and 0 when p may not raise at all.
+func (g *GameState) MinRaiseFor(p *GamePlayer) int {
+	if !g.CanRaise(p) {
+		return 0
+	}
+	if g.RoundBet == 0 {
+		return g.fullRaiseSize()
+	}
+	return g.minRaiseCommit(p)
+}
+
+// Pot limit allows a call plus a raise the size of the pot after that call.
+func (g *GameState) maxCommit(p *GamePlayer) int {
+	diff := g.RoundBet - p.RoundContrib
+	if diff < 0 {
+		diff = 0
+	}
+	var limit int
+	switch {
+	case g.FixedLimit:
+		limit = diff + g.betUnit()
+	case IsPotLimit(g.Variant):
+		limit = diff + g.Pot + diff
+	default:
+		return p.Stack
+	}
+	if limit > p.Stack {
+		return p.Stack
+	}
+	return limit
+}
+
+// MaxRaiseFor returns the most chips p may commit when betting or raising.
+func (g *GameState) MaxRaiseFor(p *GamePlayer) int {
+	if !g.CanRaise(p) {
+		return 0
+	}
+	return g.maxCommit(p)
+}
+
+// CanAllIn reports whether p may push the whole stack: always when that is no
+// more than a call, otherwise only while p may raise and the stack fits the
+// betting limit.
+func (g *GameState) CanAllIn(p *GamePlayer) bool {
+	if p == nil || p.Folded || p.AllIn || p.Stack <= 0 {
+		return false
+	}
+	if p.Stack <= g.RoundBet-p.RoundContrib {
+		return true
+	}
+	return g.CanRaise(p) && g.maxCommit(p) >= p.Stack
+}
+
 func (g *GameState) roundComplete() bool {
 	for _, p := range g.Players {
 		if p.Folded {
@@ -394,6 +805,16 @@ func (g *GameState) roundComplete() bool {
 }
 
 func (g *GameState) advanceStage() {
+	g.collectBets()
+	g.exposeAllIn()
+	if g.awaitRunoutAgreement() {
+		return
+	}
+	if g.Runs > 1 && g.runoutReady() {
+		g.runOutBoards()
+		return
+	}
+	dealt := len(g.CommunityCards)
 	switch g.Stage {
 	case StagePreflop:
 		g.Stage = StageFlop
@@ -409,14 +830,19 @@ func (g *GameState) advanceStage() {
 		g.finishShowdown()
 		return
 	}
+	g.emitStreet(dealt)
 	for _, p := range g.Players {
 		p.RoundContrib = 0
 		if p.Folded || p.AllIn {
 			continue
 		}
 		g.HasActed[p.UserID] = false
+		g.RaiseOpen[p.UserID] = true
 	}
 	g.RoundBet = 0
+	g.LastRaiseSize = g.OpenBetMin
+	g.RaiseCount = 0
+	g.Aggressor = ""
 	if len(g.Players) == 2 {
 		g.TurnPos = g.BigBlindPos
 	} else {
@@ -436,97 +862,137 @@ func (g *GameState) finishByLastStanding() {
 	if winner == nil {
 		return
 	}
-	winner.Stack += g.Pot
-	winner.Won = g.Pot
-	g.Result = &GameResult{Winners: []string{winner.UserID}, Reason: "others folded"}
+	g.RunoutPending = false
+	// Nobody called the part of the winner's bet above the largest bet
+	// folded to it.
+	matched := 0
+	for _, p := range g.potContributors() {
+		if p != winner && p.RoundContrib > matched {
+			matched = p.RoundContrib
+		}
+	}
+	if refund := winner.RoundContrib - matched; refund > 0 {
+		winner.RoundContrib -= refund
+		winner.Contributed -= refund
+		winner.Stack += refund
+		g.Pot -= refund
+		g.emit(Event{Type: EventRefund, UserID: winner.UserID, Username: winner.Username, Amount: refund})
+	}
+	g.Pots = buildPots(g.potContributors(), g.DeadMoney)
+	for i := range g.Pots {
+		g.Pots[i].WinnerIDs = []string{winner.UserID}
+	}
+	rake := g.takeRake(len(g.CommunityCards) > 0)
+	winner.Stack += g.Pot - rake
+	winner.Won = g.Pot - rake
+	g.emit(Event{Type: EventPotAwarded, UserID: winner.UserID, Username: winner.Username, Amount: winner.Won})
+	g.Result = &GameResult{Winners: []string{winner.UserID}, Reason: "others folded", Rake: rake, Seed: g.Seed}
 	g.Stage = StageFinished
 	g.applyDefaultRevealMasks()
+	g.emitShowdown()
 }
 
 func (g *GameState) finishShowdown() {
+	boards := [][]Card{g.CommunityCards}
+	if g.DoubleBoard {
+		boards = append(boards, g.SecondBoard)
+	}
+	g.finishShowdownOn(boards)
+}
+
+// With several boards each pot is split evenly between them; in hi-lo games
+// each share is split again between the best high and the best low.
+func (g *GameState) finishShowdownOn(boards [][]Card) {
 	active := g.activePlayers()
 	if len(active) == 0 {
 		g.Stage = StageFinished
-		g.Result = &GameResult{Winners: nil, Reason: "no active players"}
+		g.Result = &GameResult{Winners: nil, Reason: "no active players", Seed: g.Seed}
 		g.applyDefaultRevealMasks()
 		return
 	}
-	type candidate struct {
-		p     *GamePlayer
-		value HandValue
-	}
-	cands := make([]candidate, 0, len(active))
-	for _, p := range active {
-		cards := append([]Card{}, g.CommunityCards...)
-		cards = append(cards, p.HoleCards...)
-		best, bestCards, name := BestOfSeven(cards)
-		p.BestHandName = name
-		p.BestHandCards = bestCards
-		cands = append(cands, candidate{p: p, value: best})
-	}
-	strength := make(map[string]HandValue, len(cands))
-	for _, c := range cands {
-		strength[c.p.UserID] = c.value
-	}
 
 	for _, p := range g.Players {
 		p.Won = 0
 	}
 
+	g.collectBets()
+	rake := g.takeRake(true)
+	byID := make(map[string]*GamePlayer, len(g.Players))
 	for _, p := range g.Players {
-		if p.Folded {
-			continue
-		}
-		if p.Contributed > g.PotEligibleCap() {
-			// no-op safeguard; normally impossible
-		}
-	}
-
-	refund := g.refundUnmatchedChips()
-	if refund > 0 {
-		g.Pot -= refund
-	}
-
-	levels := uniqueContributionLevels(active)
-	prev := 0
-	for _, level := range levels {
-		if level <= prev {
-			continue
-		}
-		eligible := make([]*GamePlayer, 0, len(active))
+		byID[p.UserID] = p
+	}
+	g.Runouts = nil
+	var awards []Event
+	strengths := make([]map[string]HandRank, len(boards))
+	lows := make([]map[string]LowRank, len(boards))
+	for run, board := range boards {
+		strength := make(map[string]HandRank, len(active))
+		strengths[run] = strength
+		hands := make(map[string]string, len(active))
 		for _, p := range active {
-			if p.Contributed >= level {
-				eligible = append(eligible, p)
+			rank := RankHand(g.Variant, p.HoleCards, board)
+			if run == 0 {
+				_, p.BestHandCards, p.BestHandName = BestHand(g.Variant, p.HoleCards, board)
 			}
+			strength[p.UserID] = rank
+			hands[p.UserID] = rank.Name()
 		}
-		if len(eligible) == 0 {
-			prev = level
-			continue
-		}
-		potPart := 0
-		for _, p := range g.Players {
-			if p.Contributed >= level {
-				potPart += level - prev
-			} else if p.Contributed > prev {
-				potPart += p.Contributed - prev
+		var lowNames map[string]string
+		if IsHiLo(g.Variant) {
+			lows[run] = make(map[string]LowRank, len(active))
+			lowNames = make(map[string]string, len(active))
+			for _, p := range active {
+				low := RankLow(g.Variant, p.HoleCards, board)
+				lows[run][p.UserID] = low
+				if low > 0 {
+					lowNames[p.UserID] = low.Name()
+				}
+				if run == 0 {
+					p.BestLowName = low.Name()
+				}
 			}
 		}
-		if potPart <= 0 {
-			prev = level
-			continue
+		award := func(p *GamePlayer, won, pot int, handName string, low bool) {
+			ev := Event{Type: EventPotAwarded, UserID: p.UserID, Username: p.Username, Amount: won, Pot: pot, HandName: handName, Low: low}
+			if len(boards) > 1 {
+				ev.Run = run + 1
+			}
+			awards = append(awards, ev)
 		}
-		winners := bestPlayers(eligible, strength)
-		share := potPart / len(winners)
-		rest := potPart % len(winners)
-		for i, w := range winners {
-			win := share
-			if i < rest {
-				win++
+		runPots := make([]Pot, len(g.Pots))
+		for i := range g.Pots {
+			eligible := make([]*GamePlayer, 0, len(g.Pots[i].EligibleIDs))
+			for _, uid := range g.Pots[i].EligibleIDs {
+				eligible = append(eligible, byID[uid])
+			}
+			runPots[i] = Pot{
+				Amount:      runShare(g.Pots[i].Amount, len(boards), run),
+				EligibleIDs: append([]string(nil), g.Pots[i].EligibleIDs...),
+			}
+			// Without a qualifying low the high hand scoops; otherwise the low
+			// takes half and the odd chip stays with the high.
+			high := Pot{Amount: runPots[i].Amount}
+			low := Pot{}
+			lowWinners := bestLows(eligible, lows[run])
+			if len(lowWinners) > 0 {
+				low.Amount = high.Amount / 2
+				high.Amount -= low.Amount
 			}
-			w.Stack += win
-			w.Won += win
+			winners := bestPlayers(eligible, strength)
+			for j, won := range awardPot(&high, winners) {
+				award(winners[j], won, i, hands[winners[j].UserID], false)
+			}
+			for j, won := range awardPot(&low, lowWinners) {
+				award(lowWinners[j], won, i, lowNames[lowWinners[j].UserID], true)
+			}
+			runPots[i].WinnerIDs = high.WinnerIDs
+			runPots[i].LowWinnerIDs = low.WinnerIDs
+			g.Pots[i].WinnerIDs = appendMissing(g.Pots[i].WinnerIDs, runPots[i].WinnerIDs)
+			g.Pots[i].LowWinnerIDs = appendMissing(g.Pots[i].LowWinnerIDs, low.WinnerIDs)
+		}
+		if len(boards) > 1 {
+			g.Runouts = append(g.Runouts, Runout{Board: board, Pots: runPots, Hands: hands, Lows: lowNames})
 		}
-		prev = level
 	}
 
 	winnerIDs := make([]string, 0)
@@ -538,9 +1004,19 @@ func (g *GameState) finishShowdown() {
 	if len(winnerIDs) == 0 && len(active) > 0 {
 		winnerIDs = append(winnerIDs, active[0].UserID)
 	}
-	g.Result = &GameResult{Winners: winnerIDs, Reason: "showdown"}
+	g.showdown(strengths, lows)
+	g.Result = &GameResult{Winners: winnerIDs, Reason: "showdown", Rake: rake, Seed: g.Seed}
 	g.Stage = StageFinished
-	g.applyDefaultRevealMasks()
+	for _, ev := range awards {
+		g.emit(ev)
+	}
+}
+
+func (g *GameState) collectBets() {
+	if refund := g.refundUnmatchedChips(); refund > 0 {
+		g.Pot -= refund
+	}
+	g.Pots = buildPots(g.potContributors(), g.DeadMoney)
 }
 
 func (g *GameState) refundUnmatchedChips() int {
@@ -548,16 +1024,17 @@ func (g *GameState) refundUnmatchedChips() int {
 	if len(active) < 2 {
 		return 0
 	}
+	// Only the current round can hold an uncalled bet; antes are never refunded.
 	max1 := -1
 	max2 := -1
 	var top *GamePlayer
 	for _, p := range active {
-		if p.Contributed > max1 {
+		if p.RoundContrib > max1 {
 			max2 = max1
-			max1 = p.Contributed
+			max1 = p.RoundContrib
 			top = p
-		} else if p.Contributed > max2 {
-			max2 = p.Contributed
+		} else if p.RoundContrib > max2 {
+			max2 = p.RoundContrib
 		}
 	}
 	if top == nil || max1 <= max2 {
@@ -565,37 +1042,26 @@ func (g *GameState) refundUnmatchedChips() int {
 	}
 	refund := max1 - max2
 	top.Contributed -= refund
+	top.RoundContrib -= refund
+	if top.RoundContrib < 0 {
+		top.RoundContrib = 0
+	}
 	top.Stack += refund
+	g.emit(Event{Type: EventRefund, UserID: top.UserID, Username: top.Username, Amount: refund})
 	return refund
 }
 
-func uniqueContributionLevels(players []*GamePlayer) []int {
-	set := map[int]struct{}{}
-	for _, p := range players {
-		if p.Contributed > 0 {
-			set[p.Contributed] = struct{}{}
-		}
-	}
-	out := make([]int, 0, len(set))
-	for v := range set {
-		out = append(out, v)
-	}
-	sort.Ints(out)
-	return out
-}
-
-func bestPlayers(players []*GamePlayer, strength map[string]HandValue) []*GamePlayer {
+func bestPlayers(players []*GamePlayer, strength map[string]HandRank) []*GamePlayer {
 	if len(players) == 0 {
 		return nil
 	}
 	best := players[0]
 	winners := []*GamePlayer{best}
 	for i := 1; i < len(players); i++ {
-		cmp := CompareHandValue(strength[players[i].UserID], strength[best.UserID])
-		if cmp > 0 {
+		if rank := strength[players[i].UserID]; rank > strength[best.UserID] {
 			best = players[i]
 			winners = []*GamePlayer{players[i]}
-		} else if cmp == 0 {
+		} else if rank == strength[best.UserID] {
 			winners = append(winners, players[i])
 		}
 	}
@@ -606,9 +1072,6 @@ func (g *GameState) SetRevealSelection(userID string, mask int) error {
 	if g.Stage != StageFinished {
 		return errors.New("reveal only allowed after hand finished")
 	}
-	if mask < 0 || mask > 3 {
-		return errors.New("invalid reveal mask")
-	}
 	var target *GamePlayer
 	for _, p := range g.Players {
 		if p.UserID == userID {
@@ -619,7 +1082,16 @@ func (g *GameState) SetRevealSelection(userID string, mask int) error {
 	if target == nil {
 		return errors.New("player not in game")
 	}
+	if mask < 0 || mask > FullRevealMask(len(target.HoleCards)) {
+		return errors.New("invalid reveal mask")
+	}
+	if target.Exposed && mask != FullRevealMask(len(target.HoleCards)) {
+		return errors.New("exposed hand cannot be hidden")
+	}
 	target.RevealMask = mask
+	if mask != 0 {
+		g.emit(Event{Type: EventShow, UserID: target.UserID, Username: target.Username, Cards: revealedCards(target)})
+	}
 	return nil
 }
 
@@ -629,7 +1101,7 @@ func (g *GameState) applyDefaultRevealMasks() {
 			p.RevealMask = 0
 			continue
 		}
-		p.RevealMask = 3
+		p.RevealMask = FullRevealMask(len(p.HoleCards))
 	}
 	if g.Result != nil && g.Result.Reason == "others folded" && len(g.Result.Winners) == 1 {
 		winnerID := g.Result.Winners[0]
@@ -642,10 +1114,6 @@ func (g *GameState) applyDefaultRevealMasks() {
 	}
 }
 
-func (g *GameState) PotEligibleCap() int {
-	return g.Pot
-}
-
 func (g *GameState) draw() Card {
 	c := g.Deck[g.DeckPos]
 	g.DeckPos++
@@ -694,6 +1162,7 @@ func (g *GameState) ForceLeaveForStore(userID string) {
 			p.Folded = true
 			p.LastAction = "leave"
 			g.HasActed[userID] = true
+			g.emit(Event{Type: EventAction, UserID: p.UserID, Username: p.Username, Action: "leave"})
 			break
 		}
 	}
@@ -710,6 +1179,58 @@ func (g *GameState) ForceLeaveForStore(userID string) {
 	}
 }
 
+// RemovePlayerForStore drops a player who left the table and keeps every
+// position pointing at the same player. A button that belonged to the leaver
+// becomes a dead button.
+func (g *GameState) RemovePlayerForStore(userID string) {
+	idx := -1
+	for i, p := range g.Players {
+		if p.UserID == userID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	if left := g.Players[idx]; g.Stage != StageFinished && left.Contributed > 0 {
+		left.Folded = true
+		g.Departed = append(g.Departed, left)
+	}
+	players := make([]*GamePlayer, 0, len(g.Players)-1)
+	players = append(players, g.Players[:idx]...)
+	players = append(players, g.Players[idx+1:]...)
+	g.Players = players
+	if len(players) == 0 {
+		return
+	}
+	shift := func(pos int) int {
+		if pos > idx {
+			return pos - 1
+		}
+		return pos
+	}
+	n := len(players)
+	if g.DealerPos == idx {
+		g.DealerPos = (idx - 1 + n) % n
+		g.DeadButton = true
+	} else {
+		g.DealerPos = shift(g.DealerPos)
+	}
+	if g.SmallBlindPos == idx {
+		g.SmallBlindPos = -1
+	} else {
+		g.SmallBlindPos = shift(g.SmallBlindPos)
+	}
+	if g.StraddlePos == idx {
+		g.StraddlePos = -1
+	} else {
+		g.StraddlePos = shift(g.StraddlePos)
+	}
+	g.BigBlindPos = shift(g.BigBlindPos) % n
+	g.TurnPos = shift(g.TurnPos) % n
+}
+
 func (g *GameState) CountActiveForStore() int {
 	return g.countActive()
 }
@@ -9,6 +9,19 @@ func newPlayers() []*GamePlayer {
 	}
 }
 
+// checkPotsAddUp fails unless the pots and the rake of a finished hand hold
+// every chip in g.Pot.
+func checkPotsAddUp(t *testing.T, g *GameState) {
+	t.Helper()
+	sum := g.Result.Rake
+	for _, pot := range g.Pots {
+		sum += pot.Amount
+	}
+	if sum != g.Pot {
+		t.Fatalf("expected pots plus rake to add up to %d, got %d in %+v", g.Pot, sum, g.Pots)
+	}
+}
+
 func TestGame_BlindsPosted(t *testing.T) {
 	g, err := NewGame(newPlayers(), 0, 10, 10)
 	if err != nil {
@@ -177,18 +190,18 @@ func TestGame_DefaultRevealMasks_ShowdownFoldedHiddenActiveShown(t *testing.T) {
 	}
 }
 
-func TestGame_DefaultRevealMasks_ShowdownNonFoldedShown(t *testing.T) {
-	g, err := NewGame(newPlayers(), 0, 10, 10)
+func TestGame_ShowdownLosingHandIsMucked(t *testing.T) {
+	deck := stackedDeck(
+		Card{13, Spades}, Card{12, Hearts}, // u1
+		Card{14, Spades}, Card{14, Hearts}, // u2
+		Card{2, Clubs}, Card{5, Diamonds}, Card{8, Hearts}, Card{9, Spades}, Card{3, Clubs},
+	)
+	g, err := NewGameWithDeck(newPlayers(), 0, 10, 10, deck)
 	if err != nil {
 		t.Fatal(err)
 	}
-	u1 := g.Players[g.TurnPos].UserID
-	if err := g.ApplyAction(u1, "call", 0); err != nil {
-		t.Fatalf("preflop call failed: %v", err)
-	}
-	u2 := g.Players[g.TurnPos].UserID
-	if err := g.ApplyAction(u2, "check", 0); err != nil {
-		t.Fatalf("preflop check failed: %v", err)
+	if err := g.ApplyAction("u1", "call", 0); err != nil {
+		t.Fatal(err)
 	}
 	for g.Stage != StageFinished {
 		u := g.Players[g.TurnPos].UserID
@@ -196,13 +209,96 @@ func TestGame_DefaultRevealMasks_ShowdownNonFoldedShown(t *testing.T) {
 			t.Fatalf("check failed at stage=%s err=%v", g.Stage, err)
 		}
 	}
+	// Nobody bet the river, so u2 left of the button shows first and u1
+	// throws the beaten hand away.
+	if g.Players[1].RevealMask != 3 || g.Players[0].RevealMask != 0 {
+		t.Fatalf("expected u2 shown and u1 mucked, got masks %d and %d", g.Players[1].RevealMask, g.Players[0].RevealMask)
+	}
+	tail := g.ActionLogs[len(g.ActionLogs)-2:]
+	if tail[0].UserID != "u2" || tail[0].Action != ActionShow || tail[1].UserID != "u1" || tail[1].Action != ActionMuck || tail[1].Stage != "showdown" {
+		t.Fatalf("expected u2 to show then u1 to muck at showdown, got %+v", tail)
+	}
+	if err := g.SetRevealSelection("u1", 3); err != nil {
+		t.Fatalf("expected a mucked hand to be shown on request: %v", err)
+	}
+}
+
+func TestGame_ShowdownLastAggressorShowsFirst(t *testing.T) {
+	deck := stackedDeck(
+		Card{13, Spades}, Card{12, Hearts}, // u1
+		Card{14, Spades}, Card{14, Hearts}, // u2
+		Card{2, Clubs}, Card{5, Diamonds}, Card{8, Hearts}, Card{9, Spades}, Card{3, Clubs},
+	)
+	g, err := NewGameWithDeck(newPlayers(), 0, 10, 10, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		user, action string
+		amount       int
+	}{
+		{"u1", "call", 0}, {"u2", "check", 0},
+		{"u2", "check", 0}, {"u1", "check", 0},
+		{"u2", "check", 0}, {"u1", "check", 0},
+		{"u2", "check", 0}, {"u1", "bet", 20}, {"u2", "call", 0},
+	}
+	for _, s := range steps {
+		if err := g.ApplyAction(s.user, s.action, s.amount); err != nil {
+			t.Fatalf("%s %s: %v", s.user, s.action, err)
+		}
+	}
+	tail := g.ActionLogs[len(g.ActionLogs)-2:]
+	if tail[0].UserID != "u1" || tail[0].Action != ActionShow || tail[1].UserID != "u2" || tail[1].Action != ActionShow {
+		t.Fatalf("expected the river bettor to show first and the winner after, got %+v", tail)
+	}
 	for _, p := range g.Players {
 		if p.RevealMask != 3 {
-			t.Fatalf("expected non-folded showdown player default reveal mask 3, got %d for %s", p.RevealMask, p.UserID)
+			t.Fatalf("expected both hands shown, got %d for %s", p.RevealMask, p.UserID)
 		}
 	}
 }
 
+func TestGame_AllInHandsAreExposedBeforeTheRunout(t *testing.T) {
+	deck := stackedDeck(
+		Card{14, Spades}, Card{14, Hearts}, // u1
+		Card{13, Spades}, Card{13, Hearts}, // u2
+	)
+	g, err := NewGameWithDeck(newPlayers(), 0, 10, 10, deck, GameOptions{RunItTwice: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u1", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !g.RunoutPending {
+		t.Fatalf("expected the hand to wait for the runout vote")
+	}
+	for _, p := range g.Players {
+		if !p.Exposed || p.RevealMask != 3 {
+			t.Fatalf("expected %s face up before the flop, exposed=%v mask=%d", p.UserID, p.Exposed, p.RevealMask)
+		}
+	}
+	tail := g.ActionLogs[len(g.ActionLogs)-2:]
+	if tail[0].UserID != "u1" || tail[0].Action != ActionShow || tail[0].Stage != "preflop" || tail[1].UserID != "u2" {
+		t.Fatalf("expected the all-in raiser exposed first, got %+v", tail)
+	}
+	if err := g.ApplyAction("u2", ActionRunIt, 1); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFinished {
+		t.Fatalf("expected the board run out, stage=%s", g.Stage)
+	}
+	if n := len(g.ActionLogs); g.ActionLogs[n-1].Action == ActionMuck {
+		t.Fatalf("expected an exposed loser to stay face up, got %+v", g.ActionLogs[n-1])
+	}
+	if err := g.SetRevealSelection("u2", 1); err == nil {
+		t.Fatalf("expected an exposed hand to stay fully shown")
+	}
+}
+
 func TestGame_ShowdownMainPotWithOvercall(t *testing.T) {
 	players := []*GamePlayer{
 		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 10000},
@@ -248,4 +344,790 @@ func TestGame_ShowdownMainPotWithOvercall(t *testing.T) {
 	if u1.Won+u2.Won != 40 {
 		t.Fatalf("expected unmatched overcall chips 40 to remain among deep stacks, got %d", u1.Won+u2.Won)
 	}
+	checkPotsAddUp(t, g)
+}
+
+func TestGame_ShowdownBuildsSidePotsPerAllInLevel(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+		{UserID: "u4", Username: "D", SeatIndex: 3, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range g.Players {
+		p.AllIn = true
+	}
+	u1, u2, u3, u4 := g.Players[0], g.Players[1], g.Players[2], g.Players[3]
+	u1.Contributed, u2.Contributed, u3.Contributed, u4.Contributed = 100, 300, 600, 600
+	u4.Folded = true
+	u4.Contributed = 200
+	// everything went in during the river
+	for _, p := range g.Players {
+		p.RoundContrib = p.Contributed
+		p.Stack = 1000 - p.Contributed
+	}
+	g.Pot = 100 + 300 + 600 + 200
+
+	g.CommunityCards = []Card{
+		{Rank: 2, Suit: Hearts}, {Rank: 7, Suit: Clubs}, {Rank: 9, Suit: Diamonds},
+		{Rank: 11, Suit: Spades}, {Rank: 4, Suit: Clubs},
+	}
+	u1.HoleCards = []Card{{Rank: 14, Suit: Hearts}, {Rank: 14, Suit: Clubs}}
+	u2.HoleCards = []Card{{Rank: 13, Suit: Hearts}, {Rank: 13, Suit: Clubs}}
+	u3.HoleCards = []Card{{Rank: 12, Suit: Hearts}, {Rank: 12, Suit: Clubs}}
+	u4.HoleCards = []Card{{Rank: 3, Suit: Hearts}, {Rank: 5, Suit: Spades}}
+
+	g.finishShowdown()
+
+	if len(g.Pots) != 2 {
+		t.Fatalf("expected main pot and one side pot after refund, got %+v", g.Pots)
+	}
+	main, side := g.Pots[0], g.Pots[1]
+	if main.Amount != 400 || len(main.EligibleIDs) != 3 || len(main.WinnerIDs) != 1 || main.WinnerIDs[0] != "u1" {
+		t.Fatalf("unexpected main pot: %+v", main)
+	}
+	if side.Amount != 500 || len(side.EligibleIDs) != 2 || len(side.WinnerIDs) != 1 || side.WinnerIDs[0] != "u2" {
+		t.Fatalf("unexpected side pot: %+v", side)
+	}
+	if u1.Won != 400 || u2.Won != 500 {
+		t.Fatalf("expected u1=400 u2=500, got u1=%d u2=%d", u1.Won, u2.Won)
+	}
+	if u3.Contributed != 300 || u3.Stack != 1000-600+300 {
+		t.Fatalf("expected uncalled 300 refunded to u3, got contributed=%d stack=%d", u3.Contributed, u3.Stack)
+	}
+	if g.Pot != 900 {
+		t.Fatalf("expected pot 900 after refund, got %d", g.Pot)
+	}
+	checkPotsAddUp(t, g)
+}
+
+func TestBuildPots_CountsEveryContributor(t *testing.T) {
+	player := func(id string, contributed int, folded bool) *GamePlayer {
+		return &GamePlayer{UserID: id, Contributed: contributed, Folded: folded}
+	}
+	cases := []struct {
+		name     string
+		players  []*GamePlayer
+		departed []*GamePlayer
+		dead     int
+		want     []int
+	}{
+		{"called", []*GamePlayer{player("a", 100, false), player("b", 100, false)}, nil, 0, []int{200}},
+		{"short all-in", []*GamePlayer{player("a", 50, false), player("b", 200, false), player("c", 200, false)}, nil, 0, []int{150, 300}},
+		{"folded", []*GamePlayer{player("a", 100, false), player("b", 100, false), player("c", 40, true)}, nil, 0, []int{240}},
+		{"dead money", []*GamePlayer{player("a", 20, false), player("b", 20, false)}, nil, 5, []int{45}},
+		{"departed", []*GamePlayer{player("a", 100, false), player("b", 100, false)}, []*GamePlayer{player("c", 100, true)}, 0, []int{300}},
+		{"departed over a short all-in", []*GamePlayer{player("a", 50, false), player("b", 200, false)}, []*GamePlayer{player("c", 200, true)}, 0, []int{150, 300}},
+	}
+	for _, c := range cases {
+		g := &GameState{Players: c.players, Departed: c.departed}
+		pots := buildPots(g.potContributors(), c.dead)
+		total, want := 0, c.dead
+		for _, p := range g.potContributors() {
+			want += p.Contributed
+		}
+		if len(pots) != len(c.want) {
+			t.Fatalf("%s: expected %d pots, got %+v", c.name, len(c.want), pots)
+		}
+		for i, pot := range pots {
+			if pot.Amount != c.want[i] {
+				t.Fatalf("%s: expected pot %d to hold %d, got %d", c.name, i, c.want[i], pot.Amount)
+			}
+			total += pot.Amount
+		}
+		if total != want {
+			t.Fatalf("%s: expected the pots to hold all %d chips, got %d", c.name, want, total)
+		}
+	}
+}
+
+func TestGame_AllInShortStackCreatesSidePotDuringPlay(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 50},
+	}
+	g, err := NewGame(players, 0, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// dealer u1 acts first preflop with three players
+	if err := g.ApplyAction("u1", "bet", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u3", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop {
+		t.Fatalf("expected flop, got %s", g.Stage)
+	}
+	if len(g.Pots) != 2 {
+		t.Fatalf("expected main + side pot on flop, got %+v", g.Pots)
+	}
+	if g.Pots[0].Amount != 150 || len(g.Pots[0].EligibleIDs) != 3 {
+		t.Fatalf("unexpected main pot: %+v", g.Pots[0])
+	}
+	if g.Pots[1].Amount != 100 || len(g.Pots[1].EligibleIDs) != 2 {
+		t.Fatalf("unexpected side pot: %+v", g.Pots[1])
+	}
+}
+
+func TestGame_MinRaiseFollowsLastRaiseSize(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.MinRaiseFor(players[0]); got != 40 {
+		t.Fatalf("expected min raise commit 40 facing the big blind, got %d", got)
+	}
+	// u1 raises to 60: a raise of 40 over the big blind
+	if err := g.ApplyAction("u1", "bet", 60); err != nil {
+		t.Fatal(err)
+	}
+	if g.LastRaiseSize != 40 {
+		t.Fatalf("expected last raise size 40, got %d", g.LastRaiseSize)
+	}
+	// u2 posted 10, so the smallest re-raise is to 100: 90 more chips
+	if got := g.MinRaiseFor(players[1]); got != 90 {
+		t.Fatalf("expected min raise commit 90, got %d", got)
+	}
+	if err := g.ApplyAction("u2", "bet", 80); err == nil {
+		t.Fatalf("expected raise below last raise size to be rejected")
+	}
+	if err := g.ApplyAction("u2", "bet", 90); err != nil {
+		t.Fatal(err)
+	}
+	if g.RoundBet != 100 || g.LastRaiseSize != 40 {
+		t.Fatalf("unexpected round bet %d / last raise %d", g.RoundBet, g.LastRaiseSize)
+	}
+}
+
+func TestGame_ShortAllInDoesNotReopenRaising(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 150},
+	}
+	g, err := NewGame(players, 0, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u1", "bet", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	// u3 shoves to 150: only 50 over the 80-chip raise, not a full raise
+	if err := g.ApplyAction("u3", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.RoundBet != 150 || g.LastRaiseSize != 80 {
+		t.Fatalf("short all-in must not change last raise size: bet=%d last=%d", g.RoundBet, g.LastRaiseSize)
+	}
+	if g.CanRaise(players[0]) || g.MinRaiseFor(players[0]) != 0 {
+		t.Fatalf("u1 already acted and faces a short all-in, raising must stay closed")
+	}
+	if err := g.ApplyAction("u1", "bet", 200); err == nil {
+		t.Fatalf("expected re-raise after short all-in to be rejected")
+	}
+	if err := g.ApplyAction("u1", "allin", 0); err == nil {
+		t.Fatalf("expected all-in re-raise after short all-in to be rejected")
+	}
+	if err := g.ApplyAction("u1", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop {
+		t.Fatalf("expected flop, got %s", g.Stage)
+	}
+	if !g.CanRaise(players[0]) || g.LastRaiseSize != 20 {
+		t.Fatalf("new street must reopen raising with last raise size reset to the big blind")
+	}
+}
+
+func TestGame_ShortAllInsAddingUpToFullRaiseReopenRaising(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 150},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 200},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+		{UserID: "u4", Username: "D", SeatIndex: 3, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// u4 opens to 100, then two short all-ins to 150 and 200
+	if err := g.ApplyAction("u4", "bet", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u1", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.CanRaise(players[3]) {
+		t.Fatalf("first short all-in must not reopen raising for u4")
+	}
+	if err := g.ApplyAction("u2", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !g.CanRaise(players[2]) {
+		t.Fatalf("u3 has not acted yet and must be able to raise")
+	}
+	if err := g.ApplyAction("u3", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !g.CanRaise(players[3]) {
+		t.Fatalf("u4 now faces 100 more, a full raise over 80, and must be able to raise")
+	}
+	if got := g.MinRaiseFor(players[3]); got != 180 {
+		t.Fatalf("expected min raise commit 180, got %d", got)
+	}
+}
+
+func TestGame_PerPlayerAntesPostedBeforeBlinds(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{AnteMode: AntePerPlayer, Ante: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantActions := []string{"ante", "ante", "ante", "small_blind", "big_blind"}
+	if len(g.ActionLogs) != len(wantActions) {
+		t.Fatalf("unexpected logs: %+v", g.ActionLogs)
+	}
+	for i, a := range wantActions {
+		if g.ActionLogs[i].Action != a {
+			t.Fatalf("log %d: expected %s, got %+v", i, a, g.ActionLogs[i])
+		}
+	}
+	if g.Pot != 15+10+20 {
+		t.Fatalf("expected pot 45, got %d", g.Pot)
+	}
+	if players[2].RoundContrib != 20 || players[2].Contributed != 25 || players[2].Stack != 975 {
+		t.Fatalf("antes must not count towards the preflop bet: %+v", players[2])
+	}
+	if g.RoundBet != 20 || g.TurnPos != 0 {
+		t.Fatalf("expected UTG to act facing 20, got turn=%d bet=%d", g.TurnPos, g.RoundBet)
+	}
+}
+
+func TestGame_BigBlindAnteIsDeadMoneyInMainPot(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{AnteMode: AnteBigBlind})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := g.ActionLogs[len(g.ActionLogs)-1]
+	if last.Action != "ante" || last.UserID != "u3" || last.Amount != 20 {
+		t.Fatalf("expected big blind to post a 20 ante after the blind, got %+v", g.ActionLogs)
+	}
+	if g.Ante != 20 || g.DeadMoney != 20 || g.Pot != 50 {
+		t.Fatalf("unexpected ante=%d dead=%d pot=%d", g.Ante, g.DeadMoney, g.Pot)
+	}
+	if err := g.ApplyAction("u1", "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u3", "check", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop {
+		t.Fatalf("expected flop, got %s", g.Stage)
+	}
+	if len(g.Pots) != 1 || g.Pots[0].Amount != 60 || len(g.Pots[0].EligibleIDs) != 2 {
+		t.Fatalf("big blind ante must sit in the main pot, got %+v", g.Pots)
+	}
+	if players[2].Stack != 960 {
+		t.Fatalf("big blind ante must not be refunded, stack=%d", players[2].Stack)
+	}
+}
+
+func TestGame_StraddleShiftsPreflopOrder(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+		{UserID: "u4", Username: "D", SeatIndex: 3, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{Straddle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := g.ActionLogs[len(g.ActionLogs)-1]
+	if last.Action != "straddle" || last.UserID != "u4" || last.Amount != 40 {
+		t.Fatalf("expected u4 to straddle 40, got %+v", g.ActionLogs)
+	}
+	if g.StraddlePos != 3 || g.RoundBet != 40 || g.ForcedBet() != 40 {
+		t.Fatalf("unexpected straddle state pos=%d bet=%d", g.StraddlePos, g.RoundBet)
+	}
+	if g.TurnPos != 0 {
+		t.Fatalf("expected the player after the straddle to act first, got %d", g.TurnPos)
+	}
+	if got := g.MinRaiseFor(players[0]); got != 80 {
+		t.Fatalf("expected min raise to 80 over the straddle, got %d", got)
+	}
+	for _, uid := range []string{"u1", "u2", "u3"} {
+		if err := g.ApplyAction(uid, "call", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.Stage != StagePreflop || g.TurnPos != 3 {
+		t.Fatalf("straddler must get the last option, stage=%s turn=%d", g.Stage, g.TurnPos)
+	}
+	if err := g.ApplyAction("u4", "check", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop || g.Pot != 160 {
+		t.Fatalf("expected flop with pot 160, got %s / %d", g.Stage, g.Pot)
+	}
+}
+
+func TestGame_PostedBlindPlaysLive(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+		{UserID: "u4", Username: "D", SeatIndex: 3, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{Posts: []BlindPost{{UserID: "u4", Amount: 20}, {UserID: "u3", Amount: 20}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := g.ActionLogs[len(g.ActionLogs)-1]
+	if last.Action != "post" || last.UserID != "u4" || last.Amount != 20 {
+		t.Fatalf("expected u4 to post 20, got %+v", g.ActionLogs)
+	}
+	if g.Pot != 50 || players[2].RoundContrib != 20 || g.RoundBet != 20 {
+		t.Fatalf("the big blind must not post twice: pot=%d bb=%d", g.Pot, players[2].RoundContrib)
+	}
+	if g.TurnPos != 3 {
+		t.Fatalf("expected the poster to act first, got %d", g.TurnPos)
+	}
+	if err := g.ApplyAction("u4", "check", 0); err != nil {
+		t.Fatalf("a live post should be able to check: %v", err)
+	}
+	for _, uid := range []string{"u1", "u2"} {
+		if err := g.ApplyAction(uid, "call", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.Stage != StagePreflop || g.TurnPos != 2 {
+		t.Fatalf("big blind must keep the option, stage=%s turn=%d", g.Stage, g.TurnPos)
+	}
+}
+
+func TestGame_DeadSmallBlindAndDeadButton(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 3, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 5, Stack: 1000},
+	}
+	// The button sits on an empty seat after u1 and the small blind is dead,
+	// so u2 posts the big blind alone.
+	g, err := NewGame(players, 0, 20, 20, GameOptions{Blinds: &BlindPositions{SmallBlind: -1, BigBlind: 1, DeadButton: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.SmallBlindPos != -1 || g.BigBlindPos != 1 || !g.DeadButton || g.Pot != 20 {
+		t.Fatalf("unexpected blinds sb=%d bb=%d dead=%v pot=%d", g.SmallBlindPos, g.BigBlindPos, g.DeadButton, g.Pot)
+	}
+	if g.TurnPos != 2 {
+		t.Fatalf("expected the player after the big blind to act first, got %d", g.TurnPos)
+	}
+	for _, uid := range []string{"u3", "u1"} {
+		if err := g.ApplyAction(uid, "call", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.ApplyAction("u2", "check", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop || g.TurnPos != 1 {
+		t.Fatalf("expected the first player after the dead button to act on the flop, stage=%s turn=%d", g.Stage, g.TurnPos)
+	}
+
+	if _, err := NewGame(newPlayers(), 0, 20, 20, GameOptions{Blinds: &BlindPositions{SmallBlind: 1, BigBlind: 1}}); err == nil {
+		t.Fatalf("expected overlapping blinds to be rejected")
+	}
+}
+
+func TestGame_StraddleIgnoredHeadsUp(t *testing.T) {
+	g, err := NewGame(newPlayers(), 0, 20, 20, GameOptions{Straddle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.StraddlePos != -1 || g.RoundBet != 20 || len(g.ActionLogs) != 2 {
+		t.Fatalf("heads-up hands must not straddle: pos=%d bet=%d logs=%+v", g.StraddlePos, g.RoundBet, g.ActionLogs)
+	}
+}
+
+func TestGame_PLODealsFourHoleCardsAndCapsRaisesAtPot(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{Variant: VariantPLO})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range players {
+		if len(p.HoleCards) != 4 {
+			t.Fatalf("expected 4 hole cards, got %d", len(p.HoleCards))
+		}
+	}
+	// call 20, then raise by the 50 in the pot after calling: 70 in total
+	if got := g.MaxRaiseFor(players[0]); got != 70 {
+		t.Fatalf("expected pot-limit commit 70, got %d", got)
+	}
+	if g.CanAllIn(players[0]) {
+		t.Fatalf("a deep stack must not be allowed to shove over the pot limit")
+	}
+	if err := g.ApplyAction("u1", "bet", 80); err == nil {
+		t.Fatalf("expected raise above pot to be rejected")
+	}
+	if err := g.ApplyAction("u1", "allin", 0); err == nil {
+		t.Fatalf("expected all-in above pot to be rejected")
+	}
+	if err := g.ApplyAction("u1", "bet", 70); err != nil {
+		t.Fatal(err)
+	}
+	// u2 posted 10: call 60, pot becomes 160, so 220 in total
+	if got := g.MaxRaiseFor(players[1]); got != 220 {
+		t.Fatalf("expected pot-limit commit 220, got %d", got)
+	}
+}
+
+func TestGame_PLORevealMaskCoversFourCards(t *testing.T) {
+	g, err := NewGame(newPlayers(), 0, 10, 10, GameOptions{Variant: VariantPLO})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SetRevealSelection("u1", 15); err != nil {
+		t.Fatalf("expected mask 15 to reveal all four cards: %v", err)
+	}
+	if err := g.SetRevealSelection("u1", 16); err == nil {
+		t.Fatalf("expected mask beyond four cards to be rejected")
+	}
+}
+
+func TestGame_ShortDeckDealsFromThirtySixCards(t *testing.T) {
+	g, err := NewGame(newPlayers(), 0, 10, 10, GameOptions{Variant: VariantShortDeck})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Deck) != 36 {
+		t.Fatalf("expected 36-card deck, got %d", len(g.Deck))
+	}
+	if _, err := NewGameWithDeck(newPlayers(), 0, 10, 10, NewShortDeck()); err == nil {
+		t.Fatalf("expected a 36-card deck to be too small for hold'em")
+	}
+	if _, err := NewGameWithDeck(newPlayers(), 0, 10, 10, NewShortDeck(), GameOptions{Variant: VariantShortDeck}); err != nil {
+		t.Fatalf("expected short deck to be accepted for short-deck hands: %v", err)
+	}
+}
+
+func TestGame_FixedLimitBetsAreServerSizedAndCapped(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{FixedLimit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.BettingStructure() != "fixed_limit" || g.BetUnit() != 20 || g.BigBet != 40 {
+		t.Fatalf("expected limit 20/40, got %s unit=%d big=%d", g.BettingStructure(), g.BetUnit(), g.BigBet)
+	}
+	// The client amount is ignored: a raise is always one small bet.
+	if err := g.ApplyAction("u1", "bet", 999); err != nil {
+		t.Fatal(err)
+	}
+	if g.RoundBet != 40 || players[0].RoundContrib != 40 {
+		t.Fatalf("expected raise to 40, got roundBet=%d contrib=%d", g.RoundBet, players[0].RoundContrib)
+	}
+	if err := g.ApplyAction("u2", "bet", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u3", "bet", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.RoundBet != 80 {
+		t.Fatalf("expected capped bet 80, got %d", g.RoundBet)
+	}
+	if g.CanRaise(players[0]) || g.MinRaiseFor(players[0]) != 0 {
+		t.Fatalf("expected no raise after bet and three raises")
+	}
+	if err := g.ApplyAction("u1", "bet", 0); err == nil {
+		t.Fatalf("expected fifth raise to be rejected")
+	}
+	if err := g.ApplyAction("u1", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFlop || !g.CanRaise(players[1]) || g.MinRaiseFor(players[1]) != 20 {
+		t.Fatalf("expected fresh flop round with a 20 bet, stage=%s", g.Stage)
+	}
+	for _, uid := range []string{"u2", "u3", "u1"} {
+		if err := g.ApplyAction(uid, "check", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.Stage != StageTurn || g.BetUnit() != 40 {
+		t.Fatalf("expected big bet on the turn, stage=%s unit=%d", g.Stage, g.BetUnit())
+	}
+	if err := g.ApplyAction("u2", "bet", 5); err != nil {
+		t.Fatal(err)
+	}
+	if g.RoundBet != 40 || g.MaxRaiseFor(players[2]) != 80 {
+		t.Fatalf("expected turn bet 40 and raise to 80, got %d/%d", g.RoundBet, g.MaxRaiseFor(players[2]))
+	}
+	if g.CanAllIn(players[2]) {
+		t.Fatalf("a deep stack must not shove in fixed limit")
+	}
+}
+
+func TestGame_FixedLimitRejectedForPLO(t *testing.T) {
+	if _, err := NewGame(newPlayers(), 0, 10, 10, GameOptions{Variant: VariantPLO, FixedLimit: true}); err == nil {
+		t.Fatalf("expected fixed-limit plo to be rejected")
+	}
+}
+
+// stackedDeck returns a full deck that deals top first and then the remaining
+// cards in their usual order.
+func stackedDeck(top ...Card) []Card {
+	deck := append([]Card(nil), top...)
+	for _, c := range NewDeck() {
+		used := false
+		for _, t := range top {
+			if c == t {
+				used = true
+				break
+			}
+		}
+		if !used {
+			deck = append(deck, c)
+		}
+	}
+	return deck
+}
+
+func TestGame_RunItTwiceSplitsPotPerBoard(t *testing.T) {
+	deck := stackedDeck(
+		Card{14, Spades}, Card{14, Hearts}, // u1
+		Card{13, Spades}, Card{13, Hearts}, // u2
+		Card{2, Clubs}, Card{5, Diamonds}, Card{8, Hearts}, Card{9, Spades}, Card{3, Clubs}, // first board: aces hold
+		Card{13, Clubs}, Card{4, Diamonds}, Card{7, Hearts}, Card{10, Clubs}, Card{2, Diamonds}, // second board: kings hit
+	)
+	players := newPlayers()
+	g, err := NewGameWithDeck(players, 0, 10, 10, deck, GameOptions{RunItTwice: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u1", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if !g.RunoutPending || g.Stage != StagePreflop || len(g.CommunityCards) != 0 {
+		t.Fatalf("expected hand to wait for runout agreement before the flop, stage=%s", g.Stage)
+	}
+	if err := g.ApplyAction("u1", "check", 0); err == nil {
+		t.Fatalf("expected betting actions to be rejected while waiting")
+	}
+	if err := g.ApplyAction("u1", ActionRunIt, 4); err == nil {
+		t.Fatalf("expected more than %d runs to be rejected", MaxRunouts)
+	}
+	if err := g.ApplyAction("u1", ActionRunIt, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !g.RunoutPending {
+		t.Fatalf("expected to keep waiting for u2")
+	}
+	if err := g.ApplyAction("u2", ActionRunIt, 3); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFinished || g.Runs != 2 || len(g.Runouts) != 2 {
+		t.Fatalf("expected two runouts, stage=%s runs=%d runouts=%d", g.Stage, g.Runs, len(g.Runouts))
+	}
+	first, second := g.Runouts[0], g.Runouts[1]
+	if first.Pots[0].WinnerIDs[0] != "u1" || second.Pots[0].WinnerIDs[0] != "u2" {
+		t.Fatalf("expected u1 to win the first board and u2 the second, got %v / %v", first.Pots[0].WinnerIDs, second.Pots[0].WinnerIDs)
+	}
+	if first.Pots[0].Amount != 200 || second.Pots[0].Amount != 200 {
+		t.Fatalf("expected pot split 200/200, got %d/%d", first.Pots[0].Amount, second.Pots[0].Amount)
+	}
+	checkPotsAddUp(t, g)
+	if players[0].Stack != 200 || players[1].Stack != 200 {
+		t.Fatalf("expected both players back to 200, got %d/%d", players[0].Stack, players[1].Stack)
+	}
+	if second.Hands["u2"] != "three_of_a_kind" {
+		t.Fatalf("expected kings to make trips on the second board, got %s", second.Hands["u2"])
+	}
+	agreed := false
+	for _, log := range g.ActionLogs {
+		if log.Action == "runout_agreed" && log.Amount == 2 {
+			agreed = true
+		}
+	}
+	if !agreed {
+		t.Fatalf("expected runout agreement in action log, got %+v", g.ActionLogs)
+	}
+}
+
+func TestGame_RunItTwiceSingleVoteDeclines(t *testing.T) {
+	players := newPlayers()
+	g, err := NewGame(players, 0, 10, 10, GameOptions{RunItTwice: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u1", "allin", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", ActionRunIt, 1); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stage != StageFinished || g.Runs != 1 || len(g.Runouts) != 0 || len(g.CommunityCards) != 5 {
+		t.Fatalf("expected a single board once anyone declines, stage=%s runs=%d", g.Stage, g.Runs)
+	}
+}
+
+func eventTypes(t *testing.T, events []Event) []EventType {
+	t.Helper()
+	out := make([]EventType, 0, len(events))
+	for i, ev := range events {
+		if ev.Seq != i+1 {
+			t.Fatalf("event %d has seq %d", i+1, ev.Seq)
+		}
+		out = append(out, ev.Type)
+	}
+	return out
+}
+
+func TestGame_EventStreamFollowsTheHand(t *testing.T) {
+	deck := stackedDeck(
+		Card{14, Spades}, Card{14, Hearts}, // u1
+		Card{13, Spades}, Card{12, Hearts}, // u2
+		Card{2, Clubs}, Card{5, Diamonds}, Card{8, Hearts}, Card{9, Spades}, Card{3, Clubs},
+	)
+	g, err := NewGameWithDeck(newPlayers(), 0, 10, 10, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		user, action string
+		amount       int
+	}{
+		{"u1", "call", 0}, {"u2", "check", 0},
+		{"u2", "check", 0}, {"u1", "bet", 20}, {"u2", "call", 0},
+		{"u2", "check", 0}, {"u1", "check", 0},
+		{"u2", "check", 0}, {"u1", "check", 0},
+	}
+	for _, s := range steps {
+		if err := g.ApplyAction(s.user, s.action, s.amount); err != nil {
+			t.Fatalf("%s %s: %v", s.user, s.action, err)
+		}
+	}
+
+	want := []EventType{
+		EventHandStart, EventBlind, EventBlind, EventHoleCards, EventHoleCards,
+		EventAction, EventAction, EventStreet,
+		EventAction, EventAction, EventAction, EventStreet,
+		EventAction, EventAction, EventStreet,
+		EventAction, EventAction, EventShow, EventShow, EventPotAwarded,
+	}
+	got := eventTypes(t, g.Events)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("event %d: expected %s, got %v", i+1, want[i], got)
+		}
+	}
+
+	start := g.Events[0].Table
+	if start == nil || start.ButtonSeat != 0 || start.BigBlind != 10 || len(start.Seats) != 2 || start.Seats[1].Stack != 200 {
+		t.Fatalf("expected the table before blinds in hand start, got %+v", start)
+	}
+	if hole := g.Events[3]; hole.UserID != "u1" || len(hole.Cards) != 2 || hole.Cards[0] != (Card{14, Spades}) {
+		t.Fatalf("expected u1's hole cards, got %+v", hole)
+	}
+	if flop := g.Events[7]; flop.Stage != StageFlop || len(flop.Cards) != 3 || len(flop.Board) != 3 {
+		t.Fatalf("expected the flop dealt, got %+v", flop)
+	}
+	if river := g.Events[14]; river.Stage != StageRiver || len(river.Cards) != 1 || len(river.Board) != 5 {
+		t.Fatalf("expected the river dealt, got %+v", river)
+	}
+	won := g.Events[len(g.Events)-1]
+	if won.UserID != "u1" || won.Amount != 60 || won.HandName != "one_pair" {
+		t.Fatalf("expected u1 to win 60 with a pair, got %+v", won)
+	}
+}
+
+func TestGame_EventStreamRefundsUncalledBet(t *testing.T) {
+	players := newPlayers()
+	g, err := NewGame(players, 0, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u1", "bet", 25); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction("u2", "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	tail := g.Events[len(g.Events)-3:]
+	if tail[0].Type != EventRefund || tail[0].UserID != "u1" || tail[0].Amount != 20 {
+		t.Fatalf("expected 20 returned to u1, got %+v", tail[0])
+	}
+	if tail[1].Type != EventPotAwarded || tail[1].Amount != 20 {
+		t.Fatalf("expected u1 to collect 20, got %+v", tail[1])
+	}
+	if tail[2].Type != EventMuck || tail[2].UserID != "u1" {
+		t.Fatalf("expected the winner to muck, got %+v", tail[2])
+	}
+	if players[0].Stack != 210 || players[0].Won != 20 || g.Pot != 20 {
+		t.Fatalf("expected u1 to net the big blind, stack=%d won=%d pot=%d", players[0].Stack, players[0].Won, g.Pot)
+	}
+
+	if err := g.SetRevealSelection("u1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if shown := g.Events[len(g.Events)-1]; shown.Type != EventShow || len(shown.Cards) != 1 || shown.Cards[0] != players[0].HoleCards[0] {
+		t.Fatalf("expected a show event for the revealed card, got %+v", shown)
+	}
 }
@@ -5,11 +5,15 @@ import "sort"
 type HandValue struct {
 	Category int
 	Ranks    []int
+	// ShortDeck marks values ranked by short-deck rules, where a flush beats
+	// a full house. Category keeps its usual meaning either way.
+	ShortDeck bool
 }
 
 func CompareHandValue(a, b HandValue) int {
-	if a.Category != b.Category {
-		if a.Category > b.Category {
+	ca, cb := a.categoryStrength(), b.categoryStrength()
+	if ca != cb {
+		if ca > cb {
 			return 1
 		}
 		return -1
@@ -25,25 +29,73 @@ func CompareHandValue(a, b HandValue) int {
 	return 0
 }
 
+func (v HandValue) categoryStrength() int {
+	if v.ShortDeck {
+		switch v.Category {
+		case 5:
+			return 6
+		case 6:
+			return 5
+		}
+	}
+	return v.Category
+}
+
+// BestOfSeven returns the best five-card hand among cards along with the
+// cards that make it.
 func BestOfSeven(cards []Card) (HandValue, []Card, string) {
+	return bestOfCards(cards, false)
+}
+
+func bestOfCards(cards []Card, shortDeck bool) (HandValue, []Card, string) {
 	if len(cards) < 5 {
 		return HandValue{}, nil, ""
 	}
-	best := HandValue{Category: -1}
-	var bestCards []Card
-	indices := combinations(len(cards), 5)
-	for _, idx := range indices {
+	best := rankMask(MaskOf(cards), shortDeck)
+	for _, idx := range combinations(len(cards), 5) {
 		hand := []Card{cards[idx[0]], cards[idx[1]], cards[idx[2]], cards[idx[3]], cards[idx[4]]}
-		v := EvaluateFive(hand)
-		if CompareHandValue(v, best) > 0 {
-			best = v
-			bestCards = hand
+		if rankMask(MaskOf(hand), shortDeck) == best {
+			return best.Value(), hand, best.Name()
+		}
+	}
+	return best.Value(), nil, best.Name()
+}
+
+// BestOmaha returns the best hand made from exactly two hole cards and exactly
+// three board cards, as Omaha requires.
+func BestOmaha(hole []Card, board []Card) (HandValue, []Card, string) {
+	if len(hole) < 2 || len(board) < 3 {
+		return HandValue{}, nil, ""
+	}
+	var best HandRank
+	var bestCards []Card
+	for _, h := range combinations(len(hole), 2) {
+		for _, b := range combinations(len(board), 3) {
+			hand := []Card{hole[h[0]], hole[h[1]], board[b[0]], board[b[1]], board[b[2]]}
+			if v := rankMask(MaskOf(hand), false); v > best {
+				best = v
+				bestCards = hand
+			}
 		}
 	}
-	return best, bestCards, handCategoryName(best.Category)
+	return best.Value(), bestCards, best.Name()
 }
 
+// EvaluateFive ranks exactly five cards. It is the reference the lookup-table
+// evaluator is tested against; hot paths should use RankMask instead.
 func EvaluateFive(cards []Card) HandValue {
+	return evaluateFive(cards, false)
+}
+
+// EvaluateFiveShortDeck ranks a hand by short-deck rules: a flush beats a full
+// house and A-6-7-8-9 is the lowest straight.
+func EvaluateFiveShortDeck(cards []Card) HandValue {
+	v := evaluateFive(cards, true)
+	v.ShortDeck = true
+	return v
+}
+
+func evaluateFive(cards []Card, shortDeck bool) HandValue {
 	ranks := make([]int, 0, 5)
 	rankCount := map[int]int{}
 	suitCount := map[Suit]int{}
@@ -60,7 +112,7 @@ func EvaluateFive(cards []Card) HandValue {
 			break
 		}
 	}
-	straightHigh, isStraight := detectStraight(ranks)
+	straightHigh, isStraight := detectStraight(ranks, shortDeck)
 
 	if isFlush && isStraight {
 		return HandValue{Category: 8, Ranks: []int{straightHigh}}
@@ -138,7 +190,7 @@ func EvaluateFive(cards []Card) HandValue {
 	return HandValue{Category: 0, Ranks: uniqueSortedDesc(ranks)}
 }
 
-func detectStraight(ranks []int) (int, bool) {
+func detectStraight(ranks []int, shortDeck bool) (int, bool) {
 	uniqMap := map[int]bool{}
 	for _, r := range ranks {
 		uniqMap[r] = true
@@ -156,7 +208,14 @@ func detectStraight(ranks []int) (int, bool) {
 			return uniq[i], true
 		}
 	}
-	// Wheel straight: A-2-3-4-5
+	// The ace plays low below the smallest rank in the deck:
+	// A-2-3-4-5, or A-6-7-8-9 in short deck.
+	if shortDeck {
+		if uniqMap[14] && uniqMap[9] && uniqMap[8] && uniqMap[7] && uniqMap[6] {
+			return 9, true
+		}
+		return 0, false
+	}
 	if uniqMap[14] && uniqMap[5] && uniqMap[4] && uniqMap[3] && uniqMap[2] {
 		return 5, true
 	}
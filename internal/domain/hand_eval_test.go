@@ -23,3 +23,74 @@ func TestBestOfSeven_SelectsBestHand(t *testing.T) {
 		t.Fatalf("expected straight_flush, got category=%d name=%s", v.Category, name)
 	}
 }
+
+func TestBestOmaha_UsesExactlyTwoHoleCards(t *testing.T) {
+	hole := []Card{{14, Hearts}, {7, Clubs}, {7, Diamonds}, {2, Spades}}
+	board := []Card{{13, Hearts}, {12, Hearts}, {11, Hearts}, {4, Hearts}, {3, Clubs}}
+
+	v, best, name := BestOmaha(hole, board)
+	if v.Category == 5 {
+		t.Fatalf("one heart in hand must not make a flush in omaha, got %s", name)
+	}
+	if v.Category != 1 || v.Ranks[0] != 7 {
+		t.Fatalf("expected a pair of sevens from the hole, got category=%d ranks=%v", v.Category, v.Ranks)
+	}
+	fromHole := 0
+	for _, c := range best {
+		for _, h := range hole {
+			if c == h {
+				fromHole++
+			}
+		}
+	}
+	if fromHole != 2 {
+		t.Fatalf("expected exactly two hole cards in best hand, got %d (%v)", fromHole, best)
+	}
+
+	holdem, _, _ := BestHand(VariantHoldem, hole[:2], board)
+	if holdem.Category != 5 {
+		t.Fatalf("hold'em may use one hole card, expected a flush, got %d", holdem.Category)
+	}
+}
+
+func TestEvaluateFiveShortDeck_FlushBeatsFullHouse(t *testing.T) {
+	flush := []Card{{14, Hearts}, {12, Hearts}, {9, Hearts}, {8, Hearts}, {6, Hearts}}
+	fullHouse := []Card{{13, Clubs}, {13, Diamonds}, {13, Spades}, {7, Clubs}, {7, Diamonds}}
+
+	if CompareHandValue(EvaluateFive(flush), EvaluateFive(fullHouse)) >= 0 {
+		t.Fatalf("expected full house > flush with standard rankings")
+	}
+	if CompareHandValue(EvaluateFiveShortDeck(flush), EvaluateFiveShortDeck(fullHouse)) <= 0 {
+		t.Fatalf("expected flush > full house in short deck")
+	}
+}
+
+func TestEvaluateFiveShortDeck_AceSixToNineIsLowestStraight(t *testing.T) {
+	lowStraight := []Card{{14, Spades}, {6, Hearts}, {7, Clubs}, {8, Diamonds}, {9, Hearts}}
+	v := EvaluateFiveShortDeck(lowStraight)
+	if v.Category != 4 || v.Ranks[0] != 9 {
+		t.Fatalf("expected A-6-7-8-9 straight to nine, got category=%d ranks=%v", v.Category, v.Ranks)
+	}
+	if EvaluateFive(lowStraight).Category == 4 {
+		t.Fatalf("A-6-7-8-9 is not a straight with a full deck")
+	}
+	sixHigh := EvaluateFiveShortDeck([]Card{{6, Spades}, {7, Hearts}, {8, Clubs}, {9, Diamonds}, {10, Hearts}})
+	if CompareHandValue(sixHigh, v) <= 0 {
+		t.Fatalf("expected 6-10 straight to beat A-9 straight")
+	}
+}
+
+func TestNewShortDeck_Has36CardsFromSixUp(t *testing.T) {
+	deck := NewDeckFor(VariantShortDeck)
+	if len(deck) != 36 {
+		t.Fatalf("expected 36 cards, got %d", len(deck))
+	}
+	for _, c := range deck {
+		if c.Rank < 6 {
+			t.Fatalf("unexpected low card in short deck: %+v", c)
+		}
+	}
+	if len(NewDeckFor(VariantHoldem)) != 52 {
+		t.Fatalf("expected full deck for hold'em")
+	}
+}
@@ -0,0 +1,235 @@
+package domain
+
+import "math/bits"
+
+// CardMask is a set of cards with one bit per card, bit 16*suit+rank, so each
+// suit's ranks sit in their own 16 bits.
+type CardMask uint64
+
+// Mask returns the set holding only c.
+func (c Card) Mask() CardMask {
+	return 1 << (16*uint(c.Suit) + uint(c.Rank))
+}
+
+// MaskOf returns the set of the given cards.
+func MaskOf(cards []Card) CardMask {
+	var m CardMask
+	for _, c := range cards {
+		m |= c.Mask()
+	}
+	return m
+}
+
+// HandRank is the strength of the best five-card hand in a set of cards,
+// packed so that comparing two ranks as integers orders them exactly like
+// CompareHandValue orders their HandValues. Bits 20-23 hold the category
+// strength and bits 0-19 up to five ranks, a nibble each, most significant
+// first; bit 24 marks short-deck rankings.
+type HandRank uint32
+
+const (
+	shortDeckRank HandRank = 1 << 24
+	// rankBits covers ranks 2 through ace in a suit's 16 bits.
+	rankBits = 0x7ffc
+)
+
+var rankCounts = [9]int{5, 4, 3, 3, 1, 5, 2, 2, 1}
+
+var (
+	straightHighs          = buildStraightHighs(false)
+	shortDeckStraightHighs = buildStraightHighs(true)
+	topFiveRanks           = buildTopFiveRanks()
+)
+
+func buildStraightHighs(shortDeck bool) *[1 << 13]uint8 {
+	var table [1 << 13]uint8
+	for i := range table {
+		ranks := uint16(i) << 2
+		for high := 14; high >= 6; high-- {
+			if run := uint16(0x1f) << (high - 4); ranks&run == run {
+				table[i] = uint8(high)
+				break
+			}
+		}
+		if table[i] != 0 {
+			continue
+		}
+		// The ace plays low below the smallest rank in the deck.
+		wheel, high := uint16(1<<14|1<<5|1<<4|1<<3|1<<2), 5
+		if shortDeck {
+			wheel, high = 1<<14|1<<9|1<<8|1<<7|1<<6, 9
+		}
+		if ranks&wheel == wheel {
+			table[i] = uint8(high)
+		}
+	}
+	return &table
+}
+
+func buildTopFiveRanks() *[1 << 13]uint32 {
+	var table [1 << 13]uint32
+	for i := range table {
+		ranks := uint16(i) << 2
+		for n := 0; n < 5 && ranks != 0; n++ {
+			r := highestRank(ranks)
+			table[i] |= uint32(r) << (16 - 4*n)
+			ranks &^= 1 << r
+		}
+	}
+	return &table
+}
+
+func highestRank(ranks uint16) int {
+	return bits.Len16(ranks) - 1
+}
+
+func topRanks(ranks uint16, k int) HandRank {
+	return HandRank(topFiveRanks[ranks>>2] >> (4 * (5 - k)))
+}
+
+// RankMask ranks the best five-card hold'em hand in m, which should hold at
+// least five cards. It does not allocate.
+func RankMask(m CardMask) HandRank {
+	return rankMask(m, false)
+}
+
+// RankMaskShortDeck is RankMask under short-deck rules: a flush beats a full
+// house and A-6-7-8-9 is the lowest straight.
+func RankMaskShortDeck(m CardMask) HandRank {
+	return rankMask(m, true)
+}
+
+func rankMask(m CardMask, shortDeck bool) HandRank {
+	straights := straightHighs
+	if shortDeck {
+		straights = shortDeckStraightHighs
+	}
+	pack := func(category int, ranks HandRank) HandRank {
+		if !shortDeck {
+			return HandRank(category)<<20 | ranks
+		}
+		return HandRank(HandValue{Category: category, ShortDeck: true}.categoryStrength())<<20 | ranks | shortDeckRank
+	}
+
+	// Count each rank across the suits with a bit-sliced counter: a rank held
+	// n times has bit 1 of n in ones, bit 2 in twos and bit 4 in fours.
+	var all, ones, twos, fours uint16
+	var flush HandRank
+	for s := 0; s < 4; s++ {
+		suit := uint16(m>>(16*s)) & rankBits
+		if bits.OnesCount16(suit) >= 5 {
+			if high := straights[suit>>2]; high != 0 {
+				return pack(8, HandRank(high)<<16)
+			}
+			if v := pack(5, topRanks(suit, 5)); v > flush {
+				flush = v
+			}
+		}
+		carry := ones & suit
+		ones ^= suit
+		fours |= twos & carry
+		twos ^= carry
+		all |= suit
+	}
+	trips := ones & twos
+	pairs := twos &^ ones
+
+	if fours != 0 {
+		quad := highestRank(fours)
+		return pack(7, HandRank(quad)<<16|topRanks(all&^(1<<quad), 1)<<12)
+	}
+	var fullHouse HandRank
+	if trips != 0 {
+		trip := highestRank(trips)
+		if rest := trips&^(1<<trip) | pairs; rest != 0 {
+			fullHouse = pack(6, HandRank(trip)<<16|HandRank(highestRank(rest))<<12)
+		}
+	}
+	if flush != 0 || fullHouse != 0 {
+		// Keep whichever packs higher: short deck ranks the flush above the
+		// full house.
+		if flush > fullHouse {
+			return flush
+		}
+		return fullHouse
+	}
+	if high := straights[all>>2]; high != 0 {
+		return pack(4, HandRank(high)<<16)
+	}
+	if trips != 0 {
+		trip := highestRank(trips)
+		return pack(3, HandRank(trip)<<16|topRanks(all&^(1<<trip), 2)<<8)
+	}
+	if bits.OnesCount16(pairs) >= 2 {
+		high := highestRank(pairs)
+		low := highestRank(pairs &^ (1 << high))
+		return pack(2, HandRank(high)<<16|HandRank(low)<<12|topRanks(all&^(1<<high|1<<low), 1)<<8)
+	}
+	if pairs != 0 {
+		pair := highestRank(pairs)
+		return pack(1, HandRank(pair)<<16|topRanks(all&^(1<<pair), 3)<<4)
+	}
+	return pack(0, topRanks(all, 5))
+}
+
+// RankHand is BestHand without the cards: it ranks a player's best hand under
+// the variant's rules without allocating, for code that evaluates many hands.
+func RankHand(v Variant, hole []Card, board []Card) HandRank {
+	switch {
+	case IsOmaha(v):
+		return rankOmaha(hole, board)
+	case v == VariantShortDeck:
+		return rankMask(MaskOf(hole)|MaskOf(board), true)
+	}
+	return rankMask(MaskOf(hole)|MaskOf(board), false)
+}
+
+func rankOmaha(hole []Card, board []Card) HandRank {
+	var best HandRank
+	for a := 0; a < len(hole); a++ {
+		for b := a + 1; b < len(hole); b++ {
+			two := hole[a].Mask() | hole[b].Mask()
+			for i := 0; i < len(board); i++ {
+				for j := i + 1; j < len(board); j++ {
+					for k := j + 1; k < len(board); k++ {
+						if v := rankMask(two|board[i].Mask()|board[j].Mask()|board[k].Mask(), false); v > best {
+							best = v
+						}
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// Category is the hand category, 0 for high card up to 8 for a straight
+// flush, as in HandValue.
+func (r HandRank) Category() int {
+	strength := int(r >> 20 & 0xf)
+	if r&shortDeckRank != 0 {
+		switch strength {
+		case 5:
+			return 6
+		case 6:
+			return 5
+		}
+	}
+	return strength
+}
+
+// Name is the hand category's name, such as "full_house".
+func (r HandRank) Name() string {
+	return handCategoryName(r.Category())
+}
+
+// Value unpacks r into the HandValue the five-card evaluator gives the same
+// hand.
+func (r HandRank) Value() HandValue {
+	category := r.Category()
+	ranks := make([]int, rankCounts[category])
+	for i := range ranks {
+		ranks[i] = int(r >> (16 - 4*i) & 0xf)
+	}
+	return HandValue{Category: category, Ranks: ranks, ShortDeck: r&shortDeckRank != 0}
+}
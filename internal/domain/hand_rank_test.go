@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// referenceBest is the evaluator BestOfSeven used before the lookup tables:
+// every five-card combination through the five-card evaluator.
+func referenceBest(cards []Card, evaluate func([]Card) HandValue) HandValue {
+	best := HandValue{Category: -1}
+	for _, idx := range combinations(len(cards), 5) {
+		v := evaluate([]Card{cards[idx[0]], cards[idx[1]], cards[idx[2]], cards[idx[3]], cards[idx[4]]})
+		if CompareHandValue(v, best) > 0 {
+			best = v
+		}
+	}
+	return best
+}
+
+// eachFiveCardHand calls fn with every five-card hand dealt from deck.
+func eachFiveCardHand(deck []Card, fn func(hand []Card)) {
+	hand := make([]Card, 5)
+	n := len(deck)
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			for c := b + 1; c < n; c++ {
+				for d := c + 1; d < n; d++ {
+					for e := d + 1; e < n; e++ {
+						hand[0], hand[1], hand[2], hand[3], hand[4] = deck[a], deck[b], deck[c], deck[d], deck[e]
+						fn(hand)
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkEveryFiveCardHand(t *testing.T, deck []Card, shortDeck bool) {
+	t.Helper()
+	evaluate, rank := EvaluateFive, RankMask
+	if shortDeck {
+		evaluate, rank = EvaluateFiveShortDeck, RankMaskShortDeck
+	}
+	classes := map[HandRank]HandValue{}
+	hands := 0
+	eachFiveCardHand(deck, func(hand []Card) {
+		hands++
+		want := evaluate(hand)
+		got := rank(MaskOf(hand))
+		if v := got.Value(); !reflect.DeepEqual(v, want) {
+			t.Fatalf("%v: got %+v, want %+v", hand, v, want)
+		}
+		classes[got] = want
+	})
+
+	// Equal values on every hand leave the order to check: sorting the
+	// distinct ranks as integers must sort their values strictly upwards.
+	ranks := make([]HandRank, 0, len(classes))
+	for r := range classes {
+		ranks = append(ranks, r)
+	}
+	sort.Slice(ranks, func(i, j int) bool { return ranks[i] < ranks[j] })
+	for i := 1; i < len(ranks); i++ {
+		if CompareHandValue(classes[ranks[i-1]], classes[ranks[i]]) >= 0 {
+			t.Fatalf("rank %x (%+v) sorts below %x (%+v)", ranks[i-1], classes[ranks[i-1]], ranks[i], classes[ranks[i]])
+		}
+	}
+	t.Logf("%d hands, %d distinct ranks", hands, len(ranks))
+}
+
+func TestRankMask_MatchesEvaluateFiveOnEveryHand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("walks all 2,598,960 five-card hands")
+	}
+	checkEveryFiveCardHand(t, NewDeck(), false)
+}
+
+func TestRankMaskShortDeck_MatchesEvaluateFiveShortDeckOnEveryHand(t *testing.T) {
+	checkEveryFiveCardHand(t, NewShortDeck(), true)
+}
+
+func TestRankMask_MatchesBestOfSevenReference(t *testing.T) {
+	rng := rand.New(rand.NewSource(16))
+	for _, shortDeck := range []bool{false, true} {
+		deck, evaluate, rank := NewDeck(), EvaluateFive, RankMask
+		if shortDeck {
+			deck, evaluate, rank = NewShortDeck(), EvaluateFiveShortDeck, RankMaskShortDeck
+		}
+		prev := append([]Card(nil), deck[:7]...)
+		prevRank := rank(MaskOf(prev))
+		for i := 0; i < 50000; i++ {
+			rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+			cards := append([]Card(nil), deck[:5+i%3]...)
+			want := referenceBest(cards, evaluate)
+			got := rank(MaskOf(cards))
+			if v := got.Value(); !reflect.DeepEqual(v, want) {
+				t.Fatalf("short deck %v, %v: got %+v, want %+v", shortDeck, cards, v, want)
+			}
+			if len(cards) == 7 {
+				wantCmp := CompareHandValue(want, referenceBest(prev, evaluate))
+				gotCmp := 0
+				if got > prevRank {
+					gotCmp = 1
+				} else if got < prevRank {
+					gotCmp = -1
+				}
+				if gotCmp != wantCmp {
+					t.Fatalf("short deck %v: %v vs %v compare %d, want %d", shortDeck, cards, prev, gotCmp, wantCmp)
+				}
+				prev, prevRank = cards, got
+			}
+		}
+	}
+}
+
+func TestRankMask_SpotChecks(t *testing.T) {
+	cases := []struct {
+		name  string
+		cards []Card
+		short bool
+		want  string
+		ranks []int
+	}{
+		{"steel wheel", []Card{{14, Clubs}, {2, Clubs}, {3, Clubs}, {4, Clubs}, {5, Clubs}, {13, Hearts}, {13, Spades}}, false, "straight_flush", []int{5}},
+		{"two trips make a full house", []Card{{9, Clubs}, {9, Hearts}, {9, Spades}, {4, Clubs}, {4, Hearts}, {4, Spades}, {2, Diamonds}}, false, "full_house", []int{9, 4}},
+		{"three pairs keep the best kicker", []Card{{8, Clubs}, {8, Hearts}, {6, Spades}, {6, Clubs}, {5, Hearts}, {5, Spades}, {2, Diamonds}}, false, "two_pair", []int{8, 6, 5}},
+		{"quads take the best kicker", []Card{{7, Clubs}, {7, Hearts}, {7, Spades}, {7, Diamonds}, {13, Hearts}, {13, Spades}, {14, Diamonds}}, false, "four_of_a_kind", []int{7, 14}},
+		{"six-card flush", []Card{{14, Hearts}, {12, Hearts}, {10, Hearts}, {8, Hearts}, {6, Hearts}, {3, Hearts}, {3, Spades}}, false, "flush", []int{14, 12, 10, 8, 6}},
+		{"short deck ace to nine", []Card{{14, Spades}, {6, Hearts}, {7, Clubs}, {8, Diamonds}, {9, Hearts}, {12, Clubs}, {12, Spades}}, true, "straight", []int{9}},
+	}
+	for _, tc := range cases {
+		rank := RankMask
+		if tc.short {
+			rank = RankMaskShortDeck
+		}
+		got := rank(MaskOf(tc.cards))
+		if got.Name() != tc.want {
+			t.Fatalf("%s: got %s, want %s", tc.name, got.Name(), tc.want)
+		}
+		if len(tc.ranks) > 0 && !reflect.DeepEqual(got.Value().Ranks, tc.ranks) {
+			t.Fatalf("%s: got ranks %v, want %v", tc.name, got.Value().Ranks, tc.ranks)
+		}
+	}
+}
+
+func TestRankMaskShortDeck_FlushBeatsFullHouse(t *testing.T) {
+	flush := MaskOf([]Card{{14, Hearts}, {13, Hearts}, {9, Hearts}, {7, Hearts}, {6, Hearts}})
+	fullHouse := MaskOf([]Card{{14, Spades}, {14, Clubs}, {14, Diamonds}, {13, Spades}, {13, Diamonds}})
+	if RankMaskShortDeck(flush) <= RankMaskShortDeck(fullHouse) {
+		t.Fatalf("expected short-deck flush to beat full house")
+	}
+	if RankMask(flush) >= RankMask(fullHouse) {
+		t.Fatalf("expected hold'em full house to beat flush")
+	}
+}
+
+func TestRankHand_MatchesBestHandAndDoesNotAllocate(t *testing.T) {
+	board := []Card{{13, Hearts}, {12, Hearts}, {11, Hearts}, {4, Hearts}, {3, Clubs}}
+	hands := map[Variant][]Card{
+		VariantHoldem:    {{14, Hearts}, {7, Clubs}},
+		VariantShortDeck: {{14, Hearts}, {7, Clubs}},
+		VariantPLO:       {{14, Hearts}, {7, Clubs}, {7, Diamonds}, {2, Spades}},
+	}
+	for v, hole := range hands {
+		want, _, _ := BestHand(v, hole, board)
+		if got := RankHand(v, hole, board).Value(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %+v, want %+v", v, got, want)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { RankHand(v, hole, board) }); allocs != 0 {
+			t.Fatalf("%s: expected no allocations, got %.0f", v, allocs)
+		}
+	}
+}
+
+func TestBestOfSeven_KeepsFirstBestCards(t *testing.T) {
+	cards := []Card{{9, Clubs}, {9, Hearts}, {13, Spades}, {13, Clubs}, {5, Diamonds}, {5, Hearts}, {2, Clubs}}
+	_, best, name := BestOfSeven(cards)
+	want := []Card{{9, Clubs}, {9, Hearts}, {13, Spades}, {13, Clubs}, {5, Diamonds}}
+	if name != "two_pair" || !reflect.DeepEqual(best, want) {
+		t.Fatalf("expected the first five cards making the hand, got %s %v", name, best)
+	}
+}
+
+var benchmarkHands = func() [][]Card {
+	rng := rand.New(rand.NewSource(1))
+	deck := NewDeck()
+	hands := make([][]Card, 1024)
+	for i := range hands {
+		rng.Shuffle(len(deck), func(a, b int) { deck[a], deck[b] = deck[b], deck[a] })
+		hands[i] = append([]Card(nil), deck[:7]...)
+	}
+	return hands
+}()
+
+func BenchmarkBestOfSevenReference(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		referenceBest(benchmarkHands[i%len(benchmarkHands)], EvaluateFive)
+	}
+}
+
+func BenchmarkBestOfSeven(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		BestOfSeven(benchmarkHands[i%len(benchmarkHands)])
+	}
+}
+
+func BenchmarkRankMask(b *testing.B) {
+	masks := make([]CardMask, len(benchmarkHands))
+	for i, h := range benchmarkHands {
+		masks[i] = MaskOf(h)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RankMask(masks[i%len(masks)])
+	}
+}
+
+func BenchmarkRankHand(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h := benchmarkHands[i%len(benchmarkHands)]
+		RankHand(VariantHoldem, h[:2], h[2:])
+	}
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// LowRank is the strength of a player's best eight-or-better low: five cards
+// of different ranks, none above an eight, with the ace playing low. Straights
+// and flushes do not count against a low (ace-to-five lowball), so the wheel
+// A-2-3-4-5 is the best low. Like HandRank, a larger LowRank is the better
+// hand; 0 means the hand has no qualifying low.
+type LowRank uint32
+
+// lowQualifier is the highest card a low may hold.
+const lowQualifier = 8
+
+func lowValue(rank int) int {
+	if rank == 14 {
+		return 1
+	}
+	if rank > lowQualifier {
+		return 0
+	}
+	return rank
+}
+
+func lowBits(cards []Card) uint16 {
+	var set uint16
+	for _, c := range cards {
+		if v := lowValue(c.Rank); v > 0 {
+			set |= 1 << v
+		}
+	}
+	return set
+}
+
+func lowFromBits(set uint16) LowRank {
+	var picked [5]int
+	n := 0
+	for v := 1; v <= lowQualifier && n < len(picked); v++ {
+		if set&(1<<v) != 0 {
+			picked[n] = v
+			n++
+		}
+	}
+	if n < len(picked) {
+		return 0
+	}
+	packed := 0
+	for i := len(picked) - 1; i >= 0; i-- {
+		packed = packed<<4 | picked[i]
+	}
+	return LowRank(1<<20 - packed)
+}
+
+// RankLow ranks a player's best low under the variant's rules: exactly two
+// hole cards and three board cards in Omaha, any five cards otherwise.
+func RankLow(v Variant, hole []Card, board []Card) LowRank {
+	if !IsOmaha(v) {
+		return lowFromBits(lowBits(hole) | lowBits(board))
+	}
+	var best LowRank
+	for a := 0; a < len(hole); a++ {
+		for b := a + 1; b < len(hole); b++ {
+			for i := 0; i < len(board); i++ {
+				for j := i + 1; j < len(board); j++ {
+					for k := j + 1; k < len(board); k++ {
+						// Only five low cards of different ranks make a low.
+						five := lowBits([]Card{hole[a], hole[b], board[i], board[j], board[k]})
+						if bits.OnesCount16(five) < 5 {
+							continue
+						}
+						if r := lowFromBits(five); r > best {
+							best = r
+						}
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+// Name writes the low from its highest card down, such as "8-6-4-2-A", or
+// returns "" when there is no low.
+func (r LowRank) Name() string {
+	if r == 0 {
+		return ""
+	}
+	packed := 1<<20 - int(r)
+	parts := make([]string, 0, 5)
+	for shift := 16; shift >= 0; shift -= 4 {
+		v := packed >> shift & 0xf
+		if v == 1 {
+			parts = append(parts, "A")
+		} else {
+			parts = append(parts, string(rune('0'+v)))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func bestLows(players []*GamePlayer, lows map[string]LowRank) []*GamePlayer {
+	var best LowRank
+	var winners []*GamePlayer
+	for _, p := range players {
+		switch r := lows[p.UserID]; {
+		case r == 0 || r < best:
+		case r > best:
+			best = r
+			winners = []*GamePlayer{p}
+		default:
+			winners = append(winners, p)
+		}
+	}
+	return winners
+}
@@ -0,0 +1,139 @@
+package domain
+
+import "testing"
+
+func TestRankLow_EightOrBetter(t *testing.T) {
+	wheel := RankLow(VariantHoldem, []Card{{14, Spades}, {2, Hearts}}, []Card{{3, Clubs}, {4, Diamonds}, {5, Spades}, {13, Hearts}, {13, Clubs}})
+	sixFour := RankLow(VariantHoldem, []Card{{14, Spades}, {2, Hearts}}, []Card{{3, Clubs}, {4, Diamonds}, {6, Spades}, {6, Hearts}, {13, Clubs}})
+	eightSeven := RankLow(VariantHoldem, []Card{{8, Spades}, {7, Hearts}}, []Card{{6, Clubs}, {5, Diamonds}, {4, Spades}, {13, Hearts}, {13, Clubs}})
+	if !(wheel > sixFour && sixFour > eightSeven && eightSeven > 0) {
+		t.Fatalf("expected the wheel, then 6-4, then 8-7 to rank in order, got %d %d %d", wheel, sixFour, eightSeven)
+	}
+	if wheel.Name() != "5-4-3-2-A" || sixFour.Name() != "6-4-3-2-A" || eightSeven.Name() != "8-7-6-5-4" {
+		t.Fatalf("unexpected names %q %q %q", wheel.Name(), sixFour.Name(), eightSeven.Name())
+	}
+	// A nine does not qualify and a pair only counts once.
+	if got := RankLow(VariantHoldem, []Card{{9, Spades}, {2, Hearts}}, []Card{{2, Clubs}, {4, Diamonds}, {6, Spades}, {8, Hearts}, {13, Clubs}}); got != 0 {
+		t.Fatalf("expected no low, got %s", got.Name())
+	}
+
+	board := []Card{{3, Clubs}, {4, Diamonds}, {5, Hearts}, {12, Clubs}, {11, Diamonds}}
+	if got := RankLow(VariantPLO8, []Card{{14, Spades}, {2, Spades}, {13, Hearts}, {13, Spades}}, board); got.Name() != "5-4-3-2-A" {
+		t.Fatalf("expected an omaha wheel, got %q", got.Name())
+	}
+	// Omaha needs two low hole cards of ranks not already used from the board.
+	if got := RankLow(VariantPLO8, []Card{{14, Spades}, {13, Spades}, {13, Hearts}, {12, Spades}}, board); got != 0 {
+		t.Fatalf("expected one low hole card to make no omaha low, got %q", got.Name())
+	}
+	if got := RankLow(VariantPLO8, []Card{{3, Spades}, {4, Spades}, {13, Hearts}, {12, Spades}}, board); got != 0 {
+		t.Fatalf("expected hole cards pairing the board to make no omaha low, got %q", got.Name())
+	}
+}
+
+// playHiLo deals a three-handed Omaha hi-lo hand to a fixed board: u1 holds
+// A-2, u2 A-2 unless u2Folds, and u3 pocket kings.
+func playHiLo(t *testing.T, board []Card, stacks [3]int, u2Folds bool) *GameState {
+	t.Helper()
+	deck := append([]Card{
+		{14, Spades}, {2, Spades}, {9, Hearts}, {9, Clubs}, // u1
+		{14, Hearts}, {2, Hearts}, {10, Diamonds}, {11, Diamonds}, // u2
+		{13, Hearts}, {13, Spades}, {7, Clubs}, {7, Diamonds}, // u3
+	}, board...)
+	players := threePlayers()
+	for i, p := range players {
+		p.Stack = stacks[i]
+	}
+	g, err := NewGameWithDeck(players, 0, 10, 10, stackedDeck(deck...), GameOptions{Variant: VariantPLO8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pot limit keeps anyone from shoving at once, so every player still in
+	// bets the pot until they are all in.
+	for g.Stage != StageFinished {
+		p := g.Players[g.TurnPos]
+		if p.UserID == "u2" && u2Folds {
+			if err := g.ApplyAction(p.UserID, "fold", 0); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if g.ApplyAction(p.UserID, "bet", g.MaxRaiseFor(p)) == nil {
+			continue
+		}
+		if err := g.ApplyAction(p.UserID, "call", 0); err != nil {
+			if err := g.ApplyAction(p.UserID, "check", 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	checkPotsAddUp(t, g)
+	return g
+}
+
+func TestHiLo_QuartersTheLowAcrossSidePots(t *testing.T) {
+	// u1 and u2 tie the wheel for low and a five-high straight for high; u3's
+	// quad kings win the high half of the main pot only.
+	board := []Card{{3, Clubs}, {4, Diamonds}, {5, Hearts}, {13, Clubs}, {13, Diamonds}}
+	g := playHiLo(t, board, [3]int{200, 200, 100}, false)
+	if len(g.Pots) != 2 {
+		t.Fatalf("expected a main and a side pot, got %+v", g.Pots)
+	}
+	main, side := g.Pots[0], g.Pots[1]
+	if len(main.WinnerIDs) != 1 || main.WinnerIDs[0] != "u3" || len(main.LowWinnerIDs) != 2 {
+		t.Fatalf("expected u3 to take the high and u1/u2 the low of the main pot, got %+v", main)
+	}
+	if len(side.WinnerIDs) != 2 || len(side.LowWinnerIDs) != 2 {
+		t.Fatalf("expected u1/u2 to split both halves of the side pot, got %+v", side)
+	}
+	// Main pot 300: 150 high, 75 low each. Side pot 200: 50 each way.
+	for i, want := range []int{175, 175, 150} {
+		if got := g.Players[i].Stack; got != want {
+			t.Fatalf("%s: expected %d, got %d", g.Players[i].UserID, want, got)
+		}
+	}
+	if g.Players[0].BestLowName != "5-4-3-2-A" || g.Players[2].BestLowName != "" {
+		t.Fatalf("expected only the A-2 hands to have a low, got %q / %q", g.Players[0].BestLowName, g.Players[2].BestLowName)
+	}
+	lows := 0
+	for _, ev := range g.Events {
+		if ev.Type == EventPotAwarded && ev.Low {
+			lows++
+			if ev.HandName != "5-4-3-2-A" {
+				t.Fatalf("expected the low award named by the low, got %q", ev.HandName)
+			}
+		}
+	}
+	if lows != 4 {
+		t.Fatalf("expected four low awards, got %d", lows)
+	}
+}
+
+func TestHiLo_ScoopsWithoutALowAndOddChipGoesHigh(t *testing.T) {
+	// No low is possible on this board, so u3's quad kings take everything.
+	noLow := []Card{{9, Spades}, {10, Clubs}, {12, Hearts}, {13, Clubs}, {13, Diamonds}}
+	g := playHiLo(t, noLow, [3]int{200, 200, 200}, false)
+	if g.Players[2].Stack != 600 || len(g.Pots[0].LowWinnerIDs) != 0 {
+		t.Fatalf("expected u3 to scoop 600 with no low, got %d %+v", g.Players[2].Stack, g.Pots)
+	}
+
+	// u1 wins the low alone; u2's folded blind makes the pot odd.
+	board := []Card{{3, Clubs}, {4, Diamonds}, {5, Hearts}, {13, Clubs}, {13, Diamonds}}
+	g = playHiLo(t, board, [3]int{200, 200, 200}, true)
+	pot := 0
+	for _, p := range g.Players {
+		pot += p.Won
+	}
+	if pot%2 != 1 {
+		t.Fatalf("expected an odd pot, got %d", pot)
+	}
+	if g.Players[2].Won != pot/2+1 || g.Players[0].Won != pot/2 {
+		t.Fatalf("expected the odd chip to go high, got high %d low %d of %d", g.Players[2].Won, g.Players[0].Won, pot)
+	}
+
+	// u1's wheel is also the best high hand here: a scoop of both halves.
+	scoop := []Card{{3, Clubs}, {4, Diamonds}, {5, Hearts}, {8, Spades}, {12, Clubs}}
+	g = playHiLo(t, scoop, [3]int{200, 200, 200}, true)
+	if g.Players[0].Won != pot || len(g.Pots[0].WinnerIDs) != 1 || g.Pots[0].LowWinnerIDs[0] != "u1" {
+		t.Fatalf("expected u1 to scoop %d, got %d %+v", pot, g.Players[0].Won, g.Pots)
+	}
+}
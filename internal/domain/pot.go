@@ -0,0 +1,138 @@
+package domain
+
+import "sort"
+
+// Pot is one main or side pot. The first pot in GameState.Pots is the main pot;
+// every following pot is a side pot capped by a shorter all-in stack.
+type Pot struct {
+	Amount      int      `json:"amount"`
+	EligibleIDs []string `json:"eligibleUserIds"`
+	WinnerIDs   []string `json:"winnerUserIds,omitempty"`
+	// LowWinnerIDs took the low half of the pot in a hi-lo game; WinnerIDs
+	// then took the high half, or all of it when nobody had a low.
+	LowWinnerIDs []string `json:"lowWinnerUserIds,omitempty"`
+}
+
+// Folded players feed the pots but can never win them; dead money goes to
+// the main pot.
+func buildPots(players []*GamePlayer, dead int) []Pot {
+	active := make([]*GamePlayer, 0, len(players))
+	for _, p := range players {
+		if !p.Folded {
+			active = append(active, p)
+		}
+	}
+	levels := uniqueContributionLevels(active)
+	pots := make([]Pot, 0, len(levels))
+	prev := 0
+	for _, level := range levels {
+		amount := 0
+		for _, p := range players {
+			amount += clampContribution(p.Contributed, prev, level)
+		}
+		eligible := make([]string, 0, len(active))
+		for _, p := range active {
+			if p.Contributed >= level {
+				eligible = append(eligible, p.UserID)
+			}
+		}
+		prev = level
+		if amount <= 0 {
+			continue
+		}
+		if len(pots) == 0 {
+			amount += dead
+			dead = 0
+		}
+		pots = append(pots, Pot{Amount: amount, EligibleIDs: eligible})
+	}
+	// Chips above the deepest live stack can only come from players who folded
+	// after putting in more than anyone left; they belong to the last pot.
+	leftover := dead
+	for _, p := range players {
+		if p.Contributed > prev {
+			leftover += p.Contributed - prev
+		}
+	}
+	if leftover > 0 {
+		if len(pots) == 0 {
+			ids := make([]string, 0, len(active))
+			for _, p := range active {
+				ids = append(ids, p.UserID)
+			}
+			pots = append(pots, Pot{EligibleIDs: ids})
+		}
+		pots[len(pots)-1].Amount += leftover
+	}
+	return pots
+}
+
+func (g *GameState) potContributors() []*GamePlayer {
+	if len(g.Departed) == 0 {
+		return g.Players
+	}
+	return append(append([]*GamePlayer(nil), g.Players...), g.Departed...)
+}
+
+func clampContribution(contributed, low, high int) int {
+	if contributed <= low {
+		return 0
+	}
+	if contributed >= high {
+		return high - low
+	}
+	return contributed - low
+}
+
+func uniqueContributionLevels(players []*GamePlayer) []int {
+	set := map[int]struct{}{}
+	for _, p := range players {
+		if p.Contributed > 0 {
+			set[p.Contributed] = struct{}{}
+		}
+	}
+	out := make([]int, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	sort.Ints(out)
+	return out
+}
+
+func appendMissing(ids []string, more []string) []string {
+	for _, id := range more {
+		found := false
+		for _, have := range ids {
+			if have == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Odd chips go to the winners seated first.
+func awardPot(pot *Pot, winners []*GamePlayer) []int {
+	if len(winners) == 0 || pot.Amount <= 0 {
+		return nil
+	}
+	share := pot.Amount / len(winners)
+	rest := pot.Amount % len(winners)
+	pot.WinnerIDs = make([]string, 0, len(winners))
+	shares := make([]int, len(winners))
+	for i, w := range winners {
+		win := share
+		if i < rest {
+			win++
+		}
+		w.Stack += win
+		w.Won += win
+		pot.WinnerIDs = append(pot.WinnerIDs, w.UserID)
+		shares[i] = win
+	}
+	return shares
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"errors"
+	"math"
+)
+
+// MaxRakePercent is the largest share of a pot a room may take.
+const MaxRakePercent = 10
+
+// RakeRules is the house's cut of each pot. Percent of the pot is taken,
+// rounded down to a whole chip, up to Cap; a Cap of 0 leaves it uncapped.
+type RakeRules struct {
+	Percent float64 `json:"percent"`
+	Cap     int     `json:"cap,omitempty"`
+	// HeadsUpCap replaces Cap for hands dealt to two players.
+	HeadsUpCap int `json:"headsUpCap,omitempty"`
+	// NoFlopNoDrop takes nothing from hands that end before the flop.
+	NoFlopNoDrop bool `json:"noFlopNoDrop,omitempty"`
+}
+
+// Validate reports rules outside the allowed range.
+func (r RakeRules) Validate() error {
+	if r.Percent < 0 || r.Percent > MaxRakePercent {
+		return errors.New("rake percent must be between 0 and 10")
+	}
+	if r.Cap < 0 || r.HeadsUpCap < 0 {
+		return errors.New("rake caps must not be negative")
+	}
+	return nil
+}
+
+// Enabled reports whether the rules take anything at all.
+func (r RakeRules) Enabled() bool {
+	return r.Percent > 0
+}
+
+func (r RakeRules) rakeFor(pot, players int) int {
+	// Percent is kept to hundredths so that 100 * 5% is exactly 5.
+	rake := pot * int(math.Round(r.Percent*100)) / 10000
+	limit := r.Cap
+	if players == 2 && r.HeadsUpCap > 0 {
+		limit = r.HeadsUpCap
+	}
+	if limit > 0 && rake > limit {
+		rake = limit
+	}
+	return rake
+}
+
+func (g *GameState) takeRake(sawFlop bool) int {
+	rules := g.RakeRules
+	if !rules.Enabled() || (rules.NoFlopNoDrop && !sawFlop) {
+		return 0
+	}
+	rake := rules.rakeFor(g.Pot, len(g.Players))
+	if rake <= 0 {
+		return 0
+	}
+	left := rake
+	for i := range g.Pots {
+		take := min(left, g.Pots[i].Amount)
+		g.Pots[i].Amount -= take
+		left -= take
+	}
+	rake -= left
+	g.chargeRake(rake)
+	g.emit(Event{Type: EventRake, Amount: rake})
+	return rake
+}
+
+// Rake is charged by contribution; rounding leftovers go to the largest
+// contributors, first seat first.
+func (g *GameState) chargeRake(rake int) {
+	payers := g.potContributors()
+	total := 0
+	for _, p := range payers {
+		p.Rake = 0
+		total += p.Contributed
+	}
+	if total <= 0 {
+		return
+	}
+	charged := 0
+	for _, p := range payers {
+		p.Rake = rake * p.Contributed / total
+		charged += p.Rake
+	}
+	for charged < rake {
+		var top *GamePlayer
+		for _, p := range payers {
+			if p.Contributed > 0 && p.Rake*total < rake*p.Contributed && (top == nil || p.Contributed > top.Contributed) {
+				top = p
+			}
+		}
+		if top == nil {
+			break
+		}
+		top.Rake++
+		charged++
+	}
+}
@@ -0,0 +1,158 @@
+package domain
+
+import "testing"
+
+func TestRake_TakenAtShowdownAndChargedByContribution(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{Rake: RakeRules{Percent: 5, Cap: 30, HeadsUpCap: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Everyone calls preflop, then the first player bets 200 on the flop and
+	// one player calls.
+	for g.Stage == StagePreflop {
+		u := g.Players[g.TurnPos].UserID
+		if err := g.ApplyAction(u, "call", 0); err != nil {
+			if err := g.ApplyAction(u, "check", 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "bet", 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	for g.Stage != StageFinished {
+		if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "check", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 5% of 460 is 23, under the three-handed cap.
+	if g.Pot != 460 || g.Result.Rake != 23 {
+		t.Fatalf("expected 23 raked from 460, got %d from %d", g.Result.Rake, g.Pot)
+	}
+	checkPotsAddUp(t, g)
+	stacks, won, charged := 0, 0, 0
+	for _, p := range g.Players {
+		stacks += p.Stack
+		won += p.Won
+		charged += p.Rake
+		if p.Contributed == 220 && p.Rake != 11 {
+			t.Fatalf("expected a caller of 220 charged 11, got %d", p.Rake)
+		}
+		if p.Contributed == 20 && p.Rake != 1 {
+			t.Fatalf("expected the folder charged 1, got %d", p.Rake)
+		}
+	}
+	if stacks != 3000-23 || won != 460-23 || charged != 23 {
+		t.Fatalf("expected the rake to leave the table, got stacks %d won %d charged %d", stacks, won, charged)
+	}
+	var rakeEvents int
+	for _, ev := range g.Events {
+		if ev.Type == EventRake && ev.Amount == 23 {
+			rakeEvents++
+		}
+	}
+	if rakeEvents != 1 {
+		t.Fatalf("expected one rake event")
+	}
+}
+
+func TestRake_ChargesPlayersWhoLeftMidHand(t *testing.T) {
+	players := []*GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := NewGame(players, 0, 20, 20, GameOptions{Rake: RakeRules{Percent: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g.Stage == StagePreflop {
+		u := g.Players[g.TurnPos].UserID
+		if err := g.ApplyAction(u, "call", 0); err != nil {
+			if err := g.ApplyAction(u, "check", 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	left := g.Players[0]
+	g.RemovePlayerForStore(left.UserID)
+	for g.Stage != StageFinished {
+		if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "check", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 5% of the 60 everyone put in is 3, a chip from each of them.
+	if g.Result.Rake != 3 || left.Rake != 1 {
+		t.Fatalf("expected the leaver charged 1 of 3, got %d of %d", left.Rake, g.Result.Rake)
+	}
+	for _, p := range g.Players {
+		if p.Rake != 1 {
+			t.Fatalf("expected %s charged 1, got %d", p.UserID, p.Rake)
+		}
+	}
+}
+
+func TestRake_HeadsUpCapAndNoFlopNoDrop(t *testing.T) {
+	rules := RakeRules{Percent: 10, Cap: 50, HeadsUpCap: 5, NoFlopNoDrop: true}
+	g, err := NewGame(newPlayers(), 0, 10, 10, GameOptions{Rake: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "bet", 50); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.Result.Rake != 0 || g.Players[0].Stack+g.Players[1].Stack != 400 {
+		t.Fatalf("expected no rake before the flop, got %d", g.Result.Rake)
+	}
+
+	g, err = NewGame(newPlayers(), 0, 10, 10, GameOptions{Rake: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "call", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "check", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "bet", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "fold", 0); err != nil {
+		t.Fatal(err)
+	}
+	// 10% of the 20 called would be 2; the bet nobody called goes back.
+	if g.Result.Rake != 2 || g.Players[0].Stack+g.Players[1].Stack != 398 {
+		t.Fatalf("expected 2 raked after the flop, got %d", g.Result.Rake)
+	}
+	checkPotsAddUp(t, g)
+
+	if got := rules.rakeFor(400, 2); got != 5 {
+		t.Fatalf("expected the heads-up cap, got %d", got)
+	}
+	if got := rules.rakeFor(400, 3); got != 40 {
+		t.Fatalf("expected 10%% under the cap, got %d", got)
+	}
+	if got := (RakeRules{Percent: 0.29}).rakeFor(10000, 3); got != 29 {
+		t.Fatalf("expected fractional percents rounded exactly, got %d", got)
+	}
+	if err := (RakeRules{Percent: 11}).Validate(); err == nil {
+		t.Fatalf("expected a rake over 10%% refused")
+	}
+}
@@ -0,0 +1,406 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NumCombos is the number of distinct two-card holdings in a 52-card deck.
+const NumCombos = 1326
+
+const rankLetters = "23456789TJQKA"
+
+// Range is a weighted set of two-card holdings, one weight per combo. Weights
+// are relative; notation writes them as frequencies such as "KQo:0.5".
+type Range struct {
+	weights [NumCombos]float64
+}
+
+var (
+	comboCards [NumCombos][2]Card
+	comboIndex [52][52]int
+)
+
+func init() {
+	n := 0
+	for a := 0; a < 52; a++ {
+		for b := a + 1; b < 52; b++ {
+			comboCards[n] = [2]Card{cardAt(a), cardAt(b)}
+			comboIndex[a][b], comboIndex[b][a] = n, n
+			n++
+		}
+	}
+}
+
+func cardAt(i int) Card {
+	return Card{Rank: i/4 + 2, Suit: Suit(i % 4)}
+}
+
+func cardSlot(c Card) (int, bool) {
+	if c.Rank < 2 || c.Rank > 14 || c.Suit < Clubs || c.Suit > Spades {
+		return 0, false
+	}
+	return (c.Rank-2)*4 + int(c.Suit), true
+}
+
+func comboOf(a, b Card) (int, bool) {
+	i, okA := cardSlot(a)
+	j, okB := cardSlot(b)
+	if !okA || !okB || i == j {
+		return 0, false
+	}
+	return comboIndex[i][j], true
+}
+
+// NewRange returns an empty range.
+func NewRange() *Range {
+	return &Range{}
+}
+
+// ParseRange reads standard range notation: comma separated pairs ("TT",
+// "TT+", "88-55"), hands ("AKs", "KQo", "AK" for both), runs of kickers
+// ("KTs+", "A5s-A2s"), and single combos ("AhKh"), each optionally weighted
+// with ":0.5". Later entries overwrite earlier ones.
+func ParseRange(text string) (*Range, error) {
+	r := NewRange()
+	for _, token := range strings.Split(text, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		weight := 1.0
+		if i := strings.IndexByte(token, ':'); i >= 0 {
+			w, err := strconv.ParseFloat(strings.TrimSpace(token[i+1:]), 64)
+			if err != nil || w <= 0 || w > 1 {
+				return nil, fmt.Errorf("invalid weight in %q", token)
+			}
+			weight = w
+			token = strings.TrimSpace(token[:i])
+		}
+		if err := r.addToken(token, weight); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// MustParseRange is ParseRange for notation known to be valid.
+func MustParseRange(text string) *Range {
+	r, err := ParseRange(text)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+type handClass struct {
+	high, low int
+	suits     byte // 's', 'o', or 0 for both; always 0 for pairs
+}
+
+func parseHandClass(text string) (handClass, bool) {
+	if len(text) < 2 || len(text) > 3 {
+		return handClass{}, false
+	}
+	high := strings.IndexByte(rankLetters, upper(text[0])) + 2
+	low := strings.IndexByte(rankLetters, upper(text[1])) + 2
+	if high < 2 || low < 2 {
+		return handClass{}, false
+	}
+	if low > high {
+		high, low = low, high
+	}
+	h := handClass{high: high, low: low}
+	if len(text) == 3 {
+		h.suits = text[2] | 0x20
+		if h.suits != 's' && h.suits != 'o' || high == low {
+			return handClass{}, false
+		}
+	}
+	return h, true
+}
+
+func upper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - 0x20
+	}
+	return b
+}
+
+func (r *Range) addToken(token string, weight float64) error {
+	bad := fmt.Errorf("invalid range entry %q", token)
+	switch {
+	case strings.HasSuffix(token, "+"):
+		h, ok := parseHandClass(token[:len(token)-1])
+		if !ok {
+			return bad
+		}
+		top := h.high - 1
+		if h.high == h.low {
+			top = 14
+		}
+		r.addRun(h, top, weight)
+	case strings.Contains(token, "-"):
+		parts := strings.SplitN(token, "-", 2)
+		a, okA := parseHandClass(strings.TrimSpace(parts[0]))
+		b, okB := parseHandClass(strings.TrimSpace(parts[1]))
+		if !okA || !okB || a.suits != b.suits || (a.high == a.low) != (b.high == b.low) {
+			return bad
+		}
+		if a.high != a.low && a.high != b.high {
+			return bad
+		}
+		if b.low > a.low {
+			a, b = b, a
+		}
+		r.addRun(b, a.low, weight)
+	case len(token) == 4:
+		a, errA := ParseCard(token[:2])
+		b, errB := ParseCard(token[2:])
+		if errA != nil || errB != nil || a == b {
+			return bad
+		}
+		r.Set(a, b, weight)
+	default:
+		h, ok := parseHandClass(token)
+		if !ok {
+			return bad
+		}
+		r.addClass(h, weight)
+	}
+	return nil
+}
+
+func (r *Range) addRun(h handClass, top int, weight float64) {
+	pair := h.high == h.low
+	for low := h.low; low <= top; low++ {
+		c := h
+		c.low = low
+		if pair {
+			c.high = low
+		}
+		r.addClass(c, weight)
+	}
+}
+
+func (r *Range) addClass(h handClass, weight float64) {
+	for _, i := range h.combos() {
+		r.weights[i] = weight
+	}
+}
+
+func (h handClass) combos() []int {
+	var out []int
+	for s1 := Clubs; s1 <= Spades; s1++ {
+		for s2 := Clubs; s2 <= Spades; s2++ {
+			switch {
+			case h.high == h.low && s1 >= s2:
+				continue
+			case h.suits == 's' && s1 != s2, h.suits == 'o' && s1 == s2:
+				continue
+			}
+			i, _ := comboOf(Card{Rank: h.high, Suit: s1}, Card{Rank: h.low, Suit: s2})
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Weight returns the weight of holding a and b, zero if it is not in r.
+func (r *Range) Weight(a, b Card) float64 {
+	i, ok := comboOf(a, b)
+	if !ok {
+		return 0
+	}
+	return r.weights[i]
+}
+
+// Set gives the holding a and b the weight w; zero or less removes it.
+func (r *Range) Set(a, b Card, w float64) {
+	i, ok := comboOf(a, b)
+	if !ok {
+		return
+	}
+	if w < 0 {
+		w = 0
+	}
+	r.weights[i] = w
+}
+
+// Each calls fn with every holding in r and its weight, in a fixed order.
+func (r *Range) Each(fn func(hole [2]Card, weight float64)) {
+	for i, w := range r.weights {
+		if w > 0 {
+			fn(comboCards[i], w)
+		}
+	}
+}
+
+// Count is the number of holdings in r, whatever their weight.
+func (r *Range) Count() int {
+	n := 0
+	for _, w := range r.weights {
+		if w > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Combos is the weighted number of holdings in r, so "KQo:0.5" counts 6.
+func (r *Range) Combos() float64 {
+	total := 0.0
+	for _, w := range r.weights {
+		total += w
+	}
+	return total
+}
+
+// Clone returns a copy of r.
+func (r *Range) Clone() *Range {
+	out := *r
+	return &out
+}
+
+// Union holds every holding of r or o at the larger of its two weights.
+func (r *Range) Union(o *Range) *Range {
+	out := r.Clone()
+	for i, w := range o.weights {
+		if w > out.weights[i] {
+			out.weights[i] = w
+		}
+	}
+	return out
+}
+
+// Intersect holds the holdings in both r and o at the smaller weight.
+func (r *Range) Intersect(o *Range) *Range {
+	out := r.Clone()
+	for i, w := range o.weights {
+		if w < out.weights[i] {
+			out.weights[i] = w
+		}
+	}
+	return out
+}
+
+// Minus holds the holdings of r that are not in o.
+func (r *Range) Minus(o *Range) *Range {
+	out := r.Clone()
+	for i, w := range o.weights {
+		if w > 0 {
+			out.weights[i] = 0
+		}
+	}
+	return out
+}
+
+// Without drops every holding that uses a card in dead, such as the board or
+// a player's own hole cards.
+func (r *Range) Without(dead CardMask) *Range {
+	out := r.Clone()
+	for i, cards := range comboCards {
+		if dead&(cards[0].Mask()|cards[1].Mask()) != 0 {
+			out.weights[i] = 0
+		}
+	}
+	return out
+}
+
+// String writes r in the notation ParseRange reads, grouping whole hands into
+// runs such as "TT+" and "A5s-A2s" and listing partial hands combo by combo.
+func (r *Range) String() string {
+	covered := [NumCombos]bool{}
+	// classWeight is the shared weight of every combo of h, or 0 if they
+	// differ or any is missing.
+	classWeight := func(h handClass) float64 {
+		combos := h.combos()
+		w := r.weights[combos[0]]
+		for _, i := range combos {
+			if r.weights[i] != w {
+				return 0
+			}
+		}
+		if w > 0 {
+			for _, i := range combos {
+				covered[i] = true
+			}
+		}
+		return w
+	}
+
+	var parts []string
+	// Pairs, aces down.
+	var pairs [15]float64
+	for rank := 2; rank <= 14; rank++ {
+		pairs[rank] = classWeight(handClass{high: rank, low: rank})
+	}
+	for rank := 14; rank >= 2; {
+		w := pairs[rank]
+		end := rank
+		for end > 2 && pairs[end-1] == w {
+			end--
+		}
+		if w > 0 {
+			parts = append(parts, runText(handClass{high: rank, low: rank}, end, rank == 14, w))
+		}
+		rank = end - 1
+	}
+	// Suited, then offsuit hands, each by high card with kickers down.
+	for _, suits := range []byte{'s', 'o'} {
+		for high := 14; high >= 3; high-- {
+			var kickers [15]float64
+			for low := 2; low < high; low++ {
+				kickers[low] = classWeight(handClass{high: high, low: low, suits: suits})
+			}
+			for low := high - 1; low >= 2; {
+				w := kickers[low]
+				end := low
+				for end > 2 && kickers[end-1] == w {
+					end--
+				}
+				if w > 0 {
+					parts = append(parts, runText(handClass{high: high, low: low, suits: suits}, end, low == high-1, w))
+				}
+				low = end - 1
+			}
+		}
+	}
+	for i, w := range r.weights {
+		if w > 0 && !covered[i] {
+			parts = append(parts, comboCards[i][1].String()+comboCards[i][0].String()+weightText(w))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runText(h handClass, end int, fromTop bool, w float64) string {
+	last := h
+	last.low = end
+	if h.high == h.low {
+		last.high = end
+	}
+	switch {
+	case last == h:
+		return h.text() + weightText(w)
+	case fromTop:
+		return last.text() + "+" + weightText(w)
+	}
+	return h.text() + "-" + last.text() + weightText(w)
+}
+
+func (h handClass) text() string {
+	s := string(rankLetters[h.high-2]) + string(rankLetters[h.low-2])
+	if h.suits != 0 {
+		s += string(h.suits)
+	}
+	return s
+}
+
+func weightText(w float64) string {
+	if w == 1 {
+		return ""
+	}
+	return ":" + strconv.FormatFloat(w, 'f', -1, 64)
+}
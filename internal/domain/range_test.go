@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestParseRange_CountsStandardNotation(t *testing.T) {
+	r, err := ParseRange("TT+, AKs, A5s-A2s, KQo:0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 pairs x 6 + 4 + 4 x 4 + 12 half-weighted.
+	if r.Count() != 62 || r.Combos() != 56 {
+		t.Fatalf("expected 62 combos weighing 56, got %d weighing %v", r.Count(), r.Combos())
+	}
+	if w := r.Weight(Card{13, Hearts}, Card{12, Spades}); w != 0.5 {
+		t.Fatalf("expected KhQs at 0.5, got %v", w)
+	}
+	if w := r.Weight(Card{13, Hearts}, Card{12, Hearts}); w != 0 {
+		t.Fatalf("expected KQs to be out of range, got %v", w)
+	}
+	if w := r.Weight(Card{3, Clubs}, Card{14, Clubs}); w != 1 {
+		t.Fatalf("expected A3s in range, got %v", w)
+	}
+}
+
+func TestParseRange_Forms(t *testing.T) {
+	cases := map[string]int{
+		"22+":      78,
+		"88-55":    24,
+		"55-88":    24,
+		"AK":       16,
+		"KTs+":     12,
+		"kto+":     36,
+		"AhKh, AA": 7,
+		"AKs,AKs":  4,
+		"T9s:0.25": 4,
+	}
+	for text, want := range cases {
+		r, err := ParseRange(text)
+		if err != nil {
+			t.Fatalf("%q: %v", text, err)
+		}
+		if r.Count() != want {
+			t.Fatalf("%q: expected %d combos, got %d", text, want, r.Count())
+		}
+	}
+	for _, text := range []string{"AAs", "AX", "AKs-KQs", "88-AKs", "AKs:0", "AKs:2", "AhAh", "AKs-A2o"} {
+		if _, err := ParseRange(text); err == nil {
+			t.Fatalf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestRange_StringRoundTrips(t *testing.T) {
+	cases := map[string]string{
+		"TT+, AKs, A5s-A2s, KQo:0.5": "TT+, AKs, A5s-A2s, KQo:0.5",
+		"88-55, 22":                  "88-55, 22",
+		"KTs+, AK":                   "AKs, KTs+, AKo",
+		"AA, AhKh:0.5":               "AA, AhKh:0.5",
+		"":                           "",
+	}
+	for text, want := range cases {
+		r := MustParseRange(text)
+		if got := r.String(); got != want {
+			t.Fatalf("%q: expected %q, got %q", text, want, got)
+		}
+		again := MustParseRange(r.String())
+		if *again != *r {
+			t.Fatalf("%q: round trip changed the range", text)
+		}
+	}
+}
+
+func TestRange_SetOperationsAndBlockers(t *testing.T) {
+	a := MustParseRange("QQ+, AKs")
+	b := MustParseRange("KK+:0.5, AQs")
+	if got := a.Union(b).String(); got != "QQ+, AQs+" {
+		t.Fatalf("union: got %q", got)
+	}
+	if got := a.Intersect(b).String(); got != "KK+:0.5" {
+		t.Fatalf("intersect: got %q", got)
+	}
+	if got := a.Minus(b).String(); got != "QQ, AKs" {
+		t.Fatalf("minus: got %q", got)
+	}
+
+	// The ace of hearts on board leaves three aces: three pairs and three
+	// suited AK.
+	board := MaskOf([]Card{{14, Hearts}, {7, Clubs}, {2, Diamonds}})
+	left := MustParseRange("AA, AKs").Without(board)
+	if left.Count() != 6 || left.Weight(Card{14, Hearts}, Card{13, Hearts}) != 0 {
+		t.Fatalf("expected blocked combos removed, got %q", left.String())
+	}
+	if a.Count() != 22 {
+		t.Fatalf("expected set operations to leave their inputs alone, got %d", a.Count())
+	}
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxRunouts is the most boards players may agree to run when they are all-in
+// before the river.
+const MaxRunouts = 3
+
+// ActionRunIt is the action an all-in player sends to say how many times the
+// rest of the board should be dealt; the amount is the number of runs and 1
+// declines.
+const ActionRunIt = "run_it"
+
+// Runout is one of several boards dealt for the same all-in hand, or one of
+// the two boards of a double-board bomb pot, together with the share of every
+// pot it awarded.
+type Runout struct {
+	Board []Card `json:"board"`
+	Pots  []Pot  `json:"pots"`
+	// Hands maps each player still in the hand to their best hand name on
+	// this board.
+	Hands map[string]string `json:"hands"`
+	// Lows maps each player with a qualifying low on this board to its name
+	// in a hi-lo game.
+	Lows map[string]string `json:"lows,omitempty"`
+}
+
+func (g *GameState) runoutReady() bool {
+	if len(g.CommunityCards) >= 5 || g.countActive() < 2 {
+		return false
+	}
+	for _, p := range g.activePlayers() {
+		if !p.AllIn {
+			return false
+		}
+	}
+	return true
+}
+
+// The lowest vote on how many times to run it wins; AI players go along with
+// the humans.
+func (g *GameState) awaitRunoutAgreement() bool {
+	if !g.RunItTwice || g.Runs > 0 || !g.runoutReady() {
+		return false
+	}
+	runs := MaxRunouts
+	voters := 0
+	waiting := false
+	for _, p := range g.activePlayers() {
+		if p.IsAI || p.AIManaged {
+			continue
+		}
+		voters++
+		vote, ok := g.RunoutVotes[p.UserID]
+		if !ok {
+			waiting = true
+			continue
+		}
+		if vote < runs {
+			runs = vote
+		}
+	}
+	if waiting && runs > 1 {
+		g.RunoutPending = true
+		return true
+	}
+	if voters == 0 {
+		runs = 1
+	}
+	g.RunoutPending = false
+	g.Runs = runs
+	g.ActionLogs = append(g.ActionLogs, ActionLog{Action: "runout_agreed", Amount: runs, Stage: string(g.Stage)})
+	return false
+}
+
+func (g *GameState) voteRunout(userID string, runs int) error {
+	if !g.RunoutPending {
+		return errors.New("no runout to agree on")
+	}
+	var voter *GamePlayer
+	for _, p := range g.activePlayers() {
+		if p.UserID == userID {
+			voter = p
+			break
+		}
+	}
+	if voter == nil {
+		return errors.New("player not in hand")
+	}
+	if runs < 1 || runs > MaxRunouts {
+		return fmt.Errorf("runs must be between 1 and %d", MaxRunouts)
+	}
+	if _, ok := g.RunoutVotes[userID]; ok {
+		return errors.New("runout already chosen")
+	}
+	g.RunoutVotes[userID] = runs
+	g.logAction(voter, ActionRunIt, runs)
+	g.advanceStage()
+	return nil
+}
+
+func (g *GameState) runOutBoards() {
+	base := append([]Card(nil), g.CommunityCards...)
+	need := 5 - len(base)
+	runs := g.Runs
+	if left := (len(g.Deck) - g.DeckPos) / need; runs > left {
+		runs = left
+	}
+	boards := make([][]Card, runs)
+	for i := range boards {
+		board := append([]Card(nil), base...)
+		for j := 0; j < need; j++ {
+			board = append(board, g.draw())
+		}
+		boards[i] = board
+		ev := Event{Type: EventStreet, Stage: StageRiver, Cards: board[len(base):], Board: board}
+		if runs > 1 {
+			ev.Run = i + 1
+		}
+		g.emit(ev)
+	}
+	g.Runs = runs
+	g.CommunityCards = boards[0]
+	g.Stage = StageShowdown
+	g.finishShowdownOn(boards)
+}
+
+// Odd chips go to the earlier boards.
+func runShare(amount, runs, run int) int {
+	share := amount / runs
+	if run < amount%runs {
+		share++
+	}
+	return share
+}
@@ -0,0 +1,115 @@
+package domain
+
+// ActionShow and ActionMuck are logged for every player still in the hand
+// when their cards are turned over or thrown away, in the order it happens.
+const (
+	ActionShow = "show"
+	ActionMuck = "muck"
+)
+
+// The last aggressor of the final round shows first, or the first player left
+// of the button when it was checked through.
+func (g *GameState) showdownOrder() []*GamePlayer {
+	n := len(g.Players)
+	start := (g.DealerPos + 1) % n
+	for i, p := range g.Players {
+		if g.Aggressor != "" && p.UserID == g.Aggressor && !p.Folded {
+			start = i
+			break
+		}
+	}
+	order := make([]*GamePlayer, 0, n)
+	for i := 0; i < n; i++ {
+		if p := g.Players[(start+i)%n]; !p.Folded {
+			order = append(order, p)
+		}
+	}
+	return order
+}
+
+// Hands are turned face up once no more betting can happen.
+func (g *GameState) exposeAllIn() {
+	if len(g.CommunityCards) >= 5 || g.countActive() < 2 {
+		return
+	}
+	open := 0
+	for _, p := range g.activePlayers() {
+		if !p.AllIn {
+			open++
+		}
+	}
+	if open > 1 {
+		return
+	}
+	for _, p := range g.showdownOrder() {
+		if !p.Exposed {
+			p.Exposed = true
+			g.showHand(p)
+		}
+	}
+}
+
+// A hand that cannot win or split any pot it is eligible for is mucked.
+func (g *GameState) showdown(strengths []map[string]HandRank, lows []map[string]LowRank) {
+	shown := make([]*GamePlayer, 0, len(g.Players))
+	for _, p := range g.showdownOrder() {
+		switch {
+		case p.Exposed:
+			shown = append(shown, p)
+		case g.beaten(p, shown, strengths, lows):
+			g.muckHand(p)
+		default:
+			g.showHand(p)
+			shown = append(shown, p)
+		}
+	}
+}
+
+func (g *GameState) beaten(p *GamePlayer, shown []*GamePlayer, strengths []map[string]HandRank, lows []map[string]LowRank) bool {
+	for _, pot := range g.Pots {
+		if !containsID(pot.EligibleIDs, p.UserID) {
+			continue
+		}
+		for run, strength := range strengths {
+			lost := false
+			low := lows[run][p.UserID]
+			lostLow := low == 0
+			for _, s := range shown {
+				if !containsID(pot.EligibleIDs, s.UserID) {
+					continue
+				}
+				if strength[s.UserID] > strength[p.UserID] {
+					lost = true
+				}
+				if lows[run][s.UserID] > low {
+					lostLow = true
+				}
+			}
+			if !lost || !lostLow {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (g *GameState) showHand(p *GamePlayer) {
+	p.RevealMask = FullRevealMask(len(p.HoleCards))
+	g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: p.UserID, Username: p.Username, Action: ActionShow, Stage: string(g.Stage)})
+	g.emit(Event{Type: EventShow, UserID: p.UserID, Username: p.Username, Cards: revealedCards(p), HandName: p.BestHandName})
+}
+
+func (g *GameState) muckHand(p *GamePlayer) {
+	p.RevealMask = 0
+	g.ActionLogs = append(g.ActionLogs, ActionLog{UserID: p.UserID, Username: p.Username, Action: ActionMuck, Stage: string(g.Stage)})
+	g.emit(Event{Type: EventMuck, UserID: p.UserID, Username: p.Username})
+}
+
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package domain
+
+// Variant is the poker game played at a table.
+type Variant string
+
+const (
+	VariantHoldem Variant = "holdem"
+	VariantPLO    Variant = "plo"
+	// VariantShortDeck is no-limit hold'em dealt from a 36-card deck.
+	VariantShortDeck Variant = "shortdeck"
+	// VariantPLO8 is pot-limit Omaha hi-lo: every pot is split between the
+	// best high hand and the best eight-or-better low.
+	VariantPLO8 Variant = "plo8"
+)
+
+// ValidVariant reports whether v is a known variant; empty means hold'em.
+func ValidVariant(v Variant) bool {
+	switch v {
+	case "", VariantHoldem, VariantPLO, VariantShortDeck, VariantPLO8:
+		return true
+	}
+	return false
+}
+
+// IsOmaha reports whether players get four hole cards and must use exactly
+// two of them.
+func IsOmaha(v Variant) bool {
+	return v == VariantPLO || v == VariantPLO8
+}
+
+// IsHiLo reports whether pots are split between the best high hand and the
+// best eight-or-better low.
+func IsHiLo(v Variant) bool {
+	return v == VariantPLO8
+}
+
+// HoleCardCount is the number of hole cards dealt to each player.
+func HoleCardCount(v Variant) int {
+	if IsOmaha(v) {
+		return 4
+	}
+	return 2
+}
+
+// IsPotLimit reports whether bets and raises are capped at the pot size.
+func IsPotLimit(v Variant) bool {
+	return IsOmaha(v)
+}
+
+// BestHand evaluates a player's best five-card hand under the variant's rules:
+// any five of hole+board in hold'em, exactly two hole and three board cards in
+// PLO, and short-deck rankings for short-deck hold'em.
+func BestHand(v Variant, hole []Card, board []Card) (HandValue, []Card, string) {
+	cards := append(append([]Card{}, board...), hole...)
+	switch {
+	case IsOmaha(v):
+		return BestOmaha(hole, board)
+	case v == VariantShortDeck:
+		return bestOfCards(cards, true)
+	}
+	return BestOfSeven(cards)
+}
+
+// FullRevealMask is the reveal mask that shows all n hole cards.
+func FullRevealMask(n int) int {
+	return 1<<n - 1
+}
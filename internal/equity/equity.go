@@ -0,0 +1,377 @@
+// Package equity works out how often each of several hands wins a pot. On the
+// turn and river it enumerates every remaining board and holding exactly;
+// preflop and on the flop it samples boards, drawing range holdings by weight.
+package equity
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"texas_yu/internal/domain"
+)
+
+const (
+	// DefaultTrials is how many deals are sampled when a request leaves Trials
+	// at zero.
+	DefaultTrials = 20000
+	// MaxTrials caps the deals a single request may sample.
+	MaxTrials = 200000
+	// MaxExactOutcomes is the most boards times holdings enumerated before a
+	// turn or river request falls back to sampling.
+	MaxExactOutcomes = 2000000
+)
+
+// Seat is one player in the calculation: known hole cards, or a range of
+// holdings when Hole is empty, each drawn in proportion to its weight.
+type Seat struct {
+	Hole  []domain.Card
+	Range *domain.Range
+}
+
+// Request describes the spot to evaluate. Dead cards are out of the deck but
+// belong to no one.
+type Request struct {
+	Variant domain.Variant
+	Board   []domain.Card
+	Dead    []domain.Card
+	Seats   []Seat
+	// Trials is the number of deals sampled preflop and on the flop; zero
+	// means DefaultTrials.
+	Trials int
+	Seed   int64
+}
+
+// SeatResult is one seat's share of the outcomes. Win and Tie are the chances
+// of scooping and of splitting; Equity counts each split as its share.
+type SeatResult struct {
+	Equity float64 `json:"equity"`
+	Win    float64 `json:"win"`
+	Tie    float64 `json:"tie"`
+}
+
+// Result holds a SeatResult per requested seat, in order.
+type Result struct {
+	Seats []SeatResult `json:"seats"`
+	// Exact reports whether every outcome was enumerated rather than sampled.
+	Exact bool `json:"exact"`
+	// Outcomes is the number of finished boards evaluated.
+	Outcomes int `json:"outcomes"`
+}
+
+var errNoDeal = errors.New("ranges leave no possible deal")
+
+// combo is one holding of a range.
+type combo struct {
+	Cards  [2]domain.Card
+	Weight float64
+}
+
+type seat struct {
+	hole   []domain.Card
+	combos []combo
+	// cum holds the running total of combo weights, for weighted draws.
+	cum []float64
+}
+
+type calc struct {
+	variant domain.Variant
+	board   []domain.Card
+	deck    []domain.Card
+	used    domain.CardMask
+	seats   []seat
+
+	ranks  []domain.HandRank
+	holes  [][]domain.Card
+	equity []float64
+	win    []float64
+	tie    []float64
+	total  float64
+	count  int
+}
+
+// Calculate evaluates req, enumerating exactly once four or more board cards
+// are out and the work fits MaxExactOutcomes, and sampling otherwise.
+func Calculate(req Request) (Result, error) {
+	c, err := newCalc(req)
+	if err != nil {
+		return Result{}, err
+	}
+	exact := len(req.Board) >= 4 && c.exactOutcomes() <= MaxExactOutcomes
+	if exact {
+		c.enumerate()
+	} else {
+		trials := req.Trials
+		if trials <= 0 {
+			trials = DefaultTrials
+		}
+		if trials > MaxTrials {
+			trials = MaxTrials
+		}
+		c.sample(trials, req.Seed)
+	}
+	if c.total <= 0 {
+		return Result{}, errNoDeal
+	}
+	res := Result{Seats: make([]SeatResult, len(c.seats)), Exact: exact, Outcomes: c.count}
+	for i := range c.seats {
+		res.Seats[i] = SeatResult{Equity: c.equity[i] / c.total, Win: c.win[i] / c.total, Tie: c.tie[i] / c.total}
+	}
+	return res, nil
+}
+
+func newCalc(req Request) (*calc, error) {
+	if !domain.ValidVariant(req.Variant) {
+		return nil, errors.New("invalid variant")
+	}
+	if domain.IsHiLo(req.Variant) {
+		return nil, errors.New("equity is not available for hi-lo games")
+	}
+	if len(req.Seats) < 2 {
+		return nil, errors.New("at least two seats are required")
+	}
+	if n := len(req.Board); n > 5 || n == 1 || n == 2 {
+		return nil, errors.New("board must hold 0, 3, 4 or 5 cards")
+	}
+	c := &calc{variant: req.Variant, board: req.Board}
+	inDeck := domain.MaskOf(domain.NewDeckFor(req.Variant))
+	take := func(cards []domain.Card) error {
+		for _, card := range cards {
+			m := card.Mask()
+			if card.Rank < 2 || card.Rank > 14 || card.Suit < domain.Clubs || card.Suit > domain.Spades || inDeck&m == 0 {
+				return fmt.Errorf("card %s is not in the deck", card)
+			}
+			if c.used&m != 0 {
+				return fmt.Errorf("card %s is used twice", card)
+			}
+			c.used |= m
+		}
+		return nil
+	}
+	if err := take(req.Board); err != nil {
+		return nil, err
+	}
+	if err := take(req.Dead); err != nil {
+		return nil, err
+	}
+	holeCount := domain.HoleCardCount(req.Variant)
+	for i, s := range req.Seats {
+		if len(s.Hole) == 0 {
+			continue
+		}
+		if len(s.Hole) != holeCount {
+			return nil, fmt.Errorf("seat %d must hold %d cards", i, holeCount)
+		}
+		if err := take(s.Hole); err != nil {
+			return nil, err
+		}
+	}
+
+	c.seats = make([]seat, len(req.Seats))
+	for i, s := range req.Seats {
+		if len(s.Hole) > 0 {
+			c.seats[i].hole = s.Hole
+			continue
+		}
+		if holeCount != 2 {
+			return nil, fmt.Errorf("seat %d: ranges need a two-card variant", i)
+		}
+		total := 0.0
+		if s.Range != nil {
+			s.Range.Without(c.used | ^inDeck).Each(func(hole [2]domain.Card, weight float64) {
+				total += weight
+				c.seats[i].combos = append(c.seats[i].combos, combo{Cards: hole, Weight: weight})
+				c.seats[i].cum = append(c.seats[i].cum, total)
+			})
+		}
+		if len(c.seats[i].combos) == 0 {
+			return nil, fmt.Errorf("seat %d has no holdings left in its range", i)
+		}
+	}
+
+	for _, card := range domain.NewDeckFor(req.Variant) {
+		if c.used&card.Mask() == 0 {
+			c.deck = append(c.deck, card)
+		}
+	}
+	n := len(c.seats)
+	c.ranks = make([]domain.HandRank, n)
+	c.holes = make([][]domain.Card, n)
+	c.equity = make([]float64, n)
+	c.win = make([]float64, n)
+	c.tie = make([]float64, n)
+	return c, nil
+}
+
+// exactOutcomes bounds the work of enumeration: every holding of every range
+// against every way to finish the board, ignoring card removal.
+func (c *calc) exactOutcomes() float64 {
+	work := 1.0
+	dealt := 0
+	for _, s := range c.seats {
+		if s.hole == nil {
+			work *= float64(len(s.combos))
+			dealt += 2
+		}
+	}
+	left := len(c.deck) - dealt
+	for i := 0; i < 5-len(c.board); i++ {
+		work *= float64(left-i) / float64(i+1)
+	}
+	return work
+}
+
+// enumerate scores every holding for every range seat against every way to
+// finish the board, weighting each by its combos' weights.
+func (c *calc) enumerate() {
+	board := make([]domain.Card, 0, 5)
+	board = append(board, c.board...)
+	var assign func(i int, used domain.CardMask, weight float64)
+	assign = func(i int, used domain.CardMask, weight float64) {
+		if i == len(c.seats) {
+			c.eachBoard(board, 0, used, weight)
+			return
+		}
+		s := &c.seats[i]
+		if s.hole != nil {
+			c.holes[i] = s.hole
+			assign(i+1, used, weight)
+			return
+		}
+		for k := range s.combos {
+			h := &s.combos[k]
+			m := h.Cards[0].Mask() | h.Cards[1].Mask()
+			if used&m != 0 {
+				continue
+			}
+			c.holes[i] = h.Cards[:]
+			assign(i+1, used|m, weight*h.Weight)
+		}
+	}
+	assign(0, c.used, 1)
+}
+
+func (c *calc) eachBoard(board []domain.Card, from int, used domain.CardMask, weight float64) {
+	if len(board) == 5 {
+		c.score(board, weight)
+		return
+	}
+	for i := from; i < len(c.deck); i++ {
+		if used&c.deck[i].Mask() != 0 {
+			continue
+		}
+		c.eachBoard(append(board, c.deck[i]), i+1, used, weight)
+	}
+}
+
+// sample deals trials random holdings and boards. A range seat's holding is
+// drawn by weight from those its opponents' cards leave possible.
+func (c *calc) sample(trials int, seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+	work := make([]domain.Card, len(c.deck))
+	board := make([]domain.Card, 0, 5)
+	need := 5 - len(c.board)
+	for t := 0; t < trials; t++ {
+		used := c.used
+		dealt := true
+		for i := range c.seats {
+			s := &c.seats[i]
+			if s.hole != nil {
+				c.holes[i] = s.hole
+				continue
+			}
+			k := s.draw(rng, used)
+			if k < 0 {
+				dealt = false
+				break
+			}
+			c.holes[i] = s.combos[k].Cards[:]
+			used |= s.combos[k].Cards[0].Mask() | s.combos[k].Cards[1].Mask()
+		}
+		if !dealt {
+			continue
+		}
+		n := 0
+		for _, card := range c.deck {
+			if used&card.Mask() == 0 {
+				work[n] = card
+				n++
+			}
+		}
+		for i := 0; i < need; i++ {
+			j := i + rng.Intn(n-i)
+			work[i], work[j] = work[j], work[i]
+		}
+		board = append(append(board[:0], c.board...), work[:need]...)
+		c.score(board, 1)
+	}
+}
+
+// draw picks a combo index by weight among those not blocked by used, or
+// returns -1 when every combo is blocked.
+func (s *seat) draw(rng *rand.Rand, used domain.CardMask) int {
+	total := s.cum[len(s.cum)-1]
+	for attempt := 0; attempt < 32; attempt++ {
+		k := sort.SearchFloat64s(s.cum, rng.Float64()*total)
+		if k < len(s.combos) && !s.blocked(k, used) {
+			return k
+		}
+	}
+	// Heavy card removal: draw from the combos still possible directly.
+	open := 0.0
+	for k := range s.combos {
+		if !s.blocked(k, used) {
+			open += s.combos[k].Weight
+		}
+	}
+	if open <= 0 {
+		return -1
+	}
+	x := rng.Float64() * open
+	for k := range s.combos {
+		if s.blocked(k, used) {
+			continue
+		}
+		if x -= s.combos[k].Weight; x < 0 {
+			return k
+		}
+	}
+	for k := len(s.combos) - 1; k >= 0; k-- {
+		if !s.blocked(k, used) {
+			return k
+		}
+	}
+	return -1
+}
+
+func (s *seat) blocked(k int, used domain.CardMask) bool {
+	return used&(s.combos[k].Cards[0].Mask()|s.combos[k].Cards[1].Mask()) != 0
+}
+
+func (c *calc) score(board []domain.Card, weight float64) {
+	var best domain.HandRank
+	winners := 0
+	for i, hole := range c.holes {
+		r := domain.RankHand(c.variant, hole, board)
+		c.ranks[i] = r
+		if r > best {
+			best, winners = r, 1
+		} else if r == best {
+			winners++
+		}
+	}
+	for i, r := range c.ranks {
+		if r != best {
+			continue
+		}
+		if winners == 1 {
+			c.win[i] += weight
+		} else {
+			c.tie[i] += weight
+		}
+		c.equity[i] += weight / float64(winners)
+	}
+	c.total += weight
+	c.count++
+}
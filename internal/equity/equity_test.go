@@ -0,0 +1,156 @@
+package equity
+
+import (
+	"math"
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+func cards(t *testing.T, texts ...string) []domain.Card {
+	t.Helper()
+	out, err := domain.ParseCards(texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func near(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+func TestCalculate_RiverIsExact(t *testing.T) {
+	res, err := Calculate(Request{
+		Board: cards(t, "2h", "7h", "9c", "Js", "Kd"),
+		Seats: []Seat{{Hole: cards(t, "Ah", "Kh")}, {Hole: cards(t, "Qs", "Qd")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exact || res.Outcomes != 1 {
+		t.Fatalf("expected one exact outcome, got %+v", res)
+	}
+	if res.Seats[0].Equity != 1 || res.Seats[1].Equity != 0 {
+		t.Fatalf("expected kings to scoop, got %+v", res.Seats)
+	}
+}
+
+func TestCalculate_TurnEnumeratesEveryRiver(t *testing.T) {
+	// Nine hearts plus the three other aces and kings win 15 of the 44 rivers.
+	res, err := Calculate(Request{
+		Board: cards(t, "2h", "7h", "9c", "Js"),
+		Seats: []Seat{{Hole: cards(t, "Ah", "Kh")}, {Hole: cards(t, "Qs", "Qd")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Exact || res.Outcomes != 44 {
+		t.Fatalf("expected 44 exact rivers, got %+v", res)
+	}
+	if want := 15.0 / 44; !near(res.Seats[0].Win, want, 1e-9) {
+		t.Fatalf("expected win %.4f, got %.4f", want, res.Seats[0].Win)
+	}
+	if res.Seats[0].Equity+res.Seats[1].Equity < 1-1e-9 || res.Seats[0].Tie != 0 {
+		t.Fatalf("expected shares to add up with no ties, got %+v", res.Seats)
+	}
+}
+
+func TestCalculate_WeightedRangeMatchesItsHands(t *testing.T) {
+	board := cards(t, "2h", "7h", "9c", "Js")
+	hero := Seat{Hole: cards(t, "Ah", "Kh")}
+	single := func(s Seat) float64 {
+		res, err := Calculate(Request{Board: board, Seats: []Seat{hero, s}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Seats[0].Equity
+	}
+	queens := single(Seat{Hole: cards(t, "Qs", "Qd")})
+	deuces := single(Seat{Hole: cards(t, "2s", "2d")})
+	mixed := single(Seat{Range: domain.MustParseRange("QsQd, 2s2d:0.75")})
+	if want := (queens + 0.75*deuces) / 1.75; !near(mixed, want, 1e-9) {
+		t.Fatalf("expected weighted equity %.6f, got %.6f", want, mixed)
+	}
+}
+
+func TestCalculate_RangeDropsBlockedCombos(t *testing.T) {
+	res, err := Calculate(Request{
+		Board: cards(t, "2h", "7h", "9c", "Js", "Kd"),
+		Seats: []Seat{
+			{Hole: cards(t, "Ah", "Kh")},
+			{Range: domain.MustParseRange("AhAs, QsQd:0.2")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Seats[0].Equity != 1 {
+		t.Fatalf("expected only queens left once the ace of hearts blocks AhAs, got %+v", res.Seats)
+	}
+}
+
+func TestCalculate_PreflopSamples(t *testing.T) {
+	res, err := Calculate(Request{
+		Seats:  []Seat{{Hole: cards(t, "Ah", "As")}, {Hole: cards(t, "Kc", "Kd")}},
+		Trials: 50000,
+		Seed:   17,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Exact || res.Outcomes != 50000 {
+		t.Fatalf("expected 50000 samples, got %+v", res)
+	}
+	if !near(res.Seats[0].Equity, 0.82, 0.01) {
+		t.Fatalf("expected aces near 82%% over kings, got %.4f", res.Seats[0].Equity)
+	}
+}
+
+func TestCalculate_SampledRangeFollowsWeights(t *testing.T) {
+	board := cards(t, "2h", "7h", "9c")
+	res, err := Calculate(Request{
+		Board:  board,
+		Seats:  []Seat{{Hole: cards(t, "Kc", "Kd")}, {Range: domain.MustParseRange("AcAd, 3c3d")}},
+		Trials: 40000,
+		Seed:   3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kings are far behind aces and far ahead of threes, so an even range
+	// lands them near half.
+	if !near(res.Seats[0].Equity, 0.5, 0.03) {
+		t.Fatalf("expected kings near 50%%, got %.4f", res.Seats[0].Equity)
+	}
+}
+
+func TestCalculate_RejectsBadRequests(t *testing.T) {
+	hero := Seat{Hole: cards(t, "Ah", "Kh")}
+	cases := map[string]Request{
+		"one seat":         {Seats: []Seat{hero}},
+		"duplicate card":   {Board: cards(t, "Ah", "2c", "3d"), Seats: []Seat{hero, {Hole: cards(t, "Qs", "Qd")}}},
+		"short deck card":  {Variant: domain.VariantShortDeck, Seats: []Seat{hero, {Hole: cards(t, "2s", "2d")}}},
+		"two-card board":   {Board: cards(t, "2c", "3d"), Seats: []Seat{hero, {Hole: cards(t, "Qs", "Qd")}}},
+		"empty range":      {Seats: []Seat{hero, {Range: domain.MustParseRange("AhAd")}}},
+		"plo range":        {Variant: domain.VariantPLO, Seats: []Seat{{Hole: cards(t, "Ah", "Kh", "Qh", "Jh")}, {Range: domain.MustParseRange("22")}}},
+		"wrong hole count": {Variant: domain.VariantPLO, Seats: []Seat{hero, {Hole: cards(t, "Qs", "Qd", "Qc", "2d")}}},
+		"hi-lo":            {Variant: domain.VariantPLO8, Seats: []Seat{{Hole: cards(t, "Ah", "2h", "Qh", "Jh")}, {Hole: cards(t, "Ks", "Kd", "3c", "4c")}}},
+	}
+	for name, req := range cases {
+		if _, err := Calculate(req); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func BenchmarkCalculate_TurnHandVsRange(b *testing.B) {
+	board, _ := domain.ParseCards([]string{"2h", "7h", "9c", "Js"})
+	hero, _ := domain.ParseCards([]string{"Ah", "Kh"})
+	req := Request{Board: board, Seats: []Seat{{Hole: hero}, {Range: domain.MustParseRange("22+, A2+, K2+, Q2+, J2+, T2+, 92+, 82+, 72+, 62+, 52+, 42+, 32")}}}
+	for i := 0; i < b.N; i++ {
+		if _, err := Calculate(req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+// Package handhistory writes finished hands in the PokerStars text format
+// that tracking and review tools import.
+package handhistory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+// Hand is one finished hand as the table saw it. ID is the room's
+// HandCounter for the hand and ButtonSeat the seat the button was on, which
+// may be empty under the dead button rule.
+type Hand struct {
+	ID         int64
+	Table      string
+	StartedAt  time.Time
+	ButtonSeat int
+	Game       *domain.GameState
+}
+
+// handSeparator goes between hands in a bulk export, as in PokerStars files.
+const handSeparator = "\n\n\n"
+
+var runNames = []string{"FIRST", "SECOND", "THIRD"}
+
+// PokerStars renders a finished hand as PokerStars hand history text for
+// viewerID. Only the viewer's own hole cards and the cards other players
+// revealed at the end are written out.
+func PokerStars(h Hand, viewerID string) (string, error) {
+	g := h.Game
+	if g == nil || g.Stage != domain.StageFinished {
+		return "", errors.New("hand not finished")
+	}
+	if len(g.Events) == 0 || g.Events[0].Table == nil {
+		return "", errors.New("hand start not recorded")
+	}
+	w := &writer{hand: h, game: g, start: g.Events[0].Table, viewer: viewerID}
+	w.players = make(map[string]*domain.GamePlayer, len(g.Players))
+	for _, p := range g.Players {
+		w.players[p.UserID] = p
+	}
+	w.header()
+	w.streets()
+	w.showdown()
+	w.summary()
+	return strings.TrimRight(w.b.String(), "\n"), nil
+}
+
+// PokerStarsAll renders several hands one after another, the way a
+// PokerStars hand history file holds a whole session.
+func PokerStarsAll(hands []Hand, viewerID string) (string, error) {
+	out := make([]string, 0, len(hands))
+	for _, h := range hands {
+		text, err := PokerStars(h, viewerID)
+		if err != nil {
+			return "", fmt.Errorf("hand #%d: %w", h.ID, err)
+		}
+		out = append(out, text)
+	}
+	return strings.Join(out, handSeparator), nil
+}
+
+type writer struct {
+	b       strings.Builder
+	hand    Hand
+	game    *domain.GameState
+	start   *domain.EventTable
+	viewer  string
+	players map[string]*domain.GamePlayer
+	// foldedOn is the street each player folded on.
+	foldedOn map[string]domain.GameStage
+}
+
+func (w *writer) line(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+	w.b.WriteByte('\n')
+}
+
+func (w *writer) header() {
+	g := w.game
+	stakes := fmt.Sprintf("%d/%d", w.start.SmallBlind, w.start.BigBlind)
+	if g.FixedLimit {
+		stakes = fmt.Sprintf("%d/%d", g.SmallBet, g.BigBet)
+	}
+	w.line("PokerStars Hand #%d:  %s (%s) - %s", w.hand.ID, gameName(g), stakes, w.hand.StartedAt.UTC().Format("2006/01/02 15:04:05")+" UTC")
+	w.line("Table '%s' %d-max Seat #%d is the button", w.hand.Table, maxSeats(w.start.Seats), w.buttonSeat()+1)
+	for _, s := range w.start.Seats {
+		w.line("Seat %d: %s (%d in chips)", s.Seat+1, s.Username, s.Stack)
+	}
+}
+
+func (w *writer) buttonSeat() int {
+	if w.hand.ButtonSeat >= 0 || w.start.ButtonSeat < 0 {
+		return w.hand.ButtonSeat
+	}
+	return w.start.ButtonSeat
+}
+
+func gameName(g *domain.GameState) string {
+	name := "Hold'em"
+	switch g.Variant {
+	case domain.VariantPLO:
+		name = "Omaha"
+	case domain.VariantPLO8:
+		name = "Omaha Hi/Lo"
+	case domain.VariantShortDeck:
+		name = "6+ Hold'em"
+	}
+	switch {
+	case g.FixedLimit:
+		return name + " Limit"
+	case domain.IsPotLimit(g.Variant):
+		return name + " Pot Limit"
+	}
+	return name + " No Limit"
+}
+
+// maxSeats rounds the table up to the usual table sizes.
+func maxSeats(seats []domain.EventSeat) int {
+	n := len(seats)
+	for _, s := range seats {
+		if s.Seat+1 > n {
+			n = s.Seat + 1
+		}
+	}
+	for _, size := range []int{2, 6, 9} {
+		if n <= size {
+			return size
+		}
+	}
+	return n
+}
+
+// streets writes the forced bets, the hole cards and every betting round
+// with the board dealt before it.
+func (w *writer) streets() {
+	g := w.game
+	refunds := map[domain.GameStage][]domain.Event{}
+	for _, ev := range g.Events {
+		if ev.Type == domain.EventRefund {
+			refunds[ev.Stage] = append(refunds[ev.Stage], ev)
+		}
+	}
+	w.foldedOn = map[string]domain.GameStage{}
+
+	contrib := map[string]int{}
+	roundBet := 0
+	stage := domain.StagePreflop
+	dealt := false
+	closeStreet := func() {
+		for _, ev := range refunds[stage] {
+			w.line("Uncalled bet (%d) returned to %s", ev.Amount, ev.Username)
+		}
+	}
+	for _, l := range g.ActionLogs {
+		switch l.Action {
+		case domain.ActionRunIt, domain.ActionShow, domain.ActionMuck:
+			continue
+		}
+		if l.UserID == "" {
+			continue
+		}
+		switch l.Action {
+		case "ante":
+			w.line("%s: posts the ante %d", l.Username, l.Amount)
+			continue
+		case "small_blind":
+			w.line("%s: posts small blind %d", l.Username, l.Amount)
+		case "big_blind", "post":
+			w.line("%s: posts big blind %d", l.Username, l.Amount)
+		case "straddle":
+			w.line("%s: posts straddle %d", l.Username, l.Amount)
+		case "dead_blind":
+			w.line("%s: posts dead blind %d", l.Username, l.Amount)
+			continue
+		}
+		if isForced(l.Action) {
+			contrib[l.UserID] += l.Amount
+			if contrib[l.UserID] > roundBet {
+				roundBet = contrib[l.UserID]
+			}
+			continue
+		}
+		if !dealt {
+			w.holeCards()
+			dealt = true
+		}
+		if s := domain.GameStage(l.Stage); s != stage {
+			closeStreet()
+			for _, next := range []domain.GameStage{domain.StageFlop, domain.StageTurn, domain.StageRiver} {
+				if stageIndex(next) > stageIndex(stage) && stageIndex(next) <= stageIndex(s) {
+					w.boardStreets(next)
+				}
+			}
+			stage = s
+			contrib = map[string]int{}
+			roundBet = 0
+		}
+		w.action(l, contrib, &roundBet)
+	}
+	if !dealt {
+		w.holeCards()
+	}
+	closeStreet()
+	w.runouts(stage)
+}
+
+func isForced(action string) bool {
+	switch action {
+	case "ante", "small_blind", "big_blind", "straddle", "post", "dead_blind":
+		return true
+	}
+	return false
+}
+
+func (w *writer) holeCards() {
+	w.line("*** HOLE CARDS ***")
+	if p, ok := w.players[w.viewer]; ok {
+		w.line("Dealt to %s [%s]", p.Username, cardsText(p.HoleCards))
+	}
+}
+
+func (w *writer) action(l domain.ActionLog, contrib map[string]int, roundBet *int) {
+	switch l.Action {
+	case "check":
+		w.line("%s: checks", l.Username)
+	case "fold":
+		w.foldedOn[l.UserID] = domain.GameStage(l.Stage)
+		w.line("%s: folds", l.Username)
+	case "call":
+		contrib[l.UserID] += l.Amount
+		w.line("%s: calls %d", l.Username, l.Amount)
+	case "bet", "allin":
+		allIn := ""
+		if l.Action == "allin" {
+			allIn = " and is all-in"
+		}
+		total := contrib[l.UserID] + l.Amount
+		contrib[l.UserID] = total
+		switch {
+		case total <= *roundBet:
+			w.line("%s: calls %d%s", l.Username, l.Amount, allIn)
+		case *roundBet == 0:
+			w.line("%s: bets %d%s", l.Username, l.Amount, allIn)
+		default:
+			w.line("%s: raises %d to %d%s", l.Username, total-*roundBet, total, allIn)
+		}
+		if total > *roundBet {
+			*roundBet = total
+		}
+	}
+}
+
+// street writes the header of a street dealt on board; prefix names the
+// board when the hand was run more than once.
+func (w *writer) street(stage domain.GameStage, board []domain.Card, prefix string) {
+	name := ""
+	shown := 0
+	switch stage {
+	case domain.StageFlop:
+		name, shown = "FLOP", 3
+	case domain.StageTurn:
+		name, shown = "TURN", 4
+	case domain.StageRiver:
+		name, shown = "RIVER", 5
+	default:
+		return
+	}
+	if len(board) < shown {
+		return
+	}
+	if prefix != "" {
+		name = prefix + " " + name
+	}
+	if shown == 3 {
+		w.line("*** %s *** [%s]", name, cardsText(board[:3]))
+		return
+	}
+	w.line("*** %s *** [%s] [%s]", name, cardsText(board[:shown-1]), cardsText(board[shown-1:shown]))
+}
+
+// boardStreets writes the header of a street dealt before the betting on it,
+// once for each board of a double-board bomb pot.
+func (w *writer) boardStreets(stage domain.GameStage) {
+	g := w.game
+	if !g.DoubleBoard {
+		w.street(stage, g.CommunityCards, "")
+		return
+	}
+	for i, board := range [][]domain.Card{g.CommunityCards, g.SecondBoard} {
+		w.street(stage, board, runNames[i])
+	}
+}
+
+// runouts writes the streets dealt after the betting was over, once per board
+// when the hand was run more than once.
+func (w *writer) runouts(last domain.GameStage) {
+	g := w.game
+	rest := []domain.GameStage{}
+	for _, s := range []domain.GameStage{domain.StageFlop, domain.StageTurn, domain.StageRiver} {
+		if stageIndex(s) > stageIndex(last) {
+			rest = append(rest, s)
+		}
+	}
+	if len(g.Runouts) < 2 {
+		for _, s := range rest {
+			w.street(s, g.CommunityCards, "")
+		}
+		return
+	}
+	for i, run := range g.Runouts {
+		for _, s := range rest {
+			w.street(s, run.Board, runNames[i%len(runNames)])
+		}
+	}
+}
+
+func stageIndex(s domain.GameStage) int {
+	switch s {
+	case domain.StagePreflop:
+		return 0
+	case domain.StageFlop:
+		return 1
+	case domain.StageTurn:
+		return 2
+	case domain.StageRiver:
+		return 3
+	}
+	return 4
+}
+
+// revealed returns the hole cards p chose to show.
+func revealed(p *domain.GamePlayer) []domain.Card {
+	cards := make([]domain.Card, 0, len(p.HoleCards))
+	for i, c := range p.HoleCards {
+		if p.RevealMask&(1<<i) != 0 {
+			cards = append(cards, c)
+		}
+	}
+	return cards
+}
+
+func (w *writer) showdown() {
+	g := w.game
+	atShowdown := g.Result != nil && g.Result.Reason == "showdown"
+	if atShowdown {
+		w.line("*** SHOW DOWN ***")
+		for _, p := range w.showdownOrder() {
+			if cards := revealed(p); len(cards) > 0 {
+				w.line("%s: shows [%s] (%s)", p.Username, cardsText(cards), shownHandText(p))
+			} else {
+				w.line("%s: mucks hand", p.Username)
+			}
+		}
+	}
+	for _, ev := range g.Events {
+		if ev.Type != domain.EventPotAwarded {
+			continue
+		}
+		pot := "pot"
+		if atShowdown {
+			pot = potName(ev.Pot, len(g.Pots))
+		}
+		w.line("%s collected %d from %s", ev.Username, ev.Amount, pot)
+	}
+	if atShowdown {
+		return
+	}
+	for _, p := range g.Players {
+		if p.Folded {
+			continue
+		}
+		if cards := revealed(p); len(cards) > 0 {
+			w.line("%s: shows [%s]", p.Username, cardsText(cards))
+		} else {
+			w.line("%s: doesn't show hand", p.Username)
+		}
+	}
+}
+
+// showdownOrder lists the players still in the hand in the order they showed
+// or mucked, falling back to seat order for anyone the log does not name.
+func (w *writer) showdownOrder() []*domain.GamePlayer {
+	g := w.game
+	seen := map[string]bool{}
+	var order []*domain.GamePlayer
+	add := func(p *domain.GamePlayer) {
+		if p != nil && !p.Folded && !seen[p.UserID] {
+			seen[p.UserID] = true
+			order = append(order, p)
+		}
+	}
+	for _, l := range g.ActionLogs {
+		if l.Action == domain.ActionShow || l.Action == domain.ActionMuck {
+			add(w.players[l.UserID])
+		}
+	}
+	for _, p := range g.Players {
+		add(p)
+	}
+	return order
+}
+
+func potName(i, pots int) string {
+	switch {
+	case pots <= 1:
+		return "pot"
+	case i == 0:
+		return "main pot"
+	}
+	return fmt.Sprintf("side pot-%d", i)
+}
+
+func (w *writer) summary() {
+	g := w.game
+	w.line("*** SUMMARY ***")
+	rake := 0
+	if g.Result != nil {
+		rake = g.Result.Rake
+	}
+	w.line("Total pot %d | Rake %d", g.Pot, rake)
+	if g.DoubleBoard {
+		for i, board := range [][]domain.Card{g.CommunityCards, g.SecondBoard} {
+			w.line("%s Board [%s]", runNames[i], cardsText(board))
+		}
+	} else if len(g.Runouts) > 1 {
+		w.line("Hand was run %s times", strings.ToLower(numberWord(len(g.Runouts))))
+		for i, run := range g.Runouts {
+			w.line("%s Board [%s]", runNames[i%len(runNames)], cardsText(run.Board))
+		}
+	} else if len(g.CommunityCards) > 0 {
+		w.line("Board [%s]", cardsText(g.CommunityCards))
+	}
+
+	roles := map[int]string{w.buttonSeat(): " (button)"}
+	if g.SmallBlindPos >= 0 && g.SmallBlindPos < len(g.Players) {
+		roles[g.Players[g.SmallBlindPos].SeatIndex] += " (small blind)"
+	}
+	if g.BigBlindPos >= 0 && g.BigBlindPos < len(g.Players) {
+		roles[g.Players[g.BigBlindPos].SeatIndex] += " (big blind)"
+	}
+	seats := append([]domain.EventSeat(nil), w.start.Seats...)
+	sort.Slice(seats, func(i, j int) bool { return seats[i].Seat < seats[j].Seat })
+	for _, s := range seats {
+		w.line("Seat %d: %s%s %s", s.Seat+1, s.Username, roles[s.Seat], w.outcome(s.UserID))
+	}
+}
+
+func (w *writer) outcome(userID string) string {
+	p, ok := w.players[userID]
+	if !ok {
+		return "left the table"
+	}
+	if p.Folded {
+		switch w.foldedOn[userID] {
+		case domain.StagePreflop:
+			return "folded before Flop"
+		case domain.StageFlop:
+			return "folded on the Flop"
+		case domain.StageTurn:
+			return "folded on the Turn"
+		case domain.StageRiver:
+			return "folded on the River"
+		}
+		return "folded"
+	}
+	if w.game.Result == nil || w.game.Result.Reason != "showdown" {
+		return fmt.Sprintf("collected (%d)", p.Won)
+	}
+	cards := revealed(p)
+	if len(cards) == 0 {
+		if p.UserID == w.viewer {
+			return fmt.Sprintf("mucked [%s]", cardsText(p.HoleCards))
+		}
+		return "mucked"
+	}
+	if p.Won > 0 {
+		return fmt.Sprintf("showed [%s] and won (%d) with %s", cardsText(cards), p.Won, shownHandText(p))
+	}
+	return fmt.Sprintf("showed [%s] and lost with %s", cardsText(cards), shownHandText(p))
+}
+
+func numberWord(n int) string {
+	switch n {
+	case 2:
+		return "Two"
+	case 3:
+		return "Three"
+	}
+	return fmt.Sprint(n)
+}
+
+// handText turns the engine's hand category names into PokerStars wording.
+func handText(name string) string {
+	switch name {
+	case "high_card":
+		return "high card"
+	case "one_pair":
+		return "a pair"
+	case "two_pair":
+		return "two pair"
+	case "three_of_a_kind":
+		return "three of a kind"
+	case "straight":
+		return "a straight"
+	case "flush":
+		return "a flush"
+	case "full_house":
+		return "a full house"
+	case "four_of_a_kind":
+		return "four of a kind"
+	case "straight_flush":
+		return "a straight flush"
+	}
+	return strings.ReplaceAll(name, "_", " ")
+}
+
+// shownHandText is the hand a player showed down; a hi-lo hand with a low
+// reads "HI: a flush; LO: 8,6,4,2,A".
+func shownHandText(p *domain.GamePlayer) string {
+	if p.BestLowName == "" {
+		return handText(p.BestHandName)
+	}
+	return fmt.Sprintf("HI: %s; LO: %s", handText(p.BestHandName), strings.ReplaceAll(p.BestLowName, "-", ","))
+}
+
+// cardsText writes cards the PokerStars way, such as "Ah Td 2c".
+func cardsText(cards []domain.Card) string {
+	out := make([]string, 0, len(cards))
+	for _, c := range cards {
+		out = append(out, c.String())
+	}
+	return strings.Join(out, " ")
+}
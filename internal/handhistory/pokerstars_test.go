@@ -0,0 +1,271 @@
+package handhistory
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+func card(rank int, suit domain.Suit) domain.Card {
+	return domain.Card{Rank: rank, Suit: suit}
+}
+
+// deckWith returns a full deck that deals top first.
+func deckWith(top ...domain.Card) []domain.Card {
+	deck := append([]domain.Card(nil), top...)
+	used := map[domain.Card]bool{}
+	for _, c := range top {
+		used[c] = true
+	}
+	for _, c := range domain.NewDeck() {
+		if !used[c] {
+			deck = append(deck, c)
+		}
+	}
+	return deck
+}
+
+func playHand(t *testing.T, deck []domain.Card, steps [][3]string) *domain.GameState {
+	t.Helper()
+	players := []*domain.GamePlayer{
+		{UserID: "u1", Username: "Alice", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "Bob", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "Cara", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := domain.NewGameWithDeck(players, 0, 10, 10, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range steps {
+		amount, _ := strconv.Atoi(s[2])
+		if err := g.ApplyAction(s[0], s[1], amount); err != nil {
+			t.Fatalf("%s %s: %v", s[0], s[1], err)
+		}
+	}
+	return g
+}
+
+// expectLines checks that lines appear in text in the given order.
+func expectLines(t *testing.T, text string, lines ...string) {
+	t.Helper()
+	at := 0
+	for _, want := range lines {
+		i := strings.Index(text[at:], want)
+		if i < 0 {
+			t.Fatalf("expected %q after offset %d in:\n%s", want, at, text)
+		}
+		at += i + len(want)
+	}
+}
+
+func TestPokerStars_ShowdownHand(t *testing.T) {
+	deck := deckWith(
+		card(14, domain.Spades), card(14, domain.Hearts), // Alice
+		card(13, domain.Spades), card(12, domain.Hearts), // Bob
+		card(7, domain.Clubs), card(2, domain.Diamonds), // Cara
+		card(2, domain.Clubs), card(5, domain.Diamonds), card(8, domain.Hearts), card(9, domain.Spades), card(13, domain.Clubs),
+	)
+	g := playHand(t, deck, [][3]string{
+		{"u1", "bet", "30"}, {"u2", "call", ""}, {"u3", "fold", ""},
+		{"u2", "check", ""}, {"u1", "bet", "40"}, {"u2", "call", ""},
+		{"u2", "check", ""}, {"u1", "check", ""},
+		{"u2", "bet", "100"}, {"u1", "call", ""},
+	})
+	h := Hand{ID: 7, Table: "Friday", StartedAt: time.Date(2026, 10, 16, 20, 30, 0, 0, time.UTC), ButtonSeat: 0, Game: g}
+	text, err := PokerStars(h, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, text,
+		"PokerStars Hand #7:  Hold'em No Limit (5/10) - 2026/10/16 20:30:00 UTC",
+		"Table 'Friday' 6-max Seat #1 is the button",
+		"Seat 1: Alice (1000 in chips)",
+		"Seat 3: Cara (1000 in chips)",
+		"Bob: posts small blind 5",
+		"Cara: posts big blind 10",
+		"*** HOLE CARDS ***",
+		"Dealt to Bob [Ks Qh]",
+		"Alice: raises 20 to 30",
+		"Bob: calls 25",
+		"Cara: folds",
+		"*** FLOP *** [2c 5d 8h]",
+		"Bob: checks",
+		"Alice: bets 40",
+		"*** TURN *** [2c 5d 8h] [9s]",
+		"*** RIVER *** [2c 5d 8h 9s] [Kc]",
+		"Bob: bets 100",
+		"Alice: calls 100",
+		"*** SHOW DOWN ***",
+		"Bob: shows [Ks Qh] (a pair)",
+		"Alice: shows [As Ah] (a pair)",
+		"Alice collected 350 from pot",
+		"*** SUMMARY ***",
+		"Total pot 350 | Rake 0",
+		"Board [2c 5d 8h 9s Kc]",
+		"Seat 1: Alice (button) showed [As Ah] and won (350) with a pair",
+		"Seat 2: Bob (small blind) showed [Ks Qh] and lost with a pair",
+		"Seat 3: Cara (big blind) folded before Flop",
+	)
+	if strings.Contains(text, "7c") {
+		t.Fatalf("expected Cara's folded cards to stay hidden:\n%s", text)
+	}
+}
+
+func TestPokerStars_ViewerMucksAtShowdown(t *testing.T) {
+	deck := deckWith(
+		card(14, domain.Spades), card(14, domain.Hearts), // Alice
+		card(13, domain.Spades), card(12, domain.Hearts), // Bob
+		card(7, domain.Clubs), card(2, domain.Diamonds), // Cara
+		card(2, domain.Clubs), card(5, domain.Diamonds), card(8, domain.Hearts), card(9, domain.Spades), card(13, domain.Clubs),
+	)
+	// Alice bets the river and shows first, so Bob's beaten pair is mucked.
+	g := playHand(t, deck, [][3]string{
+		{"u1", "bet", "30"}, {"u2", "call", ""}, {"u3", "fold", ""},
+		{"u2", "check", ""}, {"u1", "check", ""},
+		{"u2", "check", ""}, {"u1", "check", ""},
+		{"u2", "check", ""}, {"u1", "bet", "100"}, {"u2", "call", ""},
+	})
+	text, err := PokerStars(Hand{ID: 8, Table: "t", ButtonSeat: 0, Game: g}, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, text,
+		"*** SHOW DOWN ***",
+		"Alice: shows [As Ah] (a pair)",
+		"Bob: mucks hand",
+		"*** SUMMARY ***",
+		"Seat 1: Alice (button) showed [As Ah] and won (270) with a pair",
+		"Seat 2: Bob (small blind) mucked [Ks Qh]",
+	)
+	if strings.Contains(text, "showed [Ks Qh]") {
+		t.Fatalf("expected Bob's mucked hand not reported as shown:\n%s", text)
+	}
+}
+
+func TestPokerStars_HidesCardsAndReturnsUncalledBet(t *testing.T) {
+	g := playHand(t, domain.NewDeck(), [][3]string{
+		{"u1", "bet", "30"}, {"u2", "fold", ""}, {"u3", "fold", ""},
+	})
+	h := Hand{ID: 1, Table: "t", ButtonSeat: 0, Game: g}
+	text, err := PokerStars(h, "u3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, text,
+		"Dealt to Cara ["+cardsText(g.Players[2].HoleCards)+"]",
+		"Uncalled bet (20) returned to Alice",
+		"Alice collected 25 from pot",
+		"Alice: doesn't show hand",
+		"Seat 1: Alice (button) collected (25)",
+		"Seat 2: Bob (small blind) folded before Flop",
+	)
+	if strings.Contains(text, cardsText(g.Players[0].HoleCards)) || strings.Contains(text, "*** FLOP ***") {
+		t.Fatalf("expected no board and Alice's cards hidden:\n%s", text)
+	}
+
+	if err := g.SetRevealSelection("u1", 1); err != nil {
+		t.Fatal(err)
+	}
+	text, _ = PokerStars(h, "u3")
+	expectLines(t, text, "Alice: shows ["+g.Players[0].HoleCards[0].String()+"]")
+
+	all, err := PokerStarsAll([]Hand{h, {ID: 2, Table: "t", Game: g}}, "u3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, all, "PokerStars Hand #1:", "\n\n\nPokerStars Hand #2:")
+}
+
+func TestPokerStars_DoubleBoardBombPot(t *testing.T) {
+	deck := deckWith(
+		card(14, domain.Spades), card(14, domain.Hearts), // Alice
+		card(13, domain.Spades), card(13, domain.Hearts), // Bob
+		card(7, domain.Clubs), card(2, domain.Diamonds), // Cara
+		card(2, domain.Clubs), card(5, domain.Diamonds), card(8, domain.Hearts),
+		card(13, domain.Clubs), card(4, domain.Diamonds), card(6, domain.Hearts),
+		card(9, domain.Spades), card(10, domain.Spades),
+		card(11, domain.Clubs), card(3, domain.Spades),
+	)
+	players := []*domain.GamePlayer{
+		{UserID: "u1", Username: "Alice", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "Bob", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "Cara", SeatIndex: 2, Stack: 1000},
+	}
+	g, err := domain.NewGameWithDeck(players, 0, 10, 10, deck, domain.GameOptions{BombPot: 20, DoubleBoard: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g.Stage != domain.StageFinished {
+		if err := g.ApplyAction(g.Players[g.TurnPos].UserID, "check", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	text, err := PokerStars(Hand{ID: 3, Table: "home", ButtonSeat: 0, Game: g}, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, text,
+		"Alice: posts the ante 20",
+		"Cara: posts the ante 20",
+		"*** HOLE CARDS ***",
+		"*** FIRST FLOP *** [2c 5d 8h]",
+		"*** SECOND FLOP *** [Kc 4d 6h]",
+		"Bob: checks",
+		"*** FIRST TURN *** [2c 5d 8h] [9s]",
+		"*** SECOND TURN *** [Kc 4d 6h] [Ts]",
+		"*** FIRST RIVER *** [2c 5d 8h 9s] [Jc]",
+		"*** SECOND RIVER *** [Kc 4d 6h Ts] [3s]",
+		"Alice collected 30 from pot",
+		"Bob collected 30 from pot",
+		"Total pot 60 | Rake 0",
+		"FIRST Board [2c 5d 8h 9s Jc]",
+		"SECOND Board [Kc 4d 6h Ts 3s]",
+	)
+}
+
+func TestPokerStars_HiLoSplitPot(t *testing.T) {
+	deck := deckWith(
+		card(14, domain.Spades), card(2, domain.Spades), card(11, domain.Diamonds), card(12, domain.Diamonds), // Alice
+		card(13, domain.Hearts), card(13, domain.Spades), card(7, domain.Clubs), card(7, domain.Diamonds), // Bob
+		card(3, domain.Clubs), card(4, domain.Diamonds), card(5, domain.Hearts), card(13, domain.Clubs), card(13, domain.Diamonds),
+	)
+	players := []*domain.GamePlayer{
+		{UserID: "u1", Username: "Alice", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "Bob", SeatIndex: 1, Stack: 1000},
+	}
+	g, err := domain.NewGameWithDeck(players, 0, 10, 10, deck, domain.GameOptions{Variant: domain.VariantPLO8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for g.Stage != domain.StageFinished {
+		u := g.Players[g.TurnPos].UserID
+		if err := g.ApplyAction(u, "call", 0); err != nil {
+			if err := g.ApplyAction(u, "check", 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	text, err := PokerStars(Hand{ID: 4, Table: "home", ButtonSeat: 0, Game: g}, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, text,
+		"Omaha Hi/Lo Pot Limit",
+		"*** SHOW DOWN ***",
+		"Bob: shows [Kh Ks 7c 7d] (four of a kind)",
+		"Alice: shows [As 2s Jd Qd] (HI: a straight; LO: 5,4,3,2,A)",
+		"Bob collected 10 from pot",
+		"Alice collected 10 from pot",
+		"showed [As 2s Jd Qd] and won (10) with HI: a straight; LO: 5,4,3,2,A",
+	)
+}
+
+func TestPokerStars_RejectsUnfinishedHand(t *testing.T) {
+	g := playHand(t, domain.NewDeck(), nil)
+	if _, err := PokerStars(Hand{ID: 1, Game: g}, "u1"); err == nil {
+		t.Fatalf("expected an unfinished hand to be refused")
+	}
+}
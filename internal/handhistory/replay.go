@@ -0,0 +1,74 @@
+package handhistory
+
+import (
+	"errors"
+
+	"texas_yu/internal/domain"
+)
+
+// Frame is the table right after one action of a replayed hand: Turn is the
+// player who acted, Pot and Stacks include the chips they put in, and Board
+// is what had been dealt at the time.
+type Frame struct {
+	Seq    int              `json:"seq"`
+	Stage  domain.GameStage `json:"stage"`
+	Turn   string           `json:"turn"`
+	Action string           `json:"action"`
+	Amount int              `json:"amount"`
+	Pot    int              `json:"pot"`
+	Stacks map[string]int   `json:"stacks"`
+	Board  []domain.Card    `json:"board"`
+}
+
+// Replay steps through a finished hand's event stream and returns one frame
+// per forced bet and action. Hole cards are left to the caller, who knows
+// who is watching.
+func Replay(g *domain.GameState) ([]Frame, error) {
+	if g == nil || g.Stage != domain.StageFinished {
+		return nil, errors.New("hand not finished")
+	}
+	if len(g.Events) == 0 || g.Events[0].Table == nil {
+		return nil, errors.New("hand start not recorded")
+	}
+	stacks := map[string]int{}
+	for _, s := range g.Events[0].Table.Seats {
+		stacks[s.UserID] = s.Stack
+	}
+	pot := 0
+	board := []domain.Card{}
+	frames := make([]Frame, 0, len(g.ActionLogs))
+	for _, ev := range g.Events {
+		switch ev.Type {
+		case domain.EventBlind, domain.EventAction:
+			// A runout vote names a number of boards, not chips.
+			if ev.Action != domain.ActionRunIt {
+				stacks[ev.UserID] -= ev.Amount
+				pot += ev.Amount
+			}
+			frame := Frame{
+				Seq:    len(frames) + 1,
+				Stage:  ev.Stage,
+				Turn:   ev.UserID,
+				Action: ev.Action,
+				Amount: ev.Amount,
+				Pot:    pot,
+				Stacks: make(map[string]int, len(stacks)),
+				Board:  board,
+			}
+			for uid, stack := range stacks {
+				frame.Stacks[uid] = stack
+			}
+			frames = append(frames, frame)
+		case domain.EventStreet:
+			if ev.Run <= 1 {
+				board = ev.Board
+			}
+		case domain.EventRefund:
+			stacks[ev.UserID] += ev.Amount
+			pot -= ev.Amount
+		case domain.EventRake:
+			pot -= ev.Amount
+		}
+	}
+	return frames, nil
+}
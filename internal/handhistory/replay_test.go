@@ -0,0 +1,53 @@
+package handhistory
+
+import (
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+func TestReplay_FramePerAction(t *testing.T) {
+	deck := deckWith(
+		card(14, domain.Spades), card(14, domain.Hearts), // Alice
+		card(13, domain.Spades), card(12, domain.Hearts), // Bob
+		card(7, domain.Clubs), card(2, domain.Diamonds), // Cara
+		card(2, domain.Clubs), card(5, domain.Diamonds), card(8, domain.Hearts), card(9, domain.Spades), card(13, domain.Clubs),
+	)
+	g := playHand(t, deck, [][3]string{
+		{"u1", "bet", "30"}, {"u2", "call", ""}, {"u3", "fold", ""},
+		{"u2", "bet", "50"}, {"u1", "bet", "150"}, {"u2", "fold", ""},
+	})
+	frames, err := Replay(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two blinds, then six decisions.
+	if len(frames) != 8 {
+		t.Fatalf("expected 8 frames, got %d: %+v", len(frames), frames)
+	}
+	first := frames[0]
+	if first.Action != "small_blind" || first.Turn != "u2" || first.Pot != 5 || first.Stacks["u2"] != 995 || len(first.Board) != 0 {
+		t.Fatalf("unexpected first frame %+v", first)
+	}
+	flopBet := frames[5]
+	if flopBet.Turn != "u2" || flopBet.Action != "bet" || flopBet.Stage != domain.StageFlop || len(flopBet.Board) != 3 {
+		t.Fatalf("expected Bob's flop bet with the flop out, got %+v", flopBet)
+	}
+	if flopBet.Pot != 120 || flopBet.Stacks["u2"] != 920 || flopBet.Stacks["u3"] != 990 {
+		t.Fatalf("unexpected pot and stacks after the flop bet: %+v", flopBet)
+	}
+	last := frames[len(frames)-1]
+	if last.Action != "fold" || last.Turn != "u2" || last.Stacks["u1"] != 820 {
+		t.Fatalf("unexpected last frame %+v", last)
+	}
+	for i, f := range frames {
+		if f.Seq != i+1 {
+			t.Fatalf("frame %d numbered %d", i, f.Seq)
+		}
+	}
+
+	live := playHand(t, deck, nil)
+	if _, err := Replay(live); err == nil {
+		t.Fatalf("expected a running hand to be refused")
+	}
+}
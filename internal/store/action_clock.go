@@ -0,0 +1,146 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+const actionClockTick = 500 * time.Millisecond
+
+// ActionClock is the running decision timer of the human who has to act.
+// Once DeadlineMs passes the player's time bank starts to burn; at
+// BankDeadlineMs the server acts for them.
+type ActionClock struct {
+	UserID         string `json:"userId"`
+	StartedAtMs    int64  `json:"startedAtMs"`
+	DeadlineMs     int64  `json:"deadlineMs"`
+	BankDeadlineMs int64  `json:"bankDeadlineMs"`
+	turnKey        string
+}
+
+// Now returns the store clock, which tests may replace through Options.Now.
+func (m *MemoryStore) Now() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+func (m *MemoryStore) actionClockLoop() {
+	ticker := time.NewTicker(actionClockTick)
+	for range ticker.C {
+		m.expireActionClocks()
+		m.dealTournamentHands()
+	}
+}
+
+// A timed-out player checks when it is free and folds otherwise.
+func (m *MemoryStore) expireActionClocks() {
+	type timeout struct {
+		roomID  string
+		userID  string
+		action  string
+		amount  int
+		version int64
+	}
+	nowMs := m.Now().UnixMilli()
+	m.mu.Lock()
+	var due []timeout
+	for _, r := range m.rooms {
+		c := r.ActionClock
+		if c == nil || nowMs < c.BankDeadlineMs || r.Game == nil {
+			continue
+		}
+		action, amount := timeoutAction(r.Game, c.UserID)
+		due = append(due, timeout{roomID: r.RoomID, userID: c.UserID, action: action, amount: amount, version: r.StateVersion})
+	}
+	m.mu.Unlock()
+
+	for _, t := range due {
+		actionID := fmt.Sprintf("timeout-%s-%d", t.userID, t.version)
+		_, _ = m.applyAction(t.roomID, t.userID, actionID, t.action, t.amount, t.version, true)
+	}
+}
+
+func timeoutAction(g *domain.GameState, userID string) (string, int) {
+	if g.RunoutPending {
+		return domain.ActionRunIt, 1
+	}
+	for _, p := range g.Players {
+		if p.UserID == userID && p.RoundContrib == g.RoundBet {
+			return "check", 0
+		}
+	}
+	return "fold", 0
+}
+
+func clockedUserLocked(r *Room) string {
+	if r.ActionSeconds <= 0 || r.Status != RoomPlaying || r.Game == nil {
+		return ""
+	}
+	g := r.Game
+	if g.Stage == domain.StageFinished || g.Stage == domain.StageShowdown {
+		return ""
+	}
+	if g.RunoutPending {
+		for _, p := range g.Players {
+			if p.Folded || p.IsAI || p.AIManaged {
+				continue
+			}
+			if _, voted := g.RunoutVotes[p.UserID]; !voted {
+				return p.UserID
+			}
+		}
+		return ""
+	}
+	if g.TurnPos < 0 || g.TurnPos >= len(g.Players) {
+		return ""
+	}
+	turn := g.Players[g.TurnPos]
+	if turn.IsAI || turn.AIManaged || turn.Folded || turn.AllIn {
+		return ""
+	}
+	return turn.UserID
+}
+
+func (m *MemoryStore) syncActionClockLocked(r *Room) {
+	userID := clockedUserLocked(r)
+	key := ""
+	if userID != "" {
+		key = fmt.Sprintf("%d:%s:%d", r.HandCounter, userID, len(r.Game.ActionLogs))
+	}
+	prev := r.ActionClock
+	if prev != nil && prev.turnKey == key {
+		return
+	}
+	now := m.Now().UnixMilli()
+	if prev != nil && now > prev.DeadlineMs {
+		bank := r.TimeBanks[prev.UserID] - (now - prev.DeadlineMs)
+		if bank < 0 {
+			bank = 0
+		}
+		r.TimeBanks[prev.UserID] = bank
+	}
+	r.ActionClock = nil
+	if userID == "" {
+		return
+	}
+	if r.TimeBanks == nil {
+		r.TimeBanks = map[string]int64{}
+	}
+	bank, ok := r.TimeBanks[userID]
+	if !ok {
+		bank = int64(r.TimeBankSeconds) * 1000
+		r.TimeBanks[userID] = bank
+	}
+	deadline := now + int64(r.ActionSeconds)*1000
+	r.ActionClock = &ActionClock{
+		UserID:         userID,
+		StartedAtMs:    now,
+		DeadlineMs:     deadline,
+		BankDeadlineMs: deadline + bank,
+		turnKey:        key,
+	}
+}
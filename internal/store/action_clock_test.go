@@ -0,0 +1,70 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+func TestStore_ActionClockFoldsAfterDeadlineAndBank(t *testing.T) {
+	s, clock, _, started := startRoom(t, RoomRules{ActionSeconds: 10, TimeBankSeconds: 5}, 2)
+	turn := started.Game.Players[started.Game.TurnPos].UserID
+	c := started.ActionClock
+	if c == nil || c.UserID != turn {
+		t.Fatalf("expected clock on %s, got %+v", turn, c)
+	}
+	if c.DeadlineMs-c.StartedAtMs != 10_000 || c.BankDeadlineMs-c.DeadlineMs != 5_000 {
+		t.Fatalf("expected 10s clock plus 5s bank, got %+v", c)
+	}
+
+	clock.Advance(12 * time.Second)
+	s.expireActionClocks()
+	r, _ := s.GetRoom(started.RoomID)
+	if r.StateVersion != started.StateVersion {
+		t.Fatalf("expected time bank to keep the player alive")
+	}
+
+	clock.Advance(4 * time.Second)
+	s.expireActionClocks()
+	r, _ = s.GetRoom(started.RoomID)
+	if r.Game.Stage != domain.StageFinished {
+		t.Fatalf("expected facing a bet to fold on timeout, stage=%s", r.Game.Stage)
+	}
+	for _, p := range r.Game.Players {
+		if p.UserID == turn && p.LastAction != "fold" {
+			t.Fatalf("expected %s to be folded, got %s", turn, p.LastAction)
+		}
+	}
+	if r.ActionClock != nil || r.TimeBanks[turn] != 0 {
+		t.Fatalf("expected clock stopped and bank used up, clock=%+v bank=%d", r.ActionClock, r.TimeBanks[turn])
+	}
+}
+
+func TestStore_ActionClockChecksWhenFreeAndChargesBank(t *testing.T) {
+	s, clock, _, started := startRoom(t, RoomRules{ActionSeconds: 10, TimeBankSeconds: 30}, 2)
+	button := started.Game.Players[started.Game.TurnPos].UserID
+
+	clock.Advance(13 * time.Second)
+	called, err := s.ApplyAction(started.RoomID, button, "", "call", 0, started.StateVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := called.TimeBanks[button]; got != 27_000 {
+		t.Fatalf("expected 3s charged to the bank, got %dms", got)
+	}
+	bigBlind := called.ActionClock.UserID
+	if bigBlind == button {
+		t.Fatalf("expected the clock to move to the big blind")
+	}
+
+	clock.Advance(41 * time.Second)
+	s.expireActionClocks()
+	r, _ := s.GetRoom(started.RoomID)
+	if r.Game.Stage != domain.StageFlop {
+		t.Fatalf("expected the big blind to check on timeout and deal the flop, stage=%s", r.Game.Stage)
+	}
+	if r.ActionClock == nil || r.ActionClock.UserID != bigBlind || r.ActionClock.BankDeadlineMs != r.ActionClock.DeadlineMs {
+		t.Fatalf("expected a fresh flop clock with no bank left, got %+v", r.ActionClock)
+	}
+}
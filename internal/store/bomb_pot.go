@@ -0,0 +1,101 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+// DefaultBombPotBigBlinds is the bomb pot ante, in big blinds, of a room that
+// does not set one.
+const DefaultBombPotBigBlinds = 2
+
+// BombPot is a hand where everyone antes Ante and play starts on the flop,
+// on two boards when DoubleBoard is set.
+type BombPot struct {
+	Ante        int  `json:"ante"`
+	DoubleBoard bool `json:"doubleBoard"`
+}
+
+func bombPotLocked(r *Room) *BombPot {
+	if r.TournamentRules != nil {
+		return nil
+	}
+	if r.NextBombPot != nil {
+		return r.NextBombPot
+	}
+	if r.BombPotEvery > 0 && (r.HandCounter+1)%int64(r.BombPotEvery) == 0 {
+		bp := r.BombPot
+		return &bp
+	}
+	return nil
+}
+
+// ScheduleBombPot makes the room's next hand a bomb pot. An ante of 0 uses
+// the room's bomb pot ante.
+func (m *MemoryStore) ScheduleBombPot(roomID, userID string, bp BombPot) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := m.bombPotRoomLocked(roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if bp.Ante < 0 {
+		return nil, errors.New("bomb pot ante must not be negative")
+	}
+	if bp.Ante == 0 {
+		bp.Ante = r.BombPot.Ante
+	}
+	r.NextBombPot = &bp
+	r.StateVersion++
+	r.UpdatedAtUnix = time.Now().Unix()
+	m.roomsVersion++
+	return r, nil
+}
+
+// CancelBombPot takes back a bomb pot the owner called for that has not been
+// dealt yet.
+func (m *MemoryStore) CancelBombPot(roomID, userID string) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := m.bombPotRoomLocked(roomID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if r.NextBombPot == nil {
+		return nil, errors.New("no bomb pot scheduled")
+	}
+	r.NextBombPot = nil
+	r.StateVersion++
+	r.UpdatedAtUnix = time.Now().Unix()
+	m.roomsVersion++
+	return r, nil
+}
+
+func (m *MemoryStore) bombPotRoomLocked(roomID, userID string) (*Room, error) {
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	if isSpectator(r, userID) {
+		return nil, errors.New("spectator is read-only")
+	}
+	if !isPlayer(r, userID) {
+		return nil, errors.New("user not in room")
+	}
+	if r.OwnerUserID != userID {
+		return nil, errors.New("only owner can call a bomb pot")
+	}
+	if r.TournamentRules != nil {
+		return nil, errors.New("bomb pots are not available in tournaments")
+	}
+	return r, nil
+}
+
+// Blinds owed are not posted in a bomb pot; they stay owed.
+func applyBombPot(opts *domain.GameOptions, bp *BombPot) {
+	opts.BombPot = bp.Ante
+	opts.DoubleBoard = bp.DoubleBoard
+	opts.Posts = nil
+}
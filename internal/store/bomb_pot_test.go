@@ -0,0 +1,75 @@
+package store
+
+import (
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+func TestStore_BombPotEverySoManyHands(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "home", 10, 10, RoomRules{BombPotEvery: 2})
+	for _, name := range []string{"guest", "third"} {
+		if _, err := s.JoinRoom(room.RoomID, s.CreateSession(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Game.BombPot != 0 || r.Game.Stage != domain.StagePreflop {
+		t.Fatalf("expected the first hand dealt as usual")
+	}
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if r.Game.BombPot != 20 || r.Game.Stage != domain.StageFlop || r.Game.Pot != 60 {
+		t.Fatalf("expected the second hand a bomb pot of two big blinds each, got %d in %s", r.Game.Pot, r.Game.Stage)
+	}
+}
+
+func TestStore_OwnerCallsADoubleBoardBombPot(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	room := s.CreateRoom(owner, "home", 10, 10, RoomRules{BombPot: BombPot{Ante: 30}})
+	if _, err := s.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ScheduleBombPot(room.RoomID, guest.UserID, BombPot{}); err == nil {
+		t.Fatalf("expected only the owner to call a bomb pot")
+	}
+	if _, err := s.ScheduleBombPot(room.RoomID, owner.UserID, BombPot{DoubleBoard: true}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NextBombPot != nil || r.Game.BombPot != 30 || !r.Game.DoubleBoard || len(r.Game.SecondBoard) != 3 {
+		t.Fatalf("expected a double-board bomb pot at the room's ante, got %+v", r.Game)
+	}
+	for r.Game.Stage != domain.StageFinished {
+		r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+	}
+	if len(r.Game.Runouts) != 2 || r.Players[0].Stack+r.Players[1].Stack != 2*DefaultPlayerStack {
+		t.Fatalf("expected the pot split over both boards, got %+v", r.Game.Runouts)
+	}
+	if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if r.Game.BombPot != 0 {
+		t.Fatalf("expected a called bomb pot dealt once")
+	}
+
+	if _, err := s.CancelBombPot(r.RoomID, owner.UserID); err == nil {
+		t.Fatalf("expected nothing to cancel")
+	}
+	tourney := s.CreateRoom(owner, "stt", 10, 10, RoomRules{TournamentRules: &TournamentRules{StartingStack: 100, Levels: []BlindLevel{{BigBlind: 10}}, LevelHands: 5}})
+	if _, err := s.ScheduleBombPot(tourney.RoomID, owner.UserID, BombPot{}); err == nil {
+		t.Fatalf("expected no bomb pots in tournaments")
+	}
+}
@@ -0,0 +1,297 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+// DefaultMinBuyInBigBlinds is the minimum buy-in, in big blinds, of a room
+// that does not set one.
+const DefaultMinBuyInBigBlinds = 20
+
+// ChipLedgerKind is what moved chips onto or off a seat.
+type ChipLedgerKind string
+
+const (
+	ChipLedgerBuyIn   ChipLedgerKind = "buy_in"
+	ChipLedgerTopUp   ChipLedgerKind = "top_up"
+	ChipLedgerRebuy   ChipLedgerKind = "rebuy"
+	ChipLedgerHand    ChipLedgerKind = "hand"
+	ChipLedgerRefresh ChipLedgerKind = "refresh"
+	ChipLedgerCashOut ChipLedgerKind = "cash_out"
+)
+
+// ChipLedgerEntry is one chip movement at a cash table. Amount is signed
+// from the player's point of view; a cash-out is negative. StackAfter is the
+// seat's stack once it is applied.
+type ChipLedgerEntry struct {
+	Seq        int64          `json:"seq"`
+	AtUnix     int64          `json:"atUnix"`
+	UserID     string         `json:"userId"`
+	Username   string         `json:"username"`
+	Kind       ChipLedgerKind `json:"kind"`
+	Amount     int            `json:"amount"`
+	StackAfter int            `json:"stackAfter"`
+	HandID     int64          `json:"handId,omitempty"`
+	// Rake is the player's share of a hand's rake, already taken out of
+	// Amount.
+	Rake int `json:"rake,omitempty"`
+}
+
+// ChipTotals sums a player's ledger for the session. Between hands
+// BoughtIn + HandsNet + Refreshed - CashedOut equals Stack, the chips on
+// their seat, or 0 once they have left.
+type ChipTotals struct {
+	UserID    string `json:"userId"`
+	Username  string `json:"username"`
+	BoughtIn  int    `json:"boughtIn"`
+	HandsNet  int    `json:"handsNet"`
+	Refreshed int    `json:"refreshed"`
+	CashedOut int    `json:"cashedOut"`
+	Stack     int    `json:"stack"`
+	Seated    bool   `json:"seated"`
+	// Rake is what the player contributed to the room's rake, the basis for
+	// rakeback.
+	Rake int `json:"rake"`
+}
+
+func buyInDefaults(rr *RoomRules, bigBlind int) {
+	if rr.MaxBuyIn <= 0 {
+		rr.MaxBuyIn = maxInt(DefaultPlayerStack, rr.MinBuyIn)
+	}
+	if rr.MinBuyIn <= 0 {
+		rr.MinBuyIn = min(bigBlind*DefaultMinBuyInBigBlinds, rr.MaxBuyIn)
+	}
+}
+
+func seatBuyIn(r *Room, amount int) (int, error) {
+	if r.TournamentRules != nil {
+		return DefaultPlayerStack, nil
+	}
+	if amount == 0 {
+		return r.MaxBuyIn, nil
+	}
+	if amount < r.MinBuyIn || amount > r.MaxBuyIn {
+		return 0, fmt.Errorf("buy-in must be between %d and %d", r.MinBuyIn, r.MaxBuyIn)
+	}
+	return amount, nil
+}
+
+func recordChipsLocked(r *Room, p RoomPlayer, kind ChipLedgerKind, amount int, handID int64) {
+	if r.TournamentRules != nil || (amount == 0 && kind != ChipLedgerCashOut) {
+		return
+	}
+	appendLedgerLocked(r, ChipLedgerEntry{
+		UserID:     p.UserID,
+		Username:   p.Username,
+		Kind:       kind,
+		Amount:     amount,
+		StackAfter: p.Stack,
+		HandID:     handID,
+	})
+}
+
+func appendLedgerLocked(r *Room, e ChipLedgerEntry) {
+	r.ledgerSeq++
+	e.Seq = r.ledgerSeq
+	e.AtUnix = time.Now().Unix()
+	r.ChipLedger = append(r.ChipLedger, e)
+}
+
+func handStartStacks(g *domain.GameState) map[string]int {
+	started := map[string]int{}
+	if len(g.Events) > 0 && g.Events[0].Table != nil {
+		for _, seat := range g.Events[0].Table.Seats {
+			started[seat.UserID] = seat.Stack
+		}
+	}
+	return started
+}
+
+func recordHandChipsLocked(r *Room) {
+	if r.Game == nil || r.Game.Stage != domain.StageFinished || r.ledgerHand == r.HandCounter {
+		return
+	}
+	r.ledgerHand = r.HandCounter
+	if r.TournamentRules != nil {
+		return
+	}
+	if r.Game.Result != nil {
+		r.RakeTotal += r.Game.Result.Rake
+	}
+	started := handStartStacks(r.Game)
+	for _, gp := range r.Game.Players {
+		start, ok := started[gp.UserID]
+		if !ok || (gp.Stack == start && gp.Rake == 0) {
+			continue
+		}
+		appendLedgerLocked(r, ChipLedgerEntry{
+			UserID:     gp.UserID,
+			Username:   gp.Username,
+			Kind:       ChipLedgerHand,
+			Amount:     gp.Stack - start,
+			StackAfter: gp.Stack,
+			HandID:     r.HandCounter,
+			Rake:       gp.Rake,
+		})
+	}
+	// A player who left mid-hand was settled at cash-out; only their share of
+	// the rake is left to record.
+	for _, gp := range r.Game.Departed {
+		if gp.Rake == 0 {
+			continue
+		}
+		appendLedgerLocked(r, ChipLedgerEntry{
+			UserID:   gp.UserID,
+			Username: gp.Username,
+			Kind:     ChipLedgerHand,
+			HandID:   r.HandCounter,
+			Rake:     gp.Rake,
+		})
+	}
+}
+
+func recordCashOutLocked(r *Room, idx int) {
+	p := r.Players[idx]
+	if r.Game != nil && r.ledgerHand != r.HandCounter {
+		for _, gp := range r.Game.Players {
+			if gp.UserID != p.UserID {
+				continue
+			}
+			if start, ok := handStartStacks(r.Game)[gp.UserID]; ok {
+				p.Stack = gp.Stack
+				recordChipsLocked(r, p, ChipLedgerHand, gp.Stack-start, r.HandCounter)
+			}
+			break
+		}
+	}
+	stack := p.Stack
+	p.Stack = 0
+	recordChipsLocked(r, p, ChipLedgerCashOut, -stack, 0)
+}
+
+// TopUp adds amount to the player's stack between hands, up to the room's
+// maximum buy-in.
+func (m *MemoryStore) TopUp(roomID, userID string, amount int) (*Room, error) {
+	return m.addChips(roomID, userID, amount, ChipLedgerTopUp)
+}
+
+// Rebuy puts a busted player back in with a new buy-in.
+func (m *MemoryStore) Rebuy(roomID, userID string, amount int) (*Room, error) {
+	return m.addChips(roomID, userID, amount, ChipLedgerRebuy)
+}
+
+func (m *MemoryStore) addChips(roomID, userID string, amount int, kind ChipLedgerKind) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	if isSpectator(r, userID) {
+		return nil, errors.New("spectator is read-only")
+	}
+	idx := playerIndex(r, userID)
+	if idx < 0 {
+		return nil, errors.New("user not in room")
+	}
+	if r.TournamentRules != nil {
+		return nil, errors.New("buying chips is not available in tournaments")
+	}
+	if inLiveHand(r, userID) {
+		return nil, errors.New("chips can only be added between hands")
+	}
+	p := &r.Players[idx]
+	switch kind {
+	case ChipLedgerTopUp:
+		if p.Stack <= 0 {
+			return nil, errors.New("busted players rebuy instead")
+		}
+		if amount <= 0 || p.Stack+amount > r.MaxBuyIn {
+			return nil, fmt.Errorf("top-up must be positive and leave at most %d chips", r.MaxBuyIn)
+		}
+	case ChipLedgerRebuy:
+		if p.Stack > 0 {
+			return nil, errors.New("only busted players can rebuy")
+		}
+		stack, err := seatBuyIn(r, amount)
+		if err != nil {
+			return nil, err
+		}
+		amount = stack
+	}
+	p.Stack += amount
+	// The finished hand's stacks carry over to the next deal, so they take
+	// the new chips too.
+	if r.Game != nil {
+		for _, gp := range r.Game.Players {
+			if gp.UserID == userID {
+				gp.Stack = p.Stack
+			}
+		}
+	}
+	recordChipsLocked(r, *p, kind, amount, 0)
+	r.StateVersion++
+	r.UpdatedAtUnix = time.Now().Unix()
+	m.roomsVersion++
+	return r, nil
+}
+
+func inLiveHand(r *Room, userID string) bool {
+	if r.Game == nil || r.Game.Stage == domain.StageFinished {
+		return false
+	}
+	for _, gp := range r.Game.Players {
+		if gp.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ChipLedger returns the room's chip movements, oldest first, and every
+// player's totals in the order they first bought in.
+func (m *MemoryStore) ChipLedger(roomID, userID string) ([]ChipLedgerEntry, []ChipTotals, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, nil, errors.New("room not found")
+	}
+	if !isMember(r, userID) {
+		return nil, nil, errors.New("user not in room")
+	}
+	entries := append([]ChipLedgerEntry{}, r.ChipLedger...)
+	totals := []ChipTotals{}
+	index := map[string]int{}
+	for _, e := range entries {
+		i, ok := index[e.UserID]
+		if !ok {
+			i = len(totals)
+			index[e.UserID] = i
+			totals = append(totals, ChipTotals{UserID: e.UserID, Username: e.Username})
+		}
+		t := &totals[i]
+		switch e.Kind {
+		case ChipLedgerBuyIn, ChipLedgerTopUp, ChipLedgerRebuy:
+			t.BoughtIn += e.Amount
+		case ChipLedgerHand:
+			t.HandsNet += e.Amount
+			t.Rake += e.Rake
+		case ChipLedgerRefresh:
+			t.Refreshed += e.Amount
+		case ChipLedgerCashOut:
+			t.CashedOut -= e.Amount
+		}
+	}
+	for i := range totals {
+		if idx := playerIndex(r, totals[i].UserID); idx >= 0 {
+			totals[i].Stack = r.Players[idx].Stack
+			totals[i].Seated = true
+		}
+	}
+	return entries, totals, nil
+}
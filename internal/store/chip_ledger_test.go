@@ -0,0 +1,256 @@
+package store
+
+import (
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+func callOrCheck(t *testing.T, s *MemoryStore, r *Room, userID string) *Room {
+	t.Helper()
+	next, err := s.ApplyAction(r.RoomID, userID, "", "call", 0, r.StateVersion)
+	if err != nil {
+		if next, err = s.ApplyAction(r.RoomID, userID, "", "check", 0, r.StateVersion); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return next
+}
+
+func TestStore_BuyInLimits(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "cash", 10, 10, RoomRules{MinBuyIn: 400, MaxBuyIn: 2000})
+	if room.Players[0].Stack != 2000 {
+		t.Fatalf("expected the owner to sit with the max buy-in, got %d", room.Players[0].Stack)
+	}
+	for _, amount := range []int{399, 2001, -5} {
+		if _, err := s.JoinRoom(room.RoomID, s.CreateSession("short"), amount); err == nil {
+			t.Fatalf("expected a buy-in of %d refused", amount)
+		}
+	}
+	guest := s.CreateSession("guest")
+	joined, err := s.JoinRoom(room.RoomID, guest, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.Players[1].Stack != 500 {
+		t.Fatalf("expected the chosen buy-in, got %d", joined.Players[1].Stack)
+	}
+
+	defaults := s.CreateRoom(owner, "defaults", 50, 50)
+	if defaults.MaxBuyIn != DefaultPlayerStack || defaults.MinBuyIn != 50*DefaultMinBuyInBigBlinds {
+		t.Fatalf("expected default buy-ins, got %d-%d", defaults.MinBuyIn, defaults.MaxBuyIn)
+	}
+}
+
+func TestStore_TopUpAndRebuyBetweenHands(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	room := s.CreateRoom(owner, "cash", 10, 10, RoomRules{MinBuyIn: 100, MaxBuyIn: 1000})
+	if _, err := s.JoinRoom(room.RoomID, guest, 100); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TopUp(r.RoomID, guest.UserID, 100); err == nil {
+		t.Fatalf("expected no top-up during a hand")
+	}
+
+	// The guest moves all in and the owner calls until someone busts.
+	for {
+		for r.Game.Stage != domain.StageFinished {
+			turn := r.Game.Players[r.Game.TurnPos].UserID
+			if turn == owner.UserID {
+				r = callOrCheck(t, s, r, turn)
+			} else if r, err = s.ApplyAction(r.RoomID, turn, "", "allin", 0, r.StateVersion); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if r.Players[0].Stack == 0 || r.Players[1].Stack == 0 {
+			break
+		}
+		if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	busted, other := guest.UserID, owner.UserID
+	if r.Players[playerIndex(r, owner.UserID)].Stack == 0 {
+		busted, other = owner.UserID, guest.UserID
+	}
+	if _, err := s.TopUp(r.RoomID, busted, 100); err == nil {
+		t.Fatalf("expected a busted player to rebuy rather than top up")
+	}
+	if _, err := s.Rebuy(r.RoomID, other, 100); err == nil {
+		t.Fatalf("expected only busted players to rebuy")
+	}
+	if _, err := s.Rebuy(r.RoomID, busted, 50); err == nil {
+		t.Fatalf("expected a rebuy below the min buy-in refused")
+	}
+	if r, err = s.Rebuy(r.RoomID, busted, 300); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.TopUp(r.RoomID, busted, 701); err == nil {
+		t.Fatalf("expected a top-up past the max buy-in refused")
+	}
+	if r, err = s.TopUp(r.RoomID, busted, 700); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	for _, gp := range r.Game.Players {
+		if gp.UserID == busted && gp.Stack+gp.Contributed != 1000 {
+			t.Fatalf("expected the new chips dealt into the next hand, got %d", gp.Stack+gp.Contributed)
+		}
+	}
+}
+
+func TestStore_ChipLedgerAddsUp(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	third := s.CreateSession("third")
+	room := s.CreateRoom(owner, "cash", 10, 10)
+	for _, sess := range []*Session{guest, third} {
+		if _, err := s.JoinRoom(room.RoomID, sess, 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hand := 0; hand < 3; hand++ {
+		r = foldCurrentHand(t, s, r)
+		if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The third player calls and then walks out in the middle of the hand.
+	for r.Game.Players[r.Game.TurnPos].UserID != third.UserID {
+		r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+	}
+	r = callOrCheck(t, s, r, third.UserID)
+	if _, err := s.LeaveRoom(r.RoomID, third.UserID); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = s.GetRoom(r.RoomID)
+	foldCurrentHand(t, s, r)
+
+	entries, totals, err := s.ChipLedger(r.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 3 || entries[0].Kind != ChipLedgerBuyIn || entries[0].Amount != DefaultPlayerStack {
+		t.Fatalf("expected the owner's buy-in first, got %+v", entries)
+	}
+	hands, bought := 0, 0
+	for _, tot := range totals {
+		if tot.BoughtIn+tot.HandsNet+tot.Refreshed-tot.CashedOut != tot.Stack {
+			t.Fatalf("expected the ledger to add up to the stack, got %+v", tot)
+		}
+		hands += tot.HandsNet
+		bought += tot.BoughtIn
+	}
+	if hands != 0 || bought != DefaultPlayerStack+2000 {
+		t.Fatalf("expected hands to move chips between players only, got net %d of %d bought", hands, bought)
+	}
+	leaver := totals[2]
+	if leaver.UserID != third.UserID || leaver.Seated || leaver.CashedOut != 1000+leaver.HandsNet {
+		t.Fatalf("expected the leaver to lose their call and cash out the rest, got %+v", leaver)
+	}
+}
+
+func TestStore_RakeIsRecordedPerPlayer(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	room := s.CreateRoom(owner, "raked", 10, 10, RoomRules{Rake: domain.RakeRules{Percent: 10, Cap: 3}})
+	if _, err := s.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hand := 0; hand < 2; hand++ {
+		for r.Game.Stage != domain.StageFinished {
+			r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+		}
+		if r.Game.Result.Rake != 2 {
+			t.Fatalf("expected 10%% of the 20 pot raked, got %d", r.Game.Result.Rake)
+		}
+		if hand == 0 {
+			if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if r.RakeTotal != 4 {
+		t.Fatalf("expected the room to total the rake of both hands, got %d", r.RakeTotal)
+	}
+	_, totals, err := s.ChipLedger(r.RoomID, guest.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hands := 0
+	for _, tot := range totals {
+		if tot.Rake != 2 {
+			t.Fatalf("expected each player charged half the rake, got %+v", tot)
+		}
+		hands += tot.HandsNet
+	}
+	if hands != -4 {
+		t.Fatalf("expected the rake to leave the table, got net %d", hands)
+	}
+
+	tourney := s.CreateRoom(owner, "mtt", 10, 10, RoomRules{Rake: domain.RakeRules{Percent: 5}, TournamentRules: &TournamentRules{StartingStack: 100, Levels: []BlindLevel{{BigBlind: 10}}, LevelHands: 5}})
+	if tourney.Rake.Enabled() {
+		t.Fatalf("expected tournaments to take no rake")
+	}
+}
+
+func TestStore_ChipLedgerBalancesAfterMidHandLeave(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "cash", 100, 100, RoomRules{Rake: domain.RakeRules{Percent: 5}})
+	for _, name := range []string{"p1", "p2"} {
+		if _, err := s.JoinRoom(room.RoomID, s.CreateSession(name), 2000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r.Game.Stage == domain.StagePreflop {
+		r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+	}
+	if _, err := s.LeaveRoom(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = s.GetRoom(r.RoomID)
+	for r.Game.Stage != domain.StageFinished {
+		r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+	}
+
+	_, totals, err := s.ChipLedger(r.RoomID, r.OwnerUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hands, rake := 0, 0
+	for _, tot := range totals {
+		hands += tot.HandsNet
+		rake += tot.Rake
+	}
+	if r.RakeTotal == 0 || hands != -r.RakeTotal {
+		t.Fatalf("expected hand results to sum to minus the rake %d, got %d", r.RakeTotal, hands)
+	}
+	if rake != r.RakeTotal {
+		t.Fatalf("expected the rake charged to players to add up to %d, got %d", r.RakeTotal, rake)
+	}
+}
@@ -35,16 +35,17 @@ func buildAIDecisionInput(room *Room, turn *domain.GamePlayer, memory *RoomAIMem
 	for _, c := range turn.HoleCards {
 		holeCards = append(holeCards, cardToText(c))
 	}
-	handCategory, handCategoryRank, handRanks, preflopTier, madeHandStrength, drawFlags := buildHandStrengthFeatures(turn.HoleCards, room.Game.CommunityCards)
+	handCategory, handCategoryRank, handRanks, preflopTier, madeHandStrength, drawFlags := buildHandStrengthFeatures(room.Game.Variant, turn.HoleCards, room.Game.CommunityCards)
 	preflopPosition := preflopPositionForPlayer(room.Game, room.Game.TurnPos)
 	effectiveStackBB := effectiveStackBBForPlayer(room.Game, room.Game.TurnPos)
-	preflopFacingRaise := room.Game.Stage == domain.StagePreflop && room.Game.RoundBet > room.Game.OpenBetMin
+	preflopFacingRaise := room.Game.Stage == domain.StagePreflop && room.Game.RoundBet > room.Game.ForcedBet()
 	input := ai.DecisionInput{
 		RoomID:             room.RoomID,
 		HandID:             room.HandCounter,
 		StateVersion:       room.StateVersion,
 		AIUserID:           turn.UserID,
 		AIUsername:         turn.Username,
+		Variant:            string(room.Game.Variant),
 		Stage:              string(room.Game.Stage),
 		Pot:                room.Game.Pot,
 		RoundBet:           room.Game.RoundBet,
@@ -53,6 +54,8 @@ func buildAIDecisionInput(room *Room, turn *domain.GamePlayer, memory *RoomAIMem
 		CallAmount:         callAmount,
 		MinBet:             minBet,
 		MinRaise:           minRaise,
+		MaxRaise:           room.Game.MaxRaiseFor(turn),
+		BettingStructure:   room.Game.BettingStructure(),
 		Stack:              turn.Stack,
 		AllowedActions:     allowed,
 		CommunityCards:     community,
@@ -101,7 +104,7 @@ func buildDecisionDiagnostics(input ai.DecisionInput) ai.DecisionDiagnostics {
 	heroCategoryRank := input.HandCategoryRank
 	if cardsOK {
 		if len(heroHole)+len(heroBoard) >= 5 {
-			best, _, _ := domain.BestOfSeven(append(append([]domain.Card{}, heroBoard...), heroHole...))
+			best, _, _ := domain.BestHand(domain.Variant(input.Variant), heroHole, heroBoard)
 			heroCategoryRank = best.Category
 		}
 		diag.PairStrengthScore = pairStrengthScore(heroHole, heroBoard)
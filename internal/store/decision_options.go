@@ -59,7 +59,7 @@ func buildDecisionOptionContext(input ai.DecisionInput) decisionOptionContext {
 	ctx.heroCategoryRank = input.HandCategoryRank
 	if cardsOK {
 		if len(heroHole)+len(heroBoard) >= 5 {
-			best, _, _ := domain.BestOfSeven(append(append([]domain.Card{}, heroBoard...), heroHole...))
+			best, _, _ := domain.BestHand(domain.Variant(input.Variant), heroHole, heroBoard)
 			ctx.heroCategoryRank = best.Category
 		}
 		ctx.pairScore = pairStrengthScore(heroHole, heroBoard)
@@ -232,7 +232,11 @@ func buildDecisionOptions(input ai.DecisionInput, params StrategyParams, baselin
 				continue
 			}
 			amount := chooseFallbackBetAmount(input, betMin, mode, deterministicRoll(input, "option-"+mode), ctx.wetness, ctx.pressure, ctxForBet, params)
-			mergeOption("bet_"+mode, mode, ai.Decision{Action: "bet", Amount: clampInt(amount, betMin, input.Stack)}, false)
+			if input.BettingStructure == "fixed_limit" {
+				// Limit bets have one legal size; every mode bets it.
+				amount = betMin
+			}
+			mergeOption("bet_"+mode, mode, ai.Decision{Action: "bet", Amount: clampInt(amount, betMin, maxBetCommit(input))}, false)
 		}
 	}
 
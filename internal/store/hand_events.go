@@ -0,0 +1,39 @@
+package store
+
+import (
+	"errors"
+
+	"texas_yu/internal/domain"
+)
+
+func syncHandEventsLocked(r *Room) {
+	if r.Game == nil || r.eventsSynced >= len(r.Game.Events) {
+		return
+	}
+	for _, ev := range r.Game.Events[r.eventsSynced:] {
+		ev.HandID = r.HandCounter
+		r.Events = append(r.Events, ev)
+	}
+	r.eventsSynced = len(r.Game.Events)
+}
+
+// HandEvents returns the room's event stream for one hand, or for every hand
+// dealt so far when handID is 0. Only room members may read it.
+func (m *MemoryStore) HandEvents(roomID, userID string, handID int64) ([]domain.Event, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	if !isMember(r, userID) {
+		return nil, errors.New("user not in room")
+	}
+	out := make([]domain.Event, 0, len(r.Events))
+	for _, ev := range r.Events {
+		if handID == 0 || ev.HandID == handID {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+func TestStore_RoomKeepsEventStreamAcrossHands(t *testing.T) {
+	s, _, owner, r := startRoom(t, RoomRules{}, 3)
+	r = foldCurrentHand(t, s, r)
+	var err error
+	if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := s.HandEvents(r.RoomID, owner.UserID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.HandEvents(r.RoomID, owner.UserID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := s.HandEvents(r.RoomID, owner.UserID, 2)
+	if len(first) == 0 || len(second) == 0 || len(first)+len(second) != len(all) {
+		t.Fatalf("expected both hands in the room stream, got %d + %d of %d", len(first), len(second), len(all))
+	}
+	if first[0].Type != domain.EventHandStart || second[0].Type != domain.EventHandStart || second[0].Seq != 1 {
+		t.Fatalf("expected every hand to open with hand_start, got %+v / %+v", first[0], second[0])
+	}
+	if last := first[len(first)-1]; last.Type != domain.EventMuck {
+		t.Fatalf("expected the first hand to end with the winner mucking, got %+v", last)
+	}
+	if len(second) != len(r.Game.Events) {
+		t.Fatalf("expected the live hand synced to the room, got %d of %d", len(second), len(r.Game.Events))
+	}
+
+	outsider := s.CreateSession("outsider")
+	if _, err := s.HandEvents(r.RoomID, outsider.UserID, 0); err == nil {
+		t.Fatalf("expected outsiders to be refused")
+	}
+}
+
+func TestStore_KeepsLastFinishedHands(t *testing.T) {
+	s := NewMemoryStore(Options{KeptHands: 2})
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "kept", 10, 10)
+	if _, err := s.JoinRoom(room.RoomID, s.CreateSession("guest")); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hand := 1; hand <= 4; hand++ {
+		r = foldCurrentHand(t, s, r)
+		if hand < 4 {
+			if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	hands, err := s.FinishedHands(r.RoomID, owner.UserID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hands) != 2 || hands[0].HandID != 3 || hands[1].HandID != 4 {
+		t.Fatalf("expected hands 3 and 4 kept, got %+v", hands)
+	}
+	if _, err := s.FinishedHands(r.RoomID, owner.UserID, 2); err == nil || err.Error() != "hand not found" {
+		t.Fatalf("expected a dropped hand to be gone, got %v", err)
+	}
+	events, _ := s.HandEvents(r.RoomID, owner.UserID, 0)
+	if len(events) == 0 || events[0].HandID != 3 {
+		t.Fatalf("expected events of dropped hands trimmed, got %+v", events[:1])
+	}
+
+	hands[1].Game.Players[0].Stack = -1
+	again, _ := s.FinishedHands(r.RoomID, owner.UserID, 4)
+	if again[0].Game.Players[0].Stack == -1 {
+		t.Fatalf("expected finished hands handed out as copies")
+	}
+}
+
+func TestStore_ArchivedHandOnlyTakesReveals(t *testing.T) {
+	s, _, owner, r := startRoom(t, RoomRules{}, 2)
+	r = foldCurrentHand(t, s, r)
+	winner := r.Game.Result.Winners[0]
+
+	live := s.rooms[r.RoomID].Game
+	stack, seq := live.Players[0].Stack, live.Events[0].Seq
+	live.Players[0].Stack = -1
+	live.Events[0].Seq = -1
+	hands, _ := s.FinishedHands(r.RoomID, owner.UserID, r.HandCounter)
+	if hands[0].Game.Players[0].Stack == -1 || hands[0].Game.Events[0].Seq == -1 {
+		t.Fatalf("expected the archive not to share players or events with the live hand")
+	}
+	live.Players[0].Stack, live.Events[0].Seq = stack, seq
+
+	if _, err := s.ApplyReveal(r.RoomID, winner, "", 3, r.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	hands, _ = s.FinishedHands(r.RoomID, owner.UserID, r.HandCounter)
+	for _, gp := range hands[0].Game.Players {
+		if gp.UserID == winner && gp.RevealMask != 3 {
+			t.Fatalf("expected a reveal after the hand to reach the archive, got %d", gp.RevealMask)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"texas_yu/internal/domain"
+	"texas_yu/internal/handhistory"
+)
+
+// FinishedHand is a hand that was played to the end at a table. Game is the
+// hand as it finished; players may still reveal cards until the next hand.
+type FinishedHand struct {
+	HandID     int64
+	StartedAt  time.Time
+	ButtonSeat int
+	Game       *domain.GameState
+}
+
+func (m *MemoryStore) archiveFinishedHandLocked(r *Room) {
+	if r.Game == nil || r.Game.Stage != domain.StageFinished {
+		return
+	}
+	recordHandChipsLocked(r)
+	if n := len(r.FinishedHands); n > 0 && r.FinishedHands[n-1].HandID == r.HandCounter {
+		return
+	}
+	r.FinishedHands = append(r.FinishedHands, FinishedHand{
+		HandID:     r.HandCounter,
+		StartedAt:  r.handStartedAt,
+		ButtonSeat: r.ButtonSeat,
+		Game:       cloneGame(r.Game),
+	})
+	if drop := len(r.FinishedHands) - m.keptHands; drop > 0 {
+		r.FinishedHands = append([]FinishedHand(nil), r.FinishedHands[drop:]...)
+		oldest := r.FinishedHands[0].HandID
+		kept := 0
+		for kept < len(r.Events) && r.Events[kept].HandID < oldest {
+			kept++
+		}
+		r.Events = append([]domain.Event(nil), r.Events[kept:]...)
+	}
+}
+
+func rearchiveRevealLocked(r *Room) {
+	if n := len(r.FinishedHands); n > 0 && r.FinishedHands[n-1].HandID == r.HandCounter {
+		r.FinishedHands[n-1].Game = cloneGame(r.Game)
+	}
+}
+
+// FinishedHands returns copies of the finished hands the room still keeps:
+// one hand, or all of them when handID is 0.
+func (m *MemoryStore) FinishedHands(roomID, userID string, handID int64) ([]FinishedHand, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	if !isMember(r, userID) {
+		return nil, errors.New("user not in room")
+	}
+	out := make([]FinishedHand, 0, len(r.FinishedHands))
+	for _, fh := range r.FinishedHands {
+		if handID != 0 && fh.HandID != handID {
+			continue
+		}
+		fh.Game = cloneGame(fh.Game)
+		out = append(out, fh)
+	}
+	if handID != 0 && len(out) == 0 {
+		return nil, errors.New("hand not found")
+	}
+	return out, nil
+}
+
+// HandHistory returns finished hands of the room as PokerStars hand history
+// text, showing only the cards userID may see: one hand, or every hand the
+// room still keeps when handID is 0.
+func (m *MemoryStore) HandHistory(roomID, userID string, handID int64) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return "", errors.New("room not found")
+	}
+	if !isMember(r, userID) {
+		return "", errors.New("user not in room")
+	}
+	hands := make([]handhistory.Hand, 0, len(r.FinishedHands))
+	for _, fh := range r.FinishedHands {
+		if handID != 0 && fh.HandID != handID {
+			continue
+		}
+		hands = append(hands, handhistory.Hand{
+			ID:         fh.HandID,
+			Table:      r.Name,
+			StartedAt:  fh.StartedAt,
+			ButtonSeat: fh.ButtonSeat,
+			Game:       fh.Game,
+		})
+	}
+	if handID != 0 && len(hands) == 0 {
+		return "", errors.New("hand not found")
+	}
+	return handhistory.PokerStarsAll(hands, userID)
+}
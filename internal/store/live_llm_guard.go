@@ -38,7 +38,7 @@ func shouldPreferFallbackDecision(input ai.DecisionInput, decision ai.Decision,
 	scareScore := 0.0
 	if cardsOK {
 		if len(heroHole)+len(heroBoard) >= 5 {
-			best, _, _ := domain.BestOfSeven(append(append([]domain.Card{}, heroBoard...), heroHole...))
+			best, _, _ := domain.BestHand(domain.Variant(input.Variant), heroHole, heroBoard)
 			heroCategoryRank = best.Category
 		}
 		pairScore = pairStrengthScore(heroHole, heroBoard)
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
-	mathrand "math/rand"
 	"sort"
 	"strings"
 	"sync"
@@ -16,6 +15,7 @@ import (
 
 	"texas_yu/internal/ai"
 	"texas_yu/internal/domain"
+	"texas_yu/internal/equity"
 )
 
 type Session struct {
@@ -40,6 +40,8 @@ const (
 const (
 	MaxAISummaries     = 20
 	DefaultPlayerStack = 10000
+	DefaultSitOutHands = 3
+	DefaultKeptHands   = 50
 )
 
 type QuickChatEvent struct {
@@ -139,6 +141,19 @@ type RoomPlayer struct {
 	Stack     int    `json:"stack"`
 	IsAI      bool   `json:"isAi"`
 	AIManaged bool   `json:"aiManaged"`
+	// SittingOut players keep their seat and stack but are not dealt in;
+	// SitOutHands counts the hands they have missed so far.
+	SittingOut  bool `json:"sittingOut"`
+	SitOutHands int  `json:"sitOutHands"`
+	// WaitingForHand players joined after the first hand and have not been
+	// dealt in yet. PostBlind deals them in with a big blind from the next
+	// hand; otherwise they wait until the big blind reaches their seat.
+	WaitingForHand bool `json:"waitingForHand"`
+	PostBlind      bool `json:"postBlind"`
+	// MissedSmallBlind and MissedBigBlind record blinds that passed the
+	// player's seat while they sat out; they are posted on return.
+	MissedSmallBlind bool `json:"missedSmallBlind"`
+	MissedBigBlind   bool `json:"missedBigBlind"`
 }
 
 type RoomSpectator struct {
@@ -169,19 +184,73 @@ type ChipRefreshVote struct {
 	UpdatedAtUnix   int64                              `json:"updatedAtUnix"`
 }
 
+// RoomRules are the optional table rules chosen when a room is created.
+type RoomRules struct {
+	Variant  domain.Variant  `json:"variant"`
+	AnteMode domain.AnteMode `json:"anteMode"`
+	Ante     int             `json:"ante"`
+	Straddle bool            `json:"straddle"`
+	// FixedLimit rooms bet SmallBet preflop and on the flop and BigBet on the
+	// turn and river.
+	FixedLimit bool `json:"fixedLimit"`
+	SmallBet   int  `json:"smallBet,omitempty"`
+	BigBet     int  `json:"bigBet,omitempty"`
+	// RunItTwice lets all-in players agree to deal the rest of the board up
+	// to three times.
+	RunItTwice bool `json:"runItTwice"`
+	// ActionSeconds is the time each human gets per decision, 0 for no clock.
+	// TimeBankSeconds is extra time per player that is used up once a
+	// decision runs past ActionSeconds.
+	ActionSeconds   int `json:"actionSeconds"`
+	TimeBankSeconds int `json:"timeBankSeconds"`
+	// SitOutHands is how many hands a player may sit out before losing
+	// their seat.
+	SitOutHands int `json:"sitOutHands"`
+	// TournamentRules, when set, make the room a single-table tournament.
+	TournamentRules *TournamentRules `json:"tournamentRules,omitempty"`
+	// MinBuyIn and MaxBuyIn bound the chips a cash-game player may sit down,
+	// rebuy or top up to.
+	MinBuyIn int `json:"minBuyIn,omitempty"`
+	MaxBuyIn int `json:"maxBuyIn,omitempty"`
+	// Rake is the house's cut of every cash-game pot.
+	Rake domain.RakeRules `json:"rake"`
+	// BombPotEvery deals every so many hands as a bomb pot, 0 for none.
+	// BombPot is the ante and board of those and of the bomb pots the owner
+	// calls for.
+	BombPotEvery int     `json:"bombPotEvery,omitempty"`
+	BombPot      BombPot `json:"bombPot"`
+}
+
+func (rr RoomRules) gameOptions() domain.GameOptions {
+	return domain.GameOptions{
+		Variant:    rr.Variant,
+		AnteMode:   rr.AnteMode,
+		Ante:       rr.Ante,
+		Straddle:   rr.Straddle,
+		FixedLimit: rr.FixedLimit,
+		SmallBet:   rr.SmallBet,
+		BigBet:     rr.BigBet,
+		RunItTwice: rr.RunItTwice,
+		Rake:       rr.Rake,
+	}
+}
+
 type Room struct {
-	RoomID               string          `json:"roomId"`
-	Name                 string          `json:"name"`
-	OpenBetMin           int             `json:"openBetMin"`
-	BetMin               int             `json:"betMin"`
-	OwnerUserID          string          `json:"ownerUserId"`
-	Status               RoomStatus      `json:"status"`
-	Players              []RoomPlayer    `json:"players"`
-	Spectators           []RoomSpectator `json:"spectators,omitempty"`
-	StateVersion         int64           `json:"stateVersion"`
-	UpdatedAtUnix        int64           `json:"updatedAtUnix"`
-	NextDealerPos        int             `json:"-"`
-	Game                 *domain.GameState
+	RoomID     string `json:"roomId"`
+	Name       string `json:"name"`
+	OpenBetMin int    `json:"openBetMin"`
+	BetMin     int    `json:"betMin"`
+	RoomRules
+	OwnerUserID          string            `json:"ownerUserId"`
+	Status               RoomStatus        `json:"status"`
+	Players              []RoomPlayer      `json:"players"`
+	Spectators           []RoomSpectator   `json:"spectators,omitempty"`
+	StateVersion         int64             `json:"stateVersion"`
+	UpdatedAtUnix        int64             `json:"updatedAtUnix"`
+	ButtonSeat           int               `json:"buttonSeat"`
+	SmallBlindSeat       int               `json:"smallBlindSeat"`
+	BigBlindSeat         int               `json:"bigBlindSeat"`
+	Game                 *domain.GameState `json:"-"`
 	ActionSeen           map[string]bool
 	QuickChats           []QuickChatEvent
 	QuickChatSeen        map[string]bool
@@ -191,6 +260,26 @@ type Room struct {
 	AIMemory             map[string]*RoomAIMemory `json:"aiMemory"`
 	ChipRefreshVote      *ChipRefreshVote         `json:"chipRefreshVote,omitempty"`
 	HandCounter          int64
+	ActionClock          *ActionClock     `json:"actionClock,omitempty"`
+	TimeBanks            map[string]int64 `json:"timeBanks,omitempty"`
+	// Events is the event stream of the kept and current hands, oldest
+	// first; eventsSynced counts the events of the current hand already in it.
+	Events       []domain.Event `json:"-"`
+	eventsSynced int
+	// FinishedHands keeps the last hands played to the end, oldest first.
+	FinishedHands []FinishedHand `json:"-"`
+	// Tournament is the progress of a tournament room once it has started.
+	Tournament    *TournamentState `json:"tournament,omitempty"`
+	handStartedAt time.Time
+	// ChipLedger records every chip brought to or taken from a cash table
+	// and won or lost in its hands; ledgerHand is the last hand recorded.
+	ChipLedger []ChipLedgerEntry `json:"-"`
+	ledgerSeq  int64
+	ledgerHand int64
+	// RakeTotal is all the rake the room has taken.
+	RakeTotal int `json:"rakeTotal"`
+	// NextBombPot is the bomb pot the owner called for the next hand.
+	NextBombPot *BombPot `json:"nextBombPot,omitempty"`
 }
 
 type quickChatSeenKey struct {
@@ -216,6 +305,13 @@ type aiDecisionTask struct {
 	RetriesLeft     int
 }
 
+func (t *aiDecisionTask) prepare() {
+	t.Fallback = fallbackDecision(t.Input)
+	baseline := t.Fallback
+	t.Input.BaselineDecision = &baseline
+	t.Input.DecisionOptions = buildDecisionOptions(t.Input, currentStrategyParams(), baseline)
+}
+
 type aiSummaryTask struct {
 	RoomID string
 	HandID int64
@@ -233,6 +329,11 @@ type Options struct {
 	AIConfig            ai.Config
 	StrategyConfigPath  string
 	AIRuntimeConfigPath string
+	// Now replaces time.Now for the action clock; tests use it to move time.
+	Now func() time.Time
+	// KeptHands is how many finished hands each room keeps for hand
+	// histories and replays; 0 means DefaultKeptHands.
+	KeptHands int
 }
 
 type MemoryStore struct {
@@ -240,8 +341,10 @@ type MemoryStore struct {
 	aiStateMu           sync.RWMutex
 	users               map[string]*Session
 	rooms               map[string]*Room
+	tournaments         map[string]*MultiTableTournament
 	lastActive          map[string]int64
 	nextRoom            int64
+	nextTournament      int64
 	nextAIUser          int64
 	roomsVersion        int64
 	strategyConfigPath  string
@@ -256,6 +359,8 @@ type MemoryStore struct {
 	aiWorkers map[string]bool
 	aiQueue   chan aiTaskEnvelope
 	benchmark *BenchmarkManager
+	now       func() time.Time
+	keptHands int
 }
 
 func NewMemoryStore(opts ...Options) *MemoryStore {
@@ -297,6 +402,7 @@ func NewMemoryStore(opts ...Options) *MemoryStore {
 	ms := &MemoryStore{
 		users:               map[string]*Session{},
 		rooms:               map[string]*Room{},
+		tournaments:         map[string]*MultiTableTournament{},
 		lastActive:          map[string]int64{},
 		aiWorkers:           map[string]bool{},
 		aiQueue:             make(chan aiTaskEnvelope, 256),
@@ -307,11 +413,17 @@ func NewMemoryStore(opts ...Options) *MemoryStore {
 		aiBaseConfig:        cfg.AIConfig,
 		aiBaseService:       aiSvc,
 		aiRuntimeSettings:   runtimeSettings,
+		now:                 cfg.Now,
+		keptHands:           cfg.KeptHands,
+	}
+	if ms.keptHands <= 0 {
+		ms.keptHands = DefaultKeptHands
 	}
 	ms.rebuildAIServiceLocked()
 	ms.benchmark = NewBenchmarkManager(configPath)
 	go ms.idleCleanupLoop()
 	go ms.aiEventLoop()
+	go ms.actionClockLoop()
 	return ms
 }
 
@@ -489,20 +601,68 @@ func (m *MemoryStore) ListRooms() ([]Room, int64) {
 	return list, m.roomsVersion
 }
 
-func (m *MemoryStore) CreateRoom(owner *Session, name string, openBetMin int, betMin int) *Room {
+func (m *MemoryStore) CreateRoom(owner *Session, name string, openBetMin int, betMin int, rules ...RoomRules) *Room {
+	var rr RoomRules
+	if len(rules) > 0 {
+		rr = rules[0]
+	}
+	r := m.newRoom(owner, name, openBetMin, betMin, rr)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rooms[r.RoomID] = r
+	m.roomsVersion++
+	return r
+}
+
+func (m *MemoryStore) newRoom(owner *Session, name string, openBetMin int, betMin int, rr RoomRules) *Room {
+	if rr.Variant == "" {
+		rr.Variant = domain.VariantHoldem
+	}
+	if rr.AnteMode == "" {
+		rr.AnteMode = domain.AnteNone
+	}
+	if !rr.FixedLimit {
+		rr.SmallBet, rr.BigBet = 0, 0
+	} else {
+		if rr.SmallBet <= 0 {
+			rr.SmallBet = openBetMin
+		}
+		if rr.BigBet <= 0 {
+			rr.BigBet = rr.SmallBet * 2
+		}
+	}
+	if rr.SitOutHands <= 0 {
+		rr.SitOutHands = DefaultSitOutHands
+	}
+	if rr.TournamentRules != nil {
+		rr.TournamentRules = rr.TournamentRules.normalized()
+		openBetMin, betMin = rr.TournamentRules.Levels[0].BigBlind, rr.TournamentRules.Levels[0].BigBlind
+		rr.MinBuyIn, rr.MaxBuyIn = 0, 0
+		rr.Rake = domain.RakeRules{}
+		rr.BombPotEvery, rr.BombPot = 0, BombPot{}
+	} else {
+		buyInDefaults(&rr, openBetMin)
+		if rr.BombPot.Ante <= 0 {
+			rr.BombPot.Ante = openBetMin * DefaultBombPotBigBlinds
+		}
+	}
 	rid := atomic.AddInt64(&m.nextRoom, 1)
 	r := &Room{
 		RoomID:               fmt.Sprintf("r-%d", rid),
 		Name:                 name,
 		OpenBetMin:           openBetMin,
 		BetMin:               betMin,
+		RoomRules:            rr,
 		OwnerUserID:          owner.UserID,
 		Status:               RoomWaiting,
 		Players:              []RoomPlayer{{UserID: owner.UserID, Username: owner.Username, Seat: 0, Stack: DefaultPlayerStack, IsAI: false, AIManaged: false}},
 		Spectators:           []RoomSpectator{},
 		StateVersion:         1,
 		UpdatedAtUnix:        time.Now().Unix(),
-		NextDealerPos:        0,
+		ButtonSeat:           -1,
+		SmallBlindSeat:       -1,
+		BigBlindSeat:         -1,
 		Game:                 nil,
 		ActionSeen:           map[string]bool{},
 		QuickChats:           []QuickChatEvent{},
@@ -513,15 +673,14 @@ func (m *MemoryStore) CreateRoom(owner *Session, name string, openBetMin int, be
 		AIMemory:             map[string]*RoomAIMemory{},
 		HandCounter:          0,
 	}
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.rooms[r.RoomID] = r
-	m.roomsVersion++
+	r.Players[0].Stack, _ = seatBuyIn(r, 0)
+	recordChipsLocked(r, r.Players[0], ChipLedgerBuyIn, r.Players[0].Stack, 0)
 	return r
 }
 
-func (m *MemoryStore) JoinRoom(roomID string, s *Session) (*Room, error) {
+// JoinRoom seats s with buyIn chips, or the room's maximum buy-in when buyIn
+// is left out or 0.
+func (m *MemoryStore) JoinRoom(roomID string, s *Session, buyIn ...int) (*Room, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	r, ok := m.rooms[roomID]
@@ -531,13 +690,23 @@ func (m *MemoryStore) JoinRoom(roomID string, s *Session) (*Room, error) {
 	if isPlayer(r, s.UserID) {
 		return r, nil
 	}
-	if r.Status != RoomWaiting {
-		return nil, errors.New("room already playing")
+	if r.Tournament != nil {
+		return nil, errTournamentStarted
+	}
+	amount := 0
+	if len(buyIn) > 0 {
+		amount = buyIn[0]
+	}
+	stack, err := seatBuyIn(r, amount)
+	if err != nil {
+		return nil, err
 	}
 	if idx := spectatorIndex(r, s.UserID); idx >= 0 {
 		r.Spectators = append(r.Spectators[:idx], r.Spectators[idx+1:]...)
 	}
-	r.Players = append(r.Players, RoomPlayer{UserID: s.UserID, Username: s.Username, Seat: len(r.Players), Stack: DefaultPlayerStack, IsAI: false, AIManaged: false})
+	// Once hands have been dealt a newcomer waits for the next one.
+	seated := takeSeatLocked(r, RoomPlayer{UserID: s.UserID, Username: s.Username, Stack: stack, IsAI: false, AIManaged: false, WaitingForHand: r.HandCounter > 0})
+	recordChipsLocked(r, seated, ChipLedgerBuyIn, stack, 0)
 	r.ChipRefreshVote = nil
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
@@ -584,19 +753,25 @@ func (m *MemoryStore) AddAI(roomID, ownerUserID, name string) (*Room, *RoomPlaye
 	if r.Status != RoomWaiting {
 		return nil, nil, errors.New("can only add ai in waiting")
 	}
+	if r.Tournament != nil {
+		return nil, nil, errTournamentStarted
+	}
+	if domain.IsOmaha(r.Variant) {
+		return nil, nil, errors.New("ai players are not supported in omaha rooms")
+	}
 	aiName := strings.TrimSpace(name)
 	if aiName == "" {
 		aiName = fmt.Sprintf("Bot %d", len(r.Players)+1)
 	}
-	aiPlayer := RoomPlayer{
+	stack, _ := seatBuyIn(r, 0)
+	aiPlayer := takeSeatLocked(r, RoomPlayer{
 		UserID:    m.newAIUserID(),
 		Username:  aiName,
-		Seat:      len(r.Players),
-		Stack:     DefaultPlayerStack,
+		Stack:     stack,
 		IsAI:      true,
 		AIManaged: false,
-	}
-	r.Players = append(r.Players, aiPlayer)
+	})
+	recordChipsLocked(r, aiPlayer, ChipLedgerBuyIn, stack, 0)
 	r.AIMemory[aiPlayer.UserID] = &RoomAIMemory{
 		HandSummaries:    []string{},
 		OpponentProfiles: map[string]*OpponentProfile{},
@@ -627,6 +802,9 @@ func (m *MemoryStore) RemoveAI(roomID, ownerUserID, aiUserID string) (*Room, err
 	if r.Status != RoomWaiting {
 		return nil, errors.New("can only remove ai in waiting")
 	}
+	if r.Tournament != nil {
+		return nil, errTournamentStarted
+	}
 	idx := -1
 	for i, p := range r.Players {
 		if p.UserID == aiUserID && p.IsAI {
@@ -637,11 +815,7 @@ func (m *MemoryStore) RemoveAI(roomID, ownerUserID, aiUserID string) (*Room, err
 	if idx < 0 {
 		return nil, errors.New("ai not found")
 	}
-	r.Players = append(r.Players[:idx], r.Players[idx+1:]...)
-	for i := range r.Players {
-		r.Players[i].Seat = i
-	}
-	delete(r.AIMemory, aiUserID)
+	removeSeatLocked(r, idx)
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
 	m.roomsVersion++
@@ -666,6 +840,9 @@ func (m *MemoryStore) SetPlayerAIManaged(roomID, userID string, enabled bool) (*
 	if r.Players[idx].IsAI {
 		return nil, errors.New("ai player cannot toggle ai managed")
 	}
+	if enabled && domain.IsOmaha(r.Variant) {
+		return nil, errors.New("ai players are not supported in omaha rooms")
+	}
 	if r.Players[idx].AIManaged == enabled {
 		return r, nil
 	}
@@ -682,6 +859,7 @@ func (m *MemoryStore) SetPlayerAIManaged(roomID, userID string, enabled bool) (*
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
 	m.roomsVersion++
+	m.syncActionClockLocked(r)
 	m.enqueueAIDecisionLocked(r)
 	return r, nil
 }
@@ -749,104 +927,139 @@ func cloneRoomLocked(r *Room) *Room {
 		}
 		copyRoom.ChipRefreshVote = &voteCopy
 	}
-	if r.Game != nil {
-		gCopy := *r.Game
-		if r.Game.CommunityCards != nil {
-			gCopy.CommunityCards = append([]domain.Card(nil), r.Game.CommunityCards...)
-		}
-		if r.Game.ActionLogs != nil {
-			gCopy.ActionLogs = append([]domain.ActionLog(nil), r.Game.ActionLogs...)
-		}
-		if r.Game.Players != nil {
-			gCopy.Players = make([]*domain.GamePlayer, len(r.Game.Players))
-			for i, gp := range r.Game.Players {
-				if gp == nil {
-					continue
-				}
-				pCopy := *gp
-				if gp.HoleCards != nil {
-					pCopy.HoleCards = append([]domain.Card(nil), gp.HoleCards...)
-				}
-				if gp.BestHandCards != nil {
-					pCopy.BestHandCards = append([]domain.Card(nil), gp.BestHandCards...)
-				}
-				gCopy.Players[i] = &pCopy
-			}
-		}
-		if r.Game.Result != nil {
-			resultCopy := *r.Game.Result
-			resultCopy.Winners = append([]string(nil), r.Game.Result.Winners...)
-			gCopy.Result = &resultCopy
+	if r.ActionClock != nil {
+		clockCopy := *r.ActionClock
+		copyRoom.ActionClock = &clockCopy
+	}
+	if r.Events != nil {
+		copyRoom.Events = append([]domain.Event(nil), r.Events...)
+	}
+	if r.FinishedHands != nil {
+		copyRoom.FinishedHands = append([]FinishedHand(nil), r.FinishedHands...)
+	}
+	copyRoom.Tournament = cloneTournament(r.Tournament)
+	copyRoom.ChipLedger = append([]ChipLedgerEntry(nil), r.ChipLedger...)
+	if r.TimeBanks != nil {
+		copyRoom.TimeBanks = make(map[string]int64, len(r.TimeBanks))
+		for uid, bank := range r.TimeBanks {
+			copyRoom.TimeBanks[uid] = bank
 		}
-		copyRoom.Game = &gCopy
 	}
+	copyRoom.Game = cloneGame(r.Game)
 	return &copyRoom
 }
 
-func (m *MemoryStore) GetRoom(roomID string) (*Room, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	r, ok := m.rooms[roomID]
-	if !ok {
-		return nil, false
+func cloneGame(g *domain.GameState) *domain.GameState {
+	if g == nil {
+		return nil
 	}
-	return cloneRoomLocked(r), true
-}
-
-func (m *MemoryStore) buildGameFromRoom(r *Room, stacks map[string]int) (*domain.GameState, error) {
-	gps := make([]*domain.GamePlayer, 0, len(r.Players))
-	playablePosToGamePos := map[int]int{}
-	for pos, p := range r.Players {
-		stack := p.Stack
-		if stacks != nil {
-			if v, ok := stacks[p.UserID]; ok {
-				stack = v
-			}
+	gCopy := *g
+	if g.CommunityCards != nil {
+		gCopy.CommunityCards = append([]domain.Card(nil), g.CommunityCards...)
+	}
+	if g.SecondBoard != nil {
+		gCopy.SecondBoard = append([]domain.Card(nil), g.SecondBoard...)
+	}
+	if g.Pots != nil {
+		gCopy.Pots = make([]domain.Pot, len(g.Pots))
+		for i, pot := range g.Pots {
+			pot.EligibleIDs = append([]string(nil), pot.EligibleIDs...)
+			pot.WinnerIDs = append([]string(nil), pot.WinnerIDs...)
+			pot.LowWinnerIDs = append([]string(nil), pot.LowWinnerIDs...)
+			gCopy.Pots[i] = pot
 		}
-		if stack <= 0 {
-			continue
+	}
+	if g.ActionLogs != nil {
+		gCopy.ActionLogs = append([]domain.ActionLog(nil), g.ActionLogs...)
+	}
+	if g.Events != nil {
+		gCopy.Events = append([]domain.Event(nil), g.Events...)
+	}
+	if g.RunoutVotes != nil {
+		gCopy.RunoutVotes = make(map[string]int, len(g.RunoutVotes))
+		for uid, runs := range g.RunoutVotes {
+			gCopy.RunoutVotes[uid] = runs
 		}
-		playablePosToGamePos[pos] = len(gps)
-		gps = append(gps, &domain.GamePlayer{
-			UserID:    p.UserID,
-			Username:  p.Username,
-			IsAI:      p.IsAI,
-			AIManaged: p.AIManaged,
-			SeatIndex: p.Seat,
-			Stack:     stack,
-		})
 	}
-	dealerPos := 0
-	if len(gps) > 0 && len(r.Players) > 0 {
-		startPos := ((r.NextDealerPos % len(r.Players)) + len(r.Players)) % len(r.Players)
-		for i := 0; i < len(r.Players); i++ {
-			roomPos := (startPos + i) % len(r.Players)
-			if pos, ok := playablePosToGamePos[roomPos]; ok {
-				dealerPos = pos
-				break
+	if g.Runouts != nil {
+		gCopy.Runouts = make([]domain.Runout, len(g.Runouts))
+		for i, run := range g.Runouts {
+			run.Board = append([]domain.Card(nil), run.Board...)
+			pots := make([]domain.Pot, len(run.Pots))
+			for j, pot := range run.Pots {
+				pot.EligibleIDs = append([]string(nil), pot.EligibleIDs...)
+				pot.WinnerIDs = append([]string(nil), pot.WinnerIDs...)
+				pot.LowWinnerIDs = append([]string(nil), pot.LowWinnerIDs...)
+				pots[j] = pot
+			}
+			run.Pots = pots
+			hands := make(map[string]string, len(run.Hands))
+			for uid, name := range run.Hands {
+				hands[uid] = name
 			}
+			run.Hands = hands
+			if run.Lows != nil {
+				lows := make(map[string]string, len(run.Lows))
+				for uid, name := range run.Lows {
+					lows[uid] = name
+				}
+				run.Lows = lows
+			}
+			gCopy.Runouts[i] = run
 		}
 	}
-	return domain.NewGame(gps, dealerPos, r.OpenBetMin, r.BetMin)
-}
-
-func nextDealerPosAfterStart(room *Room, game *domain.GameState) int {
-	if room == nil || len(room.Players) == 0 {
-		return 0
+	if g.HasActed != nil {
+		gCopy.HasActed = make(map[string]bool, len(g.HasActed))
+		for uid, acted := range g.HasActed {
+			gCopy.HasActed[uid] = acted
+		}
 	}
-	fallback := ((room.NextDealerPos % len(room.Players)) + len(room.Players)) % len(room.Players)
-	if game == nil || len(game.Players) == 0 {
-		return (fallback + 1) % len(room.Players)
+	if g.RaiseOpen != nil {
+		gCopy.RaiseOpen = make(map[string]bool, len(g.RaiseOpen))
+		for uid, open := range g.RaiseOpen {
+			gCopy.RaiseOpen[uid] = open
+		}
+	}
+	if g.Players != nil {
+		gCopy.Players = make([]*domain.GamePlayer, len(g.Players))
+		for i, gp := range g.Players {
+			if gp == nil {
+				continue
+			}
+			pCopy := *gp
+			if gp.HoleCards != nil {
+				pCopy.HoleCards = append([]domain.Card(nil), gp.HoleCards...)
+			}
+			if gp.BestHandCards != nil {
+				pCopy.BestHandCards = append([]domain.Card(nil), gp.BestHandCards...)
+			}
+			gCopy.Players[i] = &pCopy
+		}
+	}
+	if g.Departed != nil {
+		gCopy.Departed = make([]*domain.GamePlayer, len(g.Departed))
+		for i, gp := range g.Departed {
+			pCopy := *gp
+			pCopy.HoleCards = append([]domain.Card(nil), gp.HoleCards...)
+			gCopy.Departed[i] = &pCopy
+		}
 	}
-	dealerPos := game.DealerPos
-	if dealerPos < 0 || dealerPos >= len(game.Players) {
-		return (fallback + 1) % len(room.Players)
+	if g.Result != nil {
+		resultCopy := *g.Result
+		resultCopy.Winners = append([]string(nil), g.Result.Winners...)
+		gCopy.Result = &resultCopy
 	}
-	dealerSeat := game.Players[dealerPos].SeatIndex
-	if dealerSeat < 0 || dealerSeat >= len(room.Players) {
-		return (fallback + 1) % len(room.Players)
+	return &gCopy
+}
+
+func (m *MemoryStore) GetRoom(roomID string) (*Room, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, false
 	}
-	return (dealerSeat + 1) % len(room.Players)
+	return cloneRoomLocked(r), true
 }
 
 func (m *MemoryStore) StartGame(roomID, userID string) (*Room, error) {
@@ -868,7 +1081,7 @@ func (m *MemoryStore) StartGame(roomID, userID string) (*Room, error) {
 		m.mu.Unlock()
 		return nil, errors.New("only owner can start")
 	}
-	if r.Status != RoomWaiting {
+	if r.Status != RoomWaiting || r.Tournament != nil {
 		m.mu.Unlock()
 		return nil, errors.New("game already started")
 	}
@@ -876,25 +1089,50 @@ func (m *MemoryStore) StartGame(roomID, userID string) (*Room, error) {
 		m.mu.Unlock()
 		return nil, errors.New("at least 2 players needed")
 	}
-	g, err := m.buildGameFromRoom(r, nil)
-	if err != nil {
+	if r.TournamentRules != nil {
+		startTournamentLocked(r, m.Now())
+	}
+	if err := m.dealHandLocked(r, nil); err != nil {
 		m.mu.Unlock()
 		return nil, err
 	}
+	m.mu.Unlock()
+	return r, nil
+}
+
+func (m *MemoryStore) dealHandLocked(r *Room, stacks map[string]int) error {
+	removed := m.removeIdleSittersLocked(r)
+	if r.Tournament != nil {
+		m.applyTournamentLevelLocked(r)
+	}
+	g, err := m.buildGameFromRoom(r, stacks)
+	if err != nil {
+		if removed {
+			r.StateVersion++
+			m.roomsVersion++
+		}
+		return err
+	}
+	countSitOutHands(r)
 	r.Game = g
 	r.Status = RoomPlaying
 	r.ActionSeen = map[string]bool{}
 	r.ChipRefreshVote = nil
 	r.HandCounter++
-	if len(r.Players) > 0 {
-		r.NextDealerPos = nextDealerPosAfterStart(r, g)
-	}
+	r.handStartedAt = m.Now()
+	r.eventsSynced = 0
+	if r.Tournament != nil {
+		r.Tournament.LevelHands++
+		r.Tournament.NextHandAtMs = 0
+	}
+	syncHandEventsLocked(r)
+	m.archiveFinishedHandLocked(r)
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
 	m.roomsVersion++
+	m.syncActionClockLocked(r)
 	m.enqueueAIDecisionLocked(r)
-	m.mu.Unlock()
-	return r, nil
+	return nil
 }
 
 func (m *MemoryStore) LeaveRoom(roomID, userID string) (*Room, error) {
@@ -926,19 +1164,26 @@ func (m *MemoryStore) LeaveRoom(roomID, userID string) (*Room, error) {
 		finishedByLeave = beforeStage != domain.StageFinished && r.Game.Stage == domain.StageFinished
 	}
 
-	r.Players = append(r.Players[:idx], r.Players[idx+1:]...)
-	for i := range r.Players {
-		r.Players[i].Seat = i
-	}
-	if r.AIMemory != nil {
-		delete(r.AIMemory, userID)
+	if r.Tournament != nil && r.Tournament.Status == TournamentRunning {
+		// Leaving a tournament forfeits the seat: the player is out now.
+		handID := int64(0)
+		if r.Status == RoomPlaying {
+			handID = r.HandCounter
+		}
+		m.eliminateLocked(r, r.Players[idx], handID)
+	} else {
+		removeSeatLocked(r, idx)
 	}
-	r.ChipRefreshVote = nil
 
 	if countHumans(r.Players) == 0 {
 		delete(m.rooms, roomID)
 		delete(m.aiWorkers, roomID)
 		m.roomsVersion++
+		if r.Tournament != nil {
+			if mt := m.tournaments[r.Tournament.TournamentID]; mt != nil {
+				m.rebalanceTournamentLocked(mt)
+			}
+		}
 		m.mu.Unlock()
 		return nil, nil
 	}
@@ -947,32 +1192,7 @@ func (m *MemoryStore) LeaveRoom(roomID, userID string) (*Room, error) {
 	}
 
 	if r.Game != nil {
-		filtered := make([]*domain.GamePlayer, 0, len(r.Game.Players))
-		for _, gp := range r.Game.Players {
-			if gp.UserID != userID {
-				filtered = append(filtered, gp)
-			}
-		}
-		playersByUserID := make(map[string]*domain.GamePlayer, len(filtered))
-		for _, gp := range filtered {
-			playersByUserID[gp.UserID] = gp
-		}
-		reordered := make([]*domain.GamePlayer, 0, len(r.Players))
-		for _, rp := range r.Players {
-			if gp, ok := playersByUserID[rp.UserID]; ok {
-				gp.SeatIndex = rp.Seat
-				reordered = append(reordered, gp)
-			}
-		}
-		r.Game.Players = reordered
-		if len(r.Game.Players) > 0 {
-			if r.Game.TurnPos >= len(r.Game.Players) {
-				r.Game.TurnPos = 0
-			}
-			if r.Game.DealerPos >= len(r.Game.Players) {
-				r.Game.DealerPos = len(r.Game.Players) - 1
-			}
-		}
+		r.Game.RemovePlayerForStore(userID)
 	}
 
 	if r.Status == RoomPlaying && r.Game != nil && r.Game.CountActiveForStore() <= 1 {
@@ -984,12 +1204,19 @@ func (m *MemoryStore) LeaveRoom(roomID, userID string) (*Room, error) {
 		r.Status = RoomWaiting
 	}
 
+	syncHandEventsLocked(r)
+	m.archiveFinishedHandLocked(r)
+	if r.Tournament != nil {
+		m.settleTournamentHandLocked(r)
+		m.finishTournamentIfDecidedLocked(r)
+	}
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
 	m.roomsVersion++
 	if finishedByLeave {
 		m.enqueueAISummaryLocked(r)
 	}
+	m.syncActionClockLocked(r)
 	m.enqueueAIDecisionLocked(r)
 	m.mu.Unlock()
 	return r, nil
@@ -1019,7 +1246,9 @@ func resetRoomPlayerStacks(r *Room, stack int) {
 		return
 	}
 	for i := range r.Players {
+		change := stack - r.Players[i].Stack
 		r.Players[i].Stack = stack
+		recordChipsLocked(r, r.Players[i], ChipLedgerRefresh, change, 0)
 	}
 	if r.Game == nil {
 		return
@@ -1070,6 +1299,9 @@ func (m *MemoryStore) StartChipRefreshVote(roomID, userID string) (*Room, error)
 	if !canRunChipRefreshVote(r) {
 		return nil, errors.New("chip refresh vote is only allowed when hand is not in progress")
 	}
+	if r.TournamentRules != nil {
+		return nil, errors.New("chip refresh is not available in tournaments")
+	}
 
 	eligible := chipRefreshEligibleUserIDs(r.Players)
 	if len(eligible) == 0 {
@@ -1140,7 +1372,7 @@ func (m *MemoryStore) CastChipRefreshVote(roomID, userID, decision string) (*Roo
 		}
 		if allAgreed {
 			vote.Result = ChipRefreshVoteApproved
-			resetRoomPlayerStacks(r, DefaultPlayerStack)
+			resetRoomPlayerStacks(r, r.MaxBuyIn)
 		}
 	}
 
@@ -1175,6 +1407,23 @@ func (m *MemoryStore) NextHand(roomID, userID string) (*Room, error) {
 		m.mu.Unlock()
 		return nil, errors.New("current hand not finished")
 	}
+	if r.Tournament != nil && r.Tournament.Status == TournamentFinished {
+		m.mu.Unlock()
+		return nil, errors.New("tournament is over")
+	}
+	if r.Tournament != nil && !r.Tournament.handDue(m.Now().UnixMilli()) {
+		m.mu.Unlock()
+		return nil, errors.New("next tournament hand is not due yet")
+	}
+	if err := m.dealNextHandLocked(r); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	m.mu.Unlock()
+	return r, nil
+}
+
+func (m *MemoryStore) dealNextHandLocked(r *Room) error {
 	stacks := map[string]int{}
 	for _, gp := range r.Game.Players {
 		stacks[gp.UserID] = gp.Stack
@@ -1184,25 +1433,7 @@ func (m *MemoryStore) NextHand(roomID, userID string) (*Room, error) {
 			r.Players[i].Stack = v
 		}
 	}
-	g, err := m.buildGameFromRoom(r, stacks)
-	if err != nil {
-		m.mu.Unlock()
-		return nil, err
-	}
-	r.Game = g
-	r.Status = RoomPlaying
-	r.ActionSeen = map[string]bool{}
-	r.ChipRefreshVote = nil
-	r.HandCounter++
-	if len(r.Players) > 0 {
-		r.NextDealerPos = nextDealerPosAfterStart(r, g)
-	}
-	r.StateVersion++
-	r.UpdatedAtUnix = time.Now().Unix()
-	m.roomsVersion++
-	m.enqueueAIDecisionLocked(r)
-	m.mu.Unlock()
-	return r, nil
+	return m.dealHandLocked(r, stacks)
 }
 
 func (m *MemoryStore) ApplyAction(roomID, userID, actionID, action string, amount int, expectedVersion int64) (*Room, error) {
@@ -1264,12 +1495,16 @@ func (m *MemoryStore) applyAction(roomID, userID, actionID, action string, amoun
 	if finishedNow {
 		r.Status = RoomWaiting
 	}
+	syncHandEventsLocked(r)
+	m.archiveFinishedHandLocked(r)
+	m.settleTournamentHandLocked(r)
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
 	m.roomsVersion++
 	if finishedNow {
 		m.enqueueAISummaryLocked(r)
 	}
+	m.syncActionClockLocked(r)
 	m.enqueueAIDecisionLocked(r)
 	m.mu.Unlock()
 	return r, nil
@@ -1323,6 +1558,8 @@ func (m *MemoryStore) ApplyReveal(roomID, userID, actionID string, mask int, exp
 	if actionID != "" {
 		r.ActionSeen[actionID] = true
 	}
+	syncHandEventsLocked(r)
+	rearchiveRevealLocked(r)
 	r.StateVersion++
 	r.UpdatedAtUnix = time.Now().Unix()
 	m.roomsVersion++
@@ -1534,6 +1771,16 @@ func firstHumanOwner(players []RoomPlayer) string {
 	return ""
 }
 
+func removeSeatLocked(r *Room, idx int) {
+	recordCashOutLocked(r, idx)
+	userID := r.Players[idx].UserID
+	r.Players = append(r.Players[:idx], r.Players[idx+1:]...)
+	if r.AIMemory != nil {
+		delete(r.AIMemory, userID)
+	}
+	r.ChipRefreshVote = nil
+}
+
 func playerIndex(r *Room, userID string) int {
 	for i, p := range r.Players {
 		if p.UserID == userID {
@@ -1587,28 +1834,12 @@ func cardToText(card domain.Card) string {
 }
 
 func parseCardText(card string) (domain.Card, bool) {
-	rank, suit, ok := parseCardTextRankSuit(card)
-	if !ok {
-		return domain.Card{}, false
-	}
-	var s domain.Suit
-	switch suit {
-	case 'C':
-		s = domain.Clubs
-	case 'D':
-		s = domain.Diamonds
-	case 'H':
-		s = domain.Hearts
-	case 'S':
-		s = domain.Spades
-	default:
-		return domain.Card{}, false
-	}
-	return domain.Card{Rank: rank, Suit: s}, true
+	c, err := domain.ParseCard(card)
+	return c, err == nil
 }
 
 func preflopTierFromHoleCards(hole []domain.Card) string {
-	if len(hole) < 2 {
+	if len(hole) != 2 {
 		return "unknown"
 	}
 	first := hole[0]
@@ -1686,7 +1917,7 @@ func preflopPositionForPlayer(game *domain.GameState, heroPos int) string {
 		}
 		return "sb"
 	}
-	if heroPos == game.DealerPos {
+	if heroPos == game.DealerPos && !game.DeadButton {
 		if activeCount == 2 {
 			return "btn_sb"
 		}
@@ -1842,7 +2073,7 @@ func preflopHoleStrengthCards(hole []domain.Card) (float64, bool, bool, int, int
 }
 
 func preflopHandScore(input ai.DecisionInput) (float64, bool, bool, int, int) {
-	if len(input.HoleCards) < 2 {
+	if len(input.HoleCards) != 2 {
 		return clampFloat(preflopTierScore(input.PreflopTier), 0.02, 0.98), false, false, 0, 10
 	}
 	hole := make([]domain.Card, 0, 2)
@@ -1956,15 +2187,14 @@ func hasGutshotStraightDraw(hole []domain.Card, board []domain.Card) bool {
 	return false
 }
 
-func buildHandStrengthFeatures(hole []domain.Card, board []domain.Card) (string, int, []int, string, string, []string) {
+func buildHandStrengthFeatures(variant domain.Variant, hole []domain.Card, board []domain.Card) (string, int, []int, string, string, []string) {
 	category := ""
 	categoryRank := -1
 	ranks := []int{}
 	madeStrength := "none"
 	draws := []string{}
 	if len(hole)+len(board) >= 5 {
-		cards := append(append([]domain.Card{}, board...), hole...)
-		best, _, name := domain.BestOfSeven(cards)
+		best, _, name := domain.BestHand(variant, hole, board)
 		category = name
 		categoryRank = best.Category
 		ranks = append([]int(nil), best.Ranks...)
@@ -2864,9 +3094,7 @@ func pairStrengthScore(hole []domain.Card, board []domain.Card) float64 {
 func boardMadeAndDrawStrength(hole []domain.Card, board []domain.Card) (float64, float64) {
 	madeScore := pairStrengthScore(hole, board)
 	if len(hole)+len(board) >= 5 {
-		cards := append(append([]domain.Card{}, board...), hole...)
-		best, _, _ := domain.BestOfSeven(cards)
-		switch best.Category {
+		switch domain.RankHand(domain.VariantHoldem, hole, board).Category() {
 		case 8:
 			madeScore = 0.99
 		case 7:
@@ -3254,23 +3482,23 @@ func opponentHandWeight(input ai.DecisionInput, villain ai.PlayerSnapshot, hole
 
 func monteCarloTrialCount(stage string, opponents int) int {
 	stage = strings.ToLower(strings.TrimSpace(stage))
-	trials := 220
+	trials := 2200
 	switch stage {
 	case "preflop":
-		trials = 300
+		trials = 3000
 	case "flop":
-		trials = 260
+		trials = 2600
 	case "turn":
-		trials = 220
+		trials = 2200
 	case "river":
-		trials = 170
+		trials = 1700
 	}
 	if opponents <= 1 {
-		trials += 40
+		trials += 400
 	} else if opponents >= 4 {
-		trials -= 40
+		trials -= 400
 	}
-	return clampInt(trials, 120, 360)
+	return clampInt(trials, 1200, 3600)
 }
 
 func estimateMonteCarloEquity(input ai.DecisionInput) (float64, bool) {
@@ -3313,84 +3541,39 @@ func estimateMonteCarloEquity(input ai.DecisionInput) (float64, bool) {
 		return 1, true
 	}
 
-	deck := make([]domain.Card, 0, 52-len(used))
-	for s := domain.Clubs; s <= domain.Spades; s++ {
-		for r := 2; r <= 14; r++ {
-			c := domain.Card{Rank: r, Suit: s}
-			if !used[c] {
-				deck = append(deck, c)
-			}
+	// Each villain holds any unseen two cards, weighted by how well the hand
+	// fits the line they have taken so far.
+	variant := domain.Variant(input.Variant)
+	deck := make([]domain.Card, 0, 52)
+	for _, c := range domain.NewDeckFor(variant) {
+		if !used[c] {
+			deck = append(deck, c)
 		}
 	}
-	needBoard := 5 - len(board)
-	if needBoard < 0 {
-		needBoard = 0
-	}
-	needTotal := needBoard + opponents*2
-	if needTotal <= 0 {
-		needTotal = 1
-	}
-	if len(deck) < needTotal {
-		return 0, false
-	}
-
-	trials := monteCarloTrialCount(input.Stage, opponents)
-	unweightedScore := 0.0
-	weightedScore := 0.0
-	totalWeight := 0.0
-	work := make([]domain.Card, len(deck))
-	seed := int64(decisionHash64(input, "mc-equity"))
-	rng := mathrand.New(mathrand.NewSource(seed))
 	actionSummary := summarizeVisibleActionsByUser(input.RecentActionLog, input.Stage)
-
-	for t := 0; t < trials; t++ {
-		copy(work, deck)
-		for i := 0; i < needTotal; i++ {
-			j := i + rng.Intn(len(work)-i)
-			work[i], work[j] = work[j], work[i]
-		}
-		drawn := work[:needTotal]
-
-		offset := 0
-		boardNow := append([]domain.Card{}, board...)
-		if needBoard > 0 {
-			boardNow = append(boardNow, drawn[offset:offset+needBoard]...)
-			offset += needBoard
-		}
-
-		heroCards := append(append([]domain.Card{}, boardNow...), hero...)
-		heroValue, _, _ := domain.BestOfSeven(heroCards)
-
-		heroBest := true
-		tiedOpponents := 0
-		sampleWeight := 1.0
-		for i := 0; i < opponents; i++ {
-			oppHole := []domain.Card{drawn[offset], drawn[offset+1]}
-			offset += 2
-			likelihood := opponentHandWeight(input, villains[i], oppHole, actionSummary[villains[i].UserID], board)
-			sampleWeight *= 0.45 + 0.55*likelihood
-			oppCards := append(append([]domain.Card{}, boardNow...), oppHole...)
-			oppValue, _, _ := domain.BestOfSeven(oppCards)
-			cmp := domain.CompareHandValue(oppValue, heroValue)
-			if cmp > 0 {
-				heroBest = false
-				break
-			}
-			if cmp == 0 {
-				tiedOpponents++
+	seats := []equity.Seat{{Hole: hero}}
+	for _, villain := range villains {
+		villainRange := domain.NewRange()
+		for i := range deck {
+			for j := i + 1; j < len(deck); j++ {
+				hole := []domain.Card{deck[i], deck[j]}
+				likelihood := opponentHandWeight(input, villain, hole, actionSummary[villain.UserID], board)
+				villainRange.Set(deck[i], deck[j], 0.15+0.85*likelihood)
 			}
 		}
-		totalWeight += sampleWeight
-		if heroBest {
-			share := 1.0 / float64(tiedOpponents+1)
-			unweightedScore += share
-			weightedScore += share * sampleWeight
-		}
+		seats = append(seats, equity.Seat{Range: villainRange})
 	}
-	if totalWeight > 0 {
-		return clampFloat(weightedScore/totalWeight, 0.01, 0.99), true
+	res, err := equity.Calculate(equity.Request{
+		Variant: variant,
+		Board:   board,
+		Seats:   seats,
+		Trials:  monteCarloTrialCount(input.Stage, opponents),
+		Seed:    int64(decisionHash64(input, "mc-equity")),
+	})
+	if err != nil {
+		return 0, false
 	}
-	return clampFloat(unweightedScore/float64(trials), 0.01, 0.99), true
+	return clampFloat(res.Seats[0].Equity, 0.01, 0.99), true
 }
 
 func boardWetness(community []string) float64 {
@@ -3654,6 +3837,13 @@ func drawEquityBonus(input ai.DecisionInput) float64 {
 	return bonus
 }
 
+func maxBetCommit(input ai.DecisionInput) int {
+	if input.MaxRaise > 0 && input.MaxRaise < input.Stack {
+		return input.MaxRaise
+	}
+	return input.Stack
+}
+
 func decisionAllowedByInput(input ai.DecisionInput, decision ai.Decision) bool {
 	action := strings.ToLower(strings.TrimSpace(decision.Action))
 	if action == "" {
@@ -3677,7 +3867,7 @@ func decisionAllowedByInput(input ai.DecisionInput, decision ai.Decision) bool {
 		if min <= 0 {
 			min = 1
 		}
-		return decision.Amount >= min && decision.Amount <= input.Stack
+		return decision.Amount >= min && decision.Amount <= maxBetCommit(input)
 	default:
 		return false
 	}
@@ -3942,7 +4132,7 @@ func fallbackDecisionWithParams(input ai.DecisionInput, params StrategyParams) a
 		betMin = 1
 	}
 	clampBet := func(amount int) int {
-		return clampInt(amount, betMin, input.Stack)
+		return clampInt(amount, betMin, maxBetCommit(input))
 	}
 	if len(allowed) == 0 {
 		return ai.Decision{Action: "fold", Amount: 0}
@@ -3989,7 +4179,7 @@ func fallbackDecisionWithParams(input ai.DecisionInput, params StrategyParams) a
 	lineCapScore := visibleRangeCapScore(input)
 	if cardsOK {
 		if len(heroHole)+len(heroBoard) >= 5 {
-			best, _, _ := domain.BestOfSeven(append(append([]domain.Card{}, heroBoard...), heroHole...))
+			best, _, _ := domain.BestHand(domain.Variant(input.Variant), heroHole, heroBoard)
 			heroCategoryRank = best.Category
 		}
 		pairScore = pairStrengthScore(heroHole, heroBoard)
@@ -4368,19 +4558,17 @@ func allowedActionsForPlayer(game *domain.GameState, p *domain.GamePlayer) ([]st
 		allowed = append(allowed, "call")
 	}
 	minBet := 0
-	if game.RoundBet == 0 && p.Stack >= game.OpenBetMin {
-		allowed = append(allowed, "bet")
-		minBet = game.OpenBetMin
-	}
 	minRaise := 0
-	if game.RoundBet > 0 {
-		need := diff + game.BetMin
-		if p.Stack >= need {
-			allowed = append(allowed, "bet")
+	need := game.MinRaiseFor(p)
+	if need > 0 && need <= game.MaxRaiseFor(p) {
+		allowed = append(allowed, "bet")
+		if game.RoundBet == 0 {
+			minBet = need
+		} else {
 			minRaise = need
 		}
 	}
-	if p.Stack > 0 {
+	if game.CanAllIn(p) {
 		allowed = append(allowed, "allin")
 	}
 	allowed = append(allowed, "fold")
@@ -4540,10 +4728,8 @@ func (m *MemoryStore) enqueueAIDecisionLockedWithRetry(room *Room, retriesLeft i
 	if !ok {
 		return
 	}
-	fallback := fallbackDecision(input)
-	baseline := fallback
-	input.BaselineDecision = &baseline
-	input.DecisionOptions = buildDecisionOptions(input, currentStrategyParams(), baseline)
+	// The fallback decision runs the equity estimate, so the worker works it
+	// out once the store lock is released.
 	task := &aiDecisionTask{
 		RoomID:          room.RoomID,
 		HandID:          room.HandCounter,
@@ -4551,7 +4737,6 @@ func (m *MemoryStore) enqueueAIDecisionLockedWithRetry(room *Room, retriesLeft i
 		AIUserID:        turn.UserID,
 		ActionID:        fmt.Sprintf("ai-%s-%d", turn.UserID, room.StateVersion),
 		Input:           input,
-		Fallback:        fallback,
 		RetriesLeft:     retriesLeft,
 	}
 	m.aiWorkers[room.RoomID] = true
@@ -4610,6 +4795,7 @@ func (m *MemoryStore) aiEventLoop() {
 			if task.decide == nil {
 				continue
 			}
+			task.decide.prepare()
 			decision := task.decide.Fallback
 			service := m.currentAIService()
 			if service != nil && service.Enabled() {
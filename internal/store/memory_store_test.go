@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +12,47 @@ import (
 	"texas_yu/internal/domain"
 )
 
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newClockedStore() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	return NewMemoryStore(Options{Now: clock.Now}), clock
+}
+
+// startRoom seats players, the owner first, at a room with rules and deals
+// the first hand.
+func startRoom(t *testing.T, rules RoomRules, players int) (*MemoryStore, *fakeClock, *Session, *Room) {
+	t.Helper()
+	s, clock := newClockedStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "room", 10, 10, rules)
+	for i := 1; i < players; i++ {
+		if _, err := s.JoinRoom(room.RoomID, s.CreateSession(fmt.Sprintf("p%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, clock, owner, r
+}
+
 type stubAIService struct {
 	decisionFn  func(ctx context.Context, input ai.DecisionInput) (ai.Decision, error)
 	summaryFn   func(ctx context.Context, input ai.SummaryInput) (ai.Summary, error)
@@ -467,6 +510,54 @@ func TestStore_LeaveRoomAndNextHand(t *testing.T) {
 	}
 }
 
+func TestStore_LeaveMidHandKeepsChipsInPot(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "r", 10, 10)
+	for _, name := range []string{"p1", "p2"} {
+		if _, err := s.JoinRoom(room.RoomID, s.CreateSession(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for r.Game.Stage == domain.StagePreflop {
+		r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+	}
+	left := 0
+	for _, gp := range r.Game.Players {
+		if gp.UserID == owner.UserID {
+			left = gp.Stack
+		}
+	}
+	if _, err := s.LeaveRoom(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = s.GetRoom(r.RoomID)
+	for r.Game.Stage != domain.StageFinished {
+		r = callOrCheck(t, s, r, r.Game.Players[r.Game.TurnPos].UserID)
+	}
+	if r.Game.Result.Reason != "showdown" {
+		t.Fatalf("expected the hand to reach showdown, got %s", r.Game.Result.Reason)
+	}
+	pots := 0
+	for _, pot := range r.Game.Pots {
+		pots += pot.Amount
+	}
+	if pots != r.Game.Pot {
+		t.Fatalf("expected the pots to hold all %d chips put in, got %d", r.Game.Pot, pots)
+	}
+	total := left
+	for _, p := range r.Players {
+		total += p.Stack
+	}
+	if total != 3*DefaultPlayerStack {
+		t.Fatalf("expected no chips lost when a player leaves mid-hand, got %d", total)
+	}
+}
+
 func TestStore_AddRemoveAIOwnerOnlyAndState(t *testing.T) {
 	s := NewMemoryStore()
 	owner := s.CreateSession("owner")
@@ -1233,6 +1324,43 @@ func TestStore_ProfileStrategyAdjustments_RecognizesChineseDescriptors(t *testin
 	}
 }
 
+func TestMonteCarloTrialCount(t *testing.T) {
+	cases := []struct {
+		stage     string
+		opponents int
+		want      int
+	}{
+		{"preflop", 1, 3400},
+		{"flop", 1, 3000},
+		{"flop", 2, 2600},
+		{"turn", 3, 2200},
+		{"river", 5, 1300},
+		{"preflop", 0, 3400},
+		{"", 4, 1800},
+	}
+	for _, c := range cases {
+		if got := monteCarloTrialCount(c.stage, c.opponents); got != c.want {
+			t.Fatalf("%s with %d opponents: expected %d trials, got %d", c.stage, c.opponents, c.want, got)
+		}
+	}
+}
+
+func BenchmarkEstimateMonteCarloEquity_Flop(b *testing.B) {
+	input := ai.DecisionInput{
+		AIUserID:       "ai-1",
+		Stage:          "flop",
+		HoleCards:      []string{"AH", "QH"},
+		CommunityCards: []string{"AS", "7D", "2C"},
+		Players: []ai.PlayerSnapshot{
+			{UserID: "ai-1"},
+			{UserID: "villain", LastAction: "bet"},
+		},
+	}
+	for i := 0; i < b.N; i++ {
+		estimateMonteCarloEquity(input)
+	}
+}
+
 func TestStore_EstimateMonteCarloEquity_UsesVisibleRangeSignals(t *testing.T) {
 	base := ai.DecisionInput{
 		AIUserID:       "ai-1",
@@ -2542,6 +2670,13 @@ func TestStore_BuildAIDecisionInput_IncludesOpponentRangeHints(t *testing.T) {
 	if hint.Confidence <= 0 || hint.FoldToPressure <= 0 {
 		t.Fatalf("expected numeric range fields, got %#v", hint)
 	}
+	preflopRange, err := domain.ParseRange(hint.PreflopRange)
+	if err != nil {
+		t.Fatalf("expected standard range notation, got %q: %v", hint.PreflopRange, err)
+	}
+	if full := preflopRange.Combos(); hint.RangeCombos <= 0 || hint.RangeCombos >= full {
+		t.Fatalf("expected visible cards to block part of the %v combos, got %v", full, hint.RangeCombos)
+	}
 }
 
 func TestStore_MaterializeDecisionOption_UsesOptionIDAndSnapsBetSize(t *testing.T) {
@@ -2607,3 +2742,203 @@ func TestStore_EnqueueAISummaryLocked_UpdatesOpponentStatsWithoutLLM(t *testing.
 		t.Fatalf("expected no llm summary when disabled, got lastSummarizedHand=%d", mem.LastSummarizedHand)
 	}
 }
+
+func TestStore_AllowedActions_ShortAllInClosesRaiseForPlayersWhoActed(t *testing.T) {
+	players := []*domain.GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+		{UserID: "u3", Username: "C", SeatIndex: 2, Stack: 150},
+	}
+	g, err := domain.NewGame(players, 0, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed, _, _, minRaise := allowedActionsForPlayer(g, players[0])
+	if !containsAction(allowed, "bet") || minRaise != 40 {
+		t.Fatalf("expected opener to raise with min commit 40, got %v / %d", allowed, minRaise)
+	}
+	for _, step := range []struct {
+		uid, action string
+		amount      int
+	}{
+		{"u1", "bet", 100},
+		{"u2", "call", 0},
+		{"u3", "allin", 0},
+	} {
+		if err := g.ApplyAction(step.uid, step.action, step.amount); err != nil {
+			t.Fatal(err)
+		}
+	}
+	allowed, callAmount, _, minRaise := allowedActionsForPlayer(g, players[0])
+	if containsAction(allowed, "bet") || containsAction(allowed, "allin") || minRaise != 0 {
+		t.Fatalf("expected only call/fold after short all-in, got %v minRaise=%d", allowed, minRaise)
+	}
+	if !containsAction(allowed, "call") || callAmount != 50 {
+		t.Fatalf("expected call of 50, got %v / %d", allowed, callAmount)
+	}
+}
+
+func TestStore_AddAIRefusedInPLORoom(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	for _, variant := range []domain.Variant{domain.VariantPLO, domain.VariantPLO8} {
+		room := s.CreateRoom(owner, "room", 10, 10, RoomRules{Variant: variant})
+		if _, _, err := s.AddAI(room.RoomID, owner.UserID, "bot"); err == nil {
+			t.Fatalf("expected ai to be refused in %s room", variant)
+		}
+	}
+}
+
+func TestStore_AIManagedRefusedInPLORoom(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	for _, variant := range []domain.Variant{domain.VariantPLO, domain.VariantPLO8} {
+		room := s.CreateRoom(owner, "room", 10, 10, RoomRules{Variant: variant})
+		if _, err := s.SetPlayerAIManaged(room.RoomID, owner.UserID, true); err == nil {
+			t.Fatalf("expected ai management to be refused in %s room", variant)
+		}
+		if _, err := s.SetPlayerAIManaged(room.RoomID, owner.UserID, false); err != nil {
+			t.Fatalf("expected turning ai management off to stay allowed in %s room: %v", variant, err)
+		}
+	}
+}
+
+func TestStore_EstimateMonteCarloEquity_ShortDeckRanksAndDeck(t *testing.T) {
+	input := ai.DecisionInput{
+		AIUserID:       "ai-1",
+		Variant:        string(domain.VariantShortDeck),
+		Stage:          "river",
+		HoleCards:      []string{"AS", "9D"},
+		CommunityCards: []string{"6C", "7D", "8S", "KH", "KD"},
+		Players: []ai.PlayerSnapshot{
+			{UserID: "ai-1"},
+			{UserID: "p-1", Folded: false},
+		},
+	}
+	shortEq, ok := estimateMonteCarloEquity(input)
+	if !ok {
+		t.Fatalf("expected monte carlo equity to be available")
+	}
+	if shortEq < 0.85 {
+		t.Fatalf("A-6-7-8-9 is a straight in short deck, expected strong equity, got %.4f", shortEq)
+	}
+	input.Variant = string(domain.VariantHoldem)
+	holdemEq, ok := estimateMonteCarloEquity(input)
+	if !ok {
+		t.Fatalf("expected monte carlo equity to be available")
+	}
+	if holdemEq >= shortEq {
+		t.Fatalf("expected ace-high to be weaker in hold'em: holdem=%.4f short=%.4f", holdemEq, shortEq)
+	}
+}
+
+func TestStore_FixedLimitAllowedActionsAndOptionsUseFixedSize(t *testing.T) {
+	players := []*domain.GamePlayer{
+		{UserID: "u1", Username: "A", SeatIndex: 0, Stack: 1000},
+		{UserID: "u2", Username: "B", SeatIndex: 1, Stack: 1000},
+	}
+	g, err := domain.NewGame(players, 0, 20, 20, domain.GameOptions{FixedLimit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed, _, _, minRaise := allowedActionsForPlayer(g, players[0])
+	if !containsAction(allowed, "bet") || containsAction(allowed, "allin") || minRaise != 30 {
+		t.Fatalf("expected limit raise of 30 and no shove, got %v / %d", allowed, minRaise)
+	}
+
+	input := ai.DecisionInput{
+		RoomID:           "limit-1",
+		AIUserID:         "ai-1",
+		Stage:            "river",
+		AllowedActions:   []string{"check", "bet", "fold"},
+		MinBet:           40,
+		MaxRaise:         40,
+		BettingStructure: "fixed_limit",
+		Stack:            700,
+		Pot:              160,
+		HoleCards:        []string{"AS", "AH"},
+		CommunityCards:   []string{"2C", "2D", "9H", "TS", "KD"},
+		HandCategory:     "two_pair",
+		HandCategoryRank: 2,
+		MadeHandStrength: "strong",
+		DrawFlags:        []string{"none"},
+		Players: []ai.PlayerSnapshot{
+			{UserID: "ai-1"},
+			{UserID: "p-1", Folded: false},
+		},
+	}
+	options := buildDecisionOptions(input, currentStrategyParams(), ai.Decision{Action: "check"})
+	foundBet := false
+	for _, option := range options {
+		if option.Action != "bet" {
+			continue
+		}
+		foundBet = true
+		if option.Amount != 40 {
+			t.Fatalf("expected every limit bet option to be 40, got %#v", option)
+		}
+	}
+	if !foundBet {
+		t.Fatalf("expected a bet option, got %#v", options)
+	}
+}
+
+func TestStore_RunItTwiceThroughActions(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	room := s.CreateRoom(owner, "rit", 10, 10, RoomRules{RunItTwice: true})
+	if _, err := s.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	started, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := started.Game.Players[started.Game.TurnPos].UserID
+	second := owner.UserID
+	if first == owner.UserID {
+		second = guest.UserID
+	}
+	cur, err := s.ApplyAction(room.RoomID, first, "", "allin", 0, started.StateVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur, err = s.ApplyAction(room.RoomID, second, "", "call", 0, cur.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	if !cur.Game.RunoutPending {
+		t.Fatalf("expected room to wait for the runout agreement")
+	}
+	if cur, err = s.ApplyAction(room.RoomID, second, "", domain.ActionRunIt, 2, cur.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	if cur, err = s.ApplyAction(room.RoomID, first, "", domain.ActionRunIt, 2, cur.StateVersion); err != nil {
+		t.Fatal(err)
+	}
+	if cur.Status != RoomWaiting || cur.Game.Stage != domain.StageFinished || len(cur.Game.Runouts) != 2 {
+		t.Fatalf("expected finished hand with two runouts, status=%s stage=%s runouts=%d", cur.Status, cur.Game.Stage, len(cur.Game.Runouts))
+	}
+	total := 0
+	for _, p := range cur.Players {
+		total += p.Stack
+	}
+	if total != DefaultPlayerStack*2 {
+		t.Fatalf("expected chips to be conserved across runouts, got %d", total)
+	}
+}
+
+func TestCloneGame_CopiesHiLoWinners(t *testing.T) {
+	pot := domain.Pot{Amount: 100, EligibleIDs: []string{"u1", "u2"}, WinnerIDs: []string{"u1"}, LowWinnerIDs: []string{"u2"}}
+	g := &domain.GameState{
+		Pots:    []domain.Pot{pot},
+		Runouts: []domain.Runout{{Pots: []domain.Pot{pot}, Hands: map[string]string{}, Lows: map[string]string{"u2": "7-5-3-2-A"}}},
+	}
+	c := cloneGame(g)
+	c.Pots[0].LowWinnerIDs[0] = "u1"
+	c.Runouts[0].Pots[0].LowWinnerIDs[0] = "u1"
+	c.Runouts[0].Lows["u2"] = ""
+	if g.Pots[0].LowWinnerIDs[0] != "u2" || g.Runouts[0].Pots[0].LowWinnerIDs[0] != "u2" || g.Runouts[0].Lows["u2"] != "7-5-3-2-A" {
+		t.Fatalf("expected the clone not to share hi-lo winners with the game")
+	}
+}
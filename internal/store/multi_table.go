@@ -0,0 +1,444 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+// Table sizes a multi-table tournament may seat. With at least three seats a
+// table, dealing the field round robin never leaves a player alone at one.
+const (
+	MinTournamentTableSize = 3
+	MaxTournamentTableSize = 10
+)
+
+const (
+	// TournamentRegistering is a multi-table tournament still taking
+	// players; TournamentCancelled one that reached its start time without
+	// enough of them.
+	TournamentRegistering TournamentStatus = "registering"
+	TournamentCancelled   TournamentStatus = "cancelled"
+)
+
+var errMultiTableNotFound = errors.New("tournament not found")
+
+// ValidateMultiTable reports the first problem with the rules for a
+// multi-table tournament seating tableSize players a table. Levels must run
+// on the clock so that every table moves up together.
+func (t TournamentRules) ValidateMultiTable(tableSize int) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if tableSize < MinTournamentTableSize || tableSize > MaxTournamentTableSize {
+		return fmt.Errorf("table size must be between %d and %d", MinTournamentTableSize, MaxTournamentTableSize)
+	}
+	if t.LevelHands > 0 || (len(t.Levels) > 1 && t.LevelMinutes == 0) {
+		return errors.New("multi-table levels must be timed in minutes")
+	}
+	return nil
+}
+
+// TournamentEntrant is a player registered for a multi-table tournament.
+type TournamentEntrant struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+}
+
+// TournamentTable is one table of a multi-table tournament still in play.
+type TournamentTable struct {
+	RoomID  string `json:"roomId"`
+	Table   int    `json:"table"`
+	Players int    `json:"players"`
+}
+
+// TournamentFieldEntry is a player still in a multi-table tournament and the
+// table they sit at.
+type TournamentFieldEntry struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Stack    int    `json:"stack"`
+	RoomID   string `json:"roomId"`
+	Table    int    `json:"table"`
+}
+
+// MultiTableTournament is a tournament the store runs over several rooms.
+// Players register in the lobby and are seated across tables when it starts,
+// either at StartAtMs or when the owner starts it. As players bust, others
+// are moved to keep the tables within one player of each other and tables
+// are broken once the rest can seat the field, down to the final table.
+type MultiTableTournament struct {
+	TournamentID string          `json:"tournamentId"`
+	Name         string          `json:"name"`
+	OwnerUserID  string          `json:"ownerUserId"`
+	Rules        TournamentRules `json:"rules"`
+	TableSize    int             `json:"tableSize"`
+	// StartAtMs is the scheduled start, 0 when the owner starts it.
+	StartAtMs  int64               `json:"startAtMs"`
+	Registered []TournamentEntrant `json:"registered"`
+	// State is the level, eliminations and results shared by every table.
+	State            *TournamentState  `json:"state"`
+	Tables           []TournamentTable `json:"tables"`
+	FinalTableRoomID string            `json:"finalTableRoomId,omitempty"`
+	// Field lists the players still in, biggest stack first.
+	Field         []TournamentFieldEntry `json:"field"`
+	Version       int64                  `json:"version"`
+	CreatedAtUnix int64                  `json:"createdAtUnix"`
+}
+
+// CreateMultiTableTournament opens registration for a tournament that seats
+// tableSize players a table. The rules must pass ValidateMultiTable.
+func (m *MemoryStore) CreateMultiTableTournament(owner *Session, name string, rules TournamentRules, tableSize int, startAtMs int64) (*MultiTableTournament, error) {
+	if err := rules.ValidateMultiTable(tableSize); err != nil {
+		return nil, err
+	}
+	id := atomic.AddInt64(&m.nextTournament, 1)
+	mt := &MultiTableTournament{
+		TournamentID:  fmt.Sprintf("t-%d", id),
+		Name:          name,
+		OwnerUserID:   owner.UserID,
+		Rules:         *rules.normalized(),
+		TableSize:     tableSize,
+		StartAtMs:     startAtMs,
+		Registered:    []TournamentEntrant{},
+		State:         &TournamentState{Status: TournamentRegistering, Eliminations: []TournamentPlace{}},
+		Tables:        []TournamentTable{},
+		Version:       1,
+		CreatedAtUnix: time.Now().Unix(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tournaments[mt.TournamentID] = mt
+	return m.viewTournamentLocked(mt), nil
+}
+
+// ListMultiTableTournaments returns every multi-table tournament, newest
+// first.
+func (m *MemoryStore) ListMultiTableTournaments() []MultiTableTournament {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]MultiTableTournament, 0, len(m.tournaments))
+	for _, mt := range m.tournaments {
+		list = append(list, *m.viewTournamentLocked(mt))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAtUnix != list[j].CreatedAtUnix {
+			return list[i].CreatedAtUnix > list[j].CreatedAtUnix
+		}
+		return list[i].TournamentID > list[j].TournamentID
+	})
+	return list
+}
+
+// GetMultiTableTournament returns a snapshot of the tournament with its
+// tables and remaining field.
+func (m *MemoryStore) GetMultiTableTournament(tournamentID string) (*MultiTableTournament, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mt, ok := m.tournaments[tournamentID]
+	if !ok {
+		return nil, false
+	}
+	return m.viewTournamentLocked(mt), true
+}
+
+// RegisterTournament enters s into a tournament that has not started yet.
+func (m *MemoryStore) RegisterTournament(tournamentID string, s *Session) (*MultiTableTournament, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.tournaments[tournamentID]
+	if !ok {
+		return nil, errMultiTableNotFound
+	}
+	if mt.State.Status != TournamentRegistering {
+		return nil, errors.New("registration is closed")
+	}
+	if registrationIndex(mt, s.UserID) < 0 {
+		mt.Registered = append(mt.Registered, TournamentEntrant{UserID: s.UserID, Username: s.Username})
+		mt.Version++
+	}
+	return m.viewTournamentLocked(mt), nil
+}
+
+// UnregisterTournament takes userID out of a tournament that has not started.
+func (m *MemoryStore) UnregisterTournament(tournamentID, userID string) (*MultiTableTournament, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.tournaments[tournamentID]
+	if !ok {
+		return nil, errMultiTableNotFound
+	}
+	if mt.State.Status != TournamentRegistering {
+		return nil, errors.New("registration is closed")
+	}
+	idx := registrationIndex(mt, userID)
+	if idx < 0 {
+		return nil, errors.New("user not registered")
+	}
+	mt.Registered = append(mt.Registered[:idx], mt.Registered[idx+1:]...)
+	mt.Version++
+	return m.viewTournamentLocked(mt), nil
+}
+
+// StartMultiTableTournament lets the owner start the tournament before its
+// scheduled time.
+func (m *MemoryStore) StartMultiTableTournament(tournamentID, userID string) (*MultiTableTournament, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mt, ok := m.tournaments[tournamentID]
+	if !ok {
+		return nil, errMultiTableNotFound
+	}
+	if mt.OwnerUserID != userID {
+		return nil, errors.New("only owner can start")
+	}
+	if mt.State.Status != TournamentRegistering {
+		return nil, errTournamentStarted
+	}
+	if len(mt.Registered) < 2 {
+		return nil, errors.New("at least 2 players needed")
+	}
+	m.startMultiTableLocked(mt)
+	return m.viewTournamentLocked(mt), nil
+}
+
+func registrationIndex(mt *MultiTableTournament, userID string) int {
+	for i, e := range mt.Registered {
+		if e.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MemoryStore) startMultiTableLocked(mt *MultiTableTournament) {
+	now := m.Now()
+	entrants := len(mt.Registered)
+	count := (entrants + mt.TableSize - 1) / mt.TableSize
+	mt.State = &TournamentState{
+		Status:           TournamentRunning,
+		Level:            1,
+		LevelStartedAtMs: now.UnixMilli(),
+		Entrants:         entrants,
+		PrizePool:        mt.Rules.BuyIn * entrants,
+		Eliminations:     []TournamentPlace{},
+	}
+	mt.State.setLevel(&mt.Rules)
+	tables := make([]*Room, count)
+	for i, e := range mt.Registered {
+		n := i % count
+		p := RoomPlayer{UserID: e.UserID, Username: e.Username, Stack: mt.Rules.StartingStack}
+		if tables[n] == nil {
+			rules := mt.Rules
+			r := m.newRoom(&Session{UserID: e.UserID, Username: e.Username}, fmt.Sprintf("%s #%d", mt.Name, n+1), 0, 0, RoomRules{TournamentRules: &rules})
+			r.Players[0].Stack = p.Stack
+			r.Tournament = &TournamentState{TournamentID: mt.TournamentID, Table: n + 1}
+			tables[n] = r
+			continue
+		}
+		takeSeatLocked(tables[n], p)
+	}
+	mt.Tables = make([]TournamentTable, 0, count)
+	for _, r := range tables {
+		m.rooms[r.RoomID] = r
+		mt.Tables = append(mt.Tables, TournamentTable{RoomID: r.RoomID, Table: r.Tournament.Table})
+		mt.syncTableLocked(r)
+		_ = m.dealHandLocked(r, nil)
+	}
+	m.roomsVersion++
+	m.markFinalTableLocked(mt, tables)
+	mt.Version++
+}
+
+func (m *MemoryStore) startDueTournamentsLocked(nowMs int64) {
+	for _, mt := range m.tournaments {
+		if mt.State.Status != TournamentRegistering || mt.StartAtMs == 0 || nowMs < mt.StartAtMs {
+			continue
+		}
+		if len(mt.Registered) < 2 {
+			mt.State.Status = TournamentCancelled
+			mt.Version++
+			continue
+		}
+		m.startMultiTableLocked(mt)
+	}
+}
+
+func (m *MemoryStore) tournamentTablesLocked(mt *MultiTableTournament) []*Room {
+	rooms := make([]*Room, 0, len(mt.Tables))
+	kept := mt.Tables[:0]
+	for _, t := range mt.Tables {
+		if r, ok := m.rooms[t.RoomID]; ok {
+			rooms = append(rooms, r)
+			kept = append(kept, t)
+		}
+	}
+	mt.Tables = kept
+	return rooms
+}
+
+func (m *MemoryStore) rebalanceTournamentLocked(mt *MultiTableTournament) {
+	if mt.State.Status != TournamentRunning {
+		return
+	}
+	tables := m.tournamentTablesLocked(mt)
+	remaining := 0
+	for _, r := range tables {
+		remaining += len(r.Players)
+	}
+	if remaining <= 1 {
+		var left []RoomPlayer
+		for _, r := range tables {
+			left = append(left, r.Players...)
+		}
+		finishTournamentLocked(mt.State, &mt.Rules, left)
+		m.syncTablesLocked(mt, tables)
+		return
+	}
+
+	for len(tables) > 1 && remaining <= (len(tables)-1)*mt.TableSize {
+		broken := -1
+		for i, r := range tables {
+			if betweenHands(r) && (broken < 0 || len(r.Players) < len(tables[broken].Players)) {
+				broken = i
+			}
+		}
+		if broken < 0 {
+			break
+		}
+		r := tables[broken]
+		tables = append(tables[:broken:broken], tables[broken+1:]...)
+		for len(r.Players) > 0 {
+			m.moveTournamentPlayerLocked(r, shortestTable(tables), 0)
+		}
+		// Players who busted here keep watching from another table.
+		watch := shortestTable(tables)
+		watch.Spectators = append(watch.Spectators, r.Spectators...)
+		m.closeTournamentTableLocked(mt, r)
+	}
+
+	for len(tables) > 1 {
+		short := shortestTable(tables)
+		var long *Room
+		for _, r := range tables {
+			if betweenHands(r) && len(r.Players) >= len(short.Players)+2 && (long == nil || len(r.Players) > len(long.Players)) {
+				long = r
+			}
+		}
+		if long == nil {
+			break
+		}
+		m.moveTournamentPlayerLocked(long, short, nextBigBlindIndex(long))
+	}
+
+	m.markFinalTableLocked(mt, tables)
+	m.syncTablesLocked(mt, tables)
+}
+
+func betweenHands(r *Room) bool {
+	return r.Game == nil || r.Game.Stage == domain.StageFinished
+}
+
+func shortestTable(tables []*Room) *Room {
+	var short *Room
+	for _, r := range tables {
+		if short == nil || len(r.Players) < len(short.Players) {
+			short = r
+		}
+	}
+	return short
+}
+
+// Moving the player due the big blind means nobody skips one.
+func nextBigBlindIndex(r *Room) int {
+	if idx := seatedAfter(r, r.BigBlindSeat, func(int) bool { return true }); idx >= 0 {
+		return idx
+	}
+	return 0
+}
+
+func (m *MemoryStore) moveTournamentPlayerLocked(from, to *Room, idx int) {
+	p := from.Players[idx]
+	removeSeatLocked(from, idx)
+	if from.OwnerUserID == p.UserID {
+		if owner := firstHumanOwner(from.Players); owner != "" {
+			from.OwnerUserID = owner
+		}
+	}
+	takeSeatLocked(to, RoomPlayer{UserID: p.UserID, Username: p.Username, Stack: p.Stack, IsAI: p.IsAI, AIManaged: p.AIManaged})
+	if t := to.Tournament; betweenHands(to) && t.NextHandAtMs == 0 {
+		t.NextHandAtMs = m.Now().Add(time.Duration(to.TournamentRules.HandPauseSeconds) * time.Second).UnixMilli()
+	}
+	for _, r := range []*Room{from, to} {
+		r.StateVersion++
+		r.UpdatedAtUnix = time.Now().Unix()
+	}
+	m.roomsVersion++
+}
+
+func (m *MemoryStore) closeTournamentTableLocked(mt *MultiTableTournament, r *Room) {
+	delete(m.rooms, r.RoomID)
+	delete(m.aiWorkers, r.RoomID)
+	m.roomsVersion++
+	m.tournamentTablesLocked(mt)
+}
+
+func (m *MemoryStore) markFinalTableLocked(mt *MultiTableTournament, tables []*Room) {
+	if len(tables) == 1 && mt.FinalTableRoomID == "" {
+		mt.FinalTableRoomID = tables[0].RoomID
+		tables[0].Name = mt.Name + " final table"
+		m.roomsVersion++
+	}
+}
+
+func (m *MemoryStore) syncTablesLocked(mt *MultiTableTournament, tables []*Room) {
+	for _, r := range tables {
+		mt.syncTableLocked(r)
+		r.StateVersion++
+		r.UpdatedAtUnix = time.Now().Unix()
+	}
+	mt.Version++
+	m.roomsVersion++
+}
+
+// A table mid-hand finishes it at the old level.
+func (mt *MultiTableTournament) syncTableLocked(r *Room) {
+	t, s := r.Tournament, mt.State
+	t.Status = s.Status
+	t.Entrants, t.PrizePool = s.Entrants, s.PrizePool
+	t.Eliminations = append([]TournamentPlace{}, s.Eliminations...)
+	t.Results = nil
+	if s.Results != nil {
+		t.Results = append([]TournamentPlace(nil), s.Results...)
+	}
+	if t.Status != TournamentRunning {
+		t.NextHandAtMs = 0
+		t.NextLevelAtMs = 0
+	}
+}
+
+func (m *MemoryStore) viewTournamentLocked(mt *MultiTableTournament) *MultiTableTournament {
+	out := *mt
+	out.Registered = append([]TournamentEntrant(nil), mt.Registered...)
+	out.State = cloneTournament(mt.State)
+	out.Tables = make([]TournamentTable, 0, len(mt.Tables))
+	out.Field = []TournamentFieldEntry{}
+	for _, t := range mt.Tables {
+		r, ok := m.rooms[t.RoomID]
+		if !ok {
+			continue
+		}
+		t.Players = len(r.Players)
+		out.Tables = append(out.Tables, t)
+		for _, p := range r.Players {
+			out.Field = append(out.Field, TournamentFieldEntry{UserID: p.UserID, Username: p.Username, Stack: p.Stack, RoomID: r.RoomID, Table: t.Table})
+		}
+	}
+	sort.SliceStable(out.Field, func(i, j int) bool { return out.Field[i].Stack > out.Field[j].Stack })
+	return &out
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+func newMultiTableTournament(t *testing.T, rules TournamentRules, tableSize, players int) (*MemoryStore, *fakeClock, *MultiTableTournament) {
+	t.Helper()
+	s, clock := newClockedStore()
+	owner := s.CreateSession("director")
+	mt, err := s.CreateMultiTableTournament(owner, "mtt", rules, tableSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < players; i++ {
+		if _, err := s.RegisterTournament(mt.TournamentID, s.CreateSession(fmt.Sprintf("p%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if mt, err = s.StartMultiTableTournament(mt.TournamentID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	return s, clock, mt
+}
+
+func TestStore_MultiTableTournamentPlaysDownToAFinalTable(t *testing.T) {
+	s, clock, mt := newMultiTableTournament(t, TournamentRules{
+		StartingStack: 100,
+		Levels:        []BlindLevel{{BigBlind: 10}, {BigBlind: 20, Ante: 5}},
+		LevelMinutes:  1,
+		BuyIn:         10,
+		Payouts:       []int{50, 30, 20},
+	}, 4, 10)
+	if len(mt.Tables) != 3 || len(mt.Field) != 10 || mt.State.PrizePool != 100 {
+		t.Fatalf("expected ten players over three tables, got %+v", mt)
+	}
+	for _, table := range mt.Tables {
+		if table.Players < 3 || table.Players > 4 {
+			t.Fatalf("expected balanced tables, got %+v", mt.Tables)
+		}
+	}
+
+	for round := 0; ; round++ {
+		if round > 300 {
+			t.Fatalf("expected the tournament to end")
+		}
+		mt, _ = s.GetMultiTableTournament(mt.TournamentID)
+		if mt.State.Status == TournamentFinished {
+			break
+		}
+		for _, table := range mt.Tables {
+			r, _ := s.GetRoom(table.RoomID)
+			if r.Game.Stage != domain.StageFinished {
+				shoveCurrentHand(t, s, r)
+			}
+		}
+
+		// With every table between hands the field is balanced and no more
+		// tables are open than it needs.
+		mt, _ = s.GetMultiTableTournament(mt.TournamentID)
+		total, smallest, largest := 0, 100, 0
+		for _, table := range mt.Tables {
+			r, _ := s.GetRoom(table.RoomID)
+			for _, p := range r.Players {
+				total += p.Stack
+			}
+			smallest, largest = min(smallest, len(r.Players)), max(largest, len(r.Players))
+		}
+		if total != 1000 {
+			t.Fatalf("expected the field to hold all 1000 chips, got %d", total)
+		}
+		if largest-smallest > 1 || largest > 4 || (len(mt.Tables)-1)*4 >= len(mt.Field) && len(mt.Tables) > 1 {
+			t.Fatalf("expected balanced tables, got %+v", mt.Tables)
+		}
+		if len(mt.Tables) == 1 && mt.FinalTableRoomID != mt.Tables[0].RoomID {
+			t.Fatalf("expected the last table marked as the final table, got %+v", mt)
+		}
+
+		clock.Advance(DefaultHandPauseSeconds * time.Second)
+		s.dealTournamentHands()
+		if round == 0 {
+			clock.Advance(time.Minute)
+		}
+	}
+
+	res := mt.State.Results
+	if len(res) != 10 || len(mt.State.Eliminations) != 9 || len(mt.Field) != 1 {
+		t.Fatalf("expected ten places, got %+v", mt.State)
+	}
+	seen := map[string]bool{}
+	for i, want := range []int{50, 30, 20, 0} {
+		if res[i].Place != i+1 || res[i].Prize != want {
+			t.Fatalf("place %d: expected prize %d, got %+v", i+1, want, res[i])
+		}
+	}
+	for _, place := range res {
+		if seen[place.UserID] {
+			t.Fatalf("expected every player placed once, got %+v", res)
+		}
+		seen[place.UserID] = true
+	}
+	final, _ := s.GetRoom(mt.FinalTableRoomID)
+	if final.Tournament.Status != TournamentFinished || len(final.Tournament.Results) != 10 {
+		t.Fatalf("expected the final table to show the results, got %+v", final.Tournament)
+	}
+}
+
+func TestStore_MultiTableTournamentLevelsStayInSync(t *testing.T) {
+	s, clock, mt := newMultiTableTournament(t, TournamentRules{
+		StartingStack: 1000,
+		Levels:        []BlindLevel{{BigBlind: 10}, {BigBlind: 20}, {BigBlind: 40}},
+		LevelMinutes:  2,
+	}, 3, 6)
+	first, _ := s.GetRoom(mt.Tables[0].RoomID)
+	foldCurrentHand(t, s, first)
+
+	// The first table deals on the new level; the second is still playing
+	// its hand at the old blinds and moves up when it deals the next one.
+	clock.Advance(2*time.Minute + DefaultHandPauseSeconds*time.Second)
+	s.dealTournamentHands()
+	first, _ = s.GetRoom(mt.Tables[0].RoomID)
+	second, _ := s.GetRoom(mt.Tables[1].RoomID)
+	if first.Tournament.Level != 2 || first.OpenBetMin != 20 || second.OpenBetMin != 10 {
+		t.Fatalf("expected only the table between hands to move up, got %d and %d", first.OpenBetMin, second.OpenBetMin)
+	}
+	foldCurrentHand(t, s, second)
+	clock.Advance(DefaultHandPauseSeconds * time.Second)
+	s.dealTournamentHands()
+	second, _ = s.GetRoom(mt.Tables[1].RoomID)
+	if second.Tournament.Level != 2 || second.Tournament.LevelStartedAtMs != first.Tournament.LevelStartedAtMs {
+		t.Fatalf("expected both tables on the same level clock, got %+v and %+v", first.Tournament, second.Tournament)
+	}
+	if mt, _ = s.GetMultiTableTournament(mt.TournamentID); mt.State.Level != 2 || mt.State.NextLevelAtMs != second.Tournament.NextLevelAtMs {
+		t.Fatalf("expected the lobby on level 2, got %+v", mt.State)
+	}
+}
+
+func TestStore_MultiTableTournamentMovesPlayersToBalance(t *testing.T) {
+	s, clock, mt := newMultiTableTournament(t, TournamentRules{StartingStack: 500, Levels: []BlindLevel{{BigBlind: 10}}}, 4, 8)
+	short, _ := s.GetRoom(mt.Tables[1].RoomID)
+	// Two players leave the second table, leaving it two players short.
+	for _, p := range short.Players[:2] {
+		if _, err := s.LeaveRoom(short.RoomID, p.UserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mt, _ = s.GetMultiTableTournament(mt.TournamentID)
+	if mt.Tables[0].Players != 4 || mt.Tables[1].Players != 2 {
+		t.Fatalf("expected nobody moved from a table in the middle of a hand, got %+v", mt.Tables)
+	}
+	if got := mt.State.Eliminations; len(got) != 2 || got[0].Place != 8 || got[1].Place != 7 {
+		t.Fatalf("expected the leavers placed across the whole field, got %+v", got)
+	}
+
+	long, _ := s.GetRoom(mt.Tables[0].RoomID)
+	due := long.Players[nextBigBlindIndex(long)].UserID
+	foldCurrentHand(t, s, long)
+	mt, _ = s.GetMultiTableTournament(mt.TournamentID)
+	if mt.Tables[0].Players != 3 || mt.Tables[1].Players != 3 {
+		t.Fatalf("expected a player moved once the hand was over, got %+v", mt.Tables)
+	}
+	for _, p := range mt.Field {
+		if p.UserID == due && p.RoomID != mt.Tables[1].RoomID {
+			t.Fatalf("expected the player due the big blind to move, got %+v", p)
+		}
+	}
+
+	// Once four players are left the second table breaks into the first.
+	clock.Advance(DefaultHandPauseSeconds * time.Second)
+	s.dealTournamentHands()
+	short, _ = s.GetRoom(mt.Tables[1].RoomID)
+	for _, p := range short.Players[:2] {
+		if _, err := s.LeaveRoom(short.RoomID, p.UserID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mt, _ = s.GetMultiTableTournament(mt.TournamentID)
+	if len(mt.Tables) != 1 || mt.Tables[0].Players != 4 || mt.FinalTableRoomID != mt.Tables[0].RoomID {
+		t.Fatalf("expected a single final table of four, got %+v", mt)
+	}
+	if _, ok := s.GetRoom(short.RoomID); ok {
+		t.Fatalf("expected the broken table closed")
+	}
+}
+
+func TestStore_MultiTableTournamentRegistration(t *testing.T) {
+	s, clock := newClockedStore()
+	owner := s.CreateSession("director")
+	rules := TournamentRules{StartingStack: 100, Levels: []BlindLevel{{BigBlind: 10}, {BigBlind: 20}}, LevelMinutes: 5}
+	if _, err := s.CreateMultiTableTournament(owner, "bad", TournamentRules{StartingStack: 100, Levels: rules.Levels, LevelHands: 5}, 6, 0); err == nil {
+		t.Fatalf("expected levels by hands refused for several tables")
+	}
+	startAt := clock.Now().Add(time.Minute).UnixMilli()
+	mt, err := s.CreateMultiTableTournament(owner, "scheduled", rules, 6, startAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := s.CreateSession("alice"), s.CreateSession("bob")
+	for _, p := range []*Session{alice, bob, alice} {
+		if _, err := s.RegisterTournament(mt.TournamentID, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.UnregisterTournament(mt.TournamentID, bob.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartMultiTableTournament(mt.TournamentID, alice.UserID); err == nil {
+		t.Fatalf("expected only the owner to start early")
+	}
+	if _, err := s.StartMultiTableTournament(mt.TournamentID, owner.UserID); err == nil {
+		t.Fatalf("expected a single registration too few to start")
+	}
+	if _, err := s.RegisterTournament(mt.TournamentID, bob); err != nil {
+		t.Fatal(err)
+	}
+
+	s.dealTournamentHands()
+	if mt, _ = s.GetMultiTableTournament(mt.TournamentID); mt.State.Status != TournamentRegistering {
+		t.Fatalf("expected no start before the scheduled time")
+	}
+	clock.Advance(time.Minute)
+	s.dealTournamentHands()
+	mt, _ = s.GetMultiTableTournament(mt.TournamentID)
+	if mt.State.Status != TournamentRunning || len(mt.Tables) != 1 || len(mt.Field) != 2 {
+		t.Fatalf("expected the tournament started on schedule at one table, got %+v", mt)
+	}
+	if _, err := s.RegisterTournament(mt.TournamentID, s.CreateSession("late")); err == nil {
+		t.Fatalf("expected registration closed once started")
+	}
+	if r, _ := s.GetRoom(mt.Tables[0].RoomID); r.Game == nil || r.Tournament.TournamentID != mt.TournamentID {
+		t.Fatalf("expected the first hand dealt at the table, got %+v", r)
+	}
+
+	empty, _ := s.CreateMultiTableTournament(owner, "empty", rules, 6, startAt)
+	s.dealTournamentHands()
+	if empty, _ = s.GetMultiTableTournament(empty.TournamentID); empty.State.Status != TournamentCancelled {
+		t.Fatalf("expected a tournament nobody joined cancelled, got %s", empty.State.Status)
+	}
+}
@@ -5,10 +5,31 @@ import (
 	"strings"
 
 	"texas_yu/internal/ai"
+	"texas_yu/internal/domain"
 )
 
+var preflopBucketRanges = map[string]*domain.Range{
+	"tight_strong_raise": domain.MustParseRange("88+, ATs+, KQs, AQo+"),
+	"polarized_raise":    domain.MustParseRange("22+, A2s+, K9s+, QTs+, JTs, T9s, 98s, 87s, 76s, 65s, ATo+, KJo+"),
+	"wide_call":          domain.MustParseRange("22+, A2s+, K5s+, Q8s+, J8s+, T8s+, 97s+, 86s+, 75s+, 64s+, 54s, A2o+, K9o+, Q9o+, J9o+, T9o"),
+	"condensed_call":     domain.MustParseRange("22-JJ, A9s-AQs, KTs+, QTs+, JTs, T9s, 98s, ATo-AQo, KJo+"),
+	"tight_cap":          domain.MustParseRange("22-JJ, A8s-AJs, KTs+, QTs+, JTs, ATo-AJo, KQo"),
+	"unknown":            domain.MustParseRange("22+, A2s+, K2s+, Q6s+, J7s+, T7s+, 97s+, 86s+, 75s+, 65s, 54s, A2o+, K7o+, Q9o+, J9o+, T9o"),
+}
+
+func visibleCardMask(input ai.DecisionInput) domain.CardMask {
+	var m domain.CardMask
+	for _, raw := range append(append([]string{}, input.HoleCards...), input.CommunityCards...) {
+		if c, ok := parseCardText(raw); ok {
+			m |= c.Mask()
+		}
+	}
+	return m
+}
+
 func buildOpponentRangeHints(input ai.DecisionInput) []ai.OpponentRangeHint {
 	actionSummary := summarizeVisibleActionsByUser(input.RecentActionLog, input.Stage)
+	visible := visibleCardMask(input)
 	hints := make([]ai.OpponentRangeHint, 0, len(input.Players))
 	for _, player := range input.Players {
 		if player.UserID == input.AIUserID || player.Folded {
@@ -41,12 +62,15 @@ func buildOpponentRangeHints(input ai.DecisionInput) []ai.OpponentRangeHint {
 		if drawWeight >= 0.42 {
 			notes = append(notes, "draw_heavy")
 		}
+		preflopRange := preflopBucketRanges[preflopBucket]
 		hints = append(hints, ai.OpponentRangeHint{
 			UserID:          player.UserID,
 			Username:        player.Username,
 			PreflopBucket:   preflopBucket,
 			CurrentLine:     currentLine,
 			LikelyHandClass: likelyHandClass,
+			PreflopRange:    preflopRange.String(),
+			RangeCombos:     preflopRange.Without(visible).Combos(),
 			FoldToPressure:  roundOptionMetric(foldToPressure),
 			TrapRisk:        roundOptionMetric(trapRisk),
 			DrawWeight:      roundOptionMetric(drawWeight),
@@ -0,0 +1,258 @@
+package store
+
+import (
+	"sort"
+
+	"texas_yu/internal/domain"
+)
+
+func takeSeatLocked(r *Room, p RoomPlayer) RoomPlayer {
+	taken := make(map[int]bool, len(r.Players))
+	for _, rp := range r.Players {
+		taken[rp.Seat] = true
+	}
+	p.Seat = 0
+	for taken[p.Seat] {
+		p.Seat++
+	}
+	r.Players = append(r.Players, p)
+	sort.SliceStable(r.Players, func(i, j int) bool { return r.Players[i].Seat < r.Players[j].Seat })
+	return p
+}
+
+func seatedAfter(r *Room, seat int, ok func(pos int) bool) int {
+	n := len(r.Players)
+	start := 0
+	for start < n && r.Players[start].Seat <= seat {
+		start++
+	}
+	for i := 0; i < n; i++ {
+		pos := (start + i) % n
+		if ok(pos) {
+			return pos
+		}
+	}
+	return -1
+}
+
+func seatBetween(seat, from, to int) bool {
+	if from < to {
+		return seat > from && seat < to
+	}
+	return seat > from || seat < to
+}
+
+// button and smallBlind are -1 when dead.
+type handPlan struct {
+	dealt       map[int]bool
+	posts       []domain.BlindPost
+	button      int
+	smallBlind  int
+	bigBlind    int
+	buttonSeat  int
+	sbSeat      int
+	bbSeat      int
+	missedSmall []int
+	missedBig   []int
+}
+
+// Dead button rule: the big blind always moves on to the next player dealt
+// in; the small blind and button follow last hand's blinds and are dead when
+// that player is gone. Newcomers are never dealt in between the button and the
+// small blind.
+func planHand(r *Room, stackOf func(RoomPlayer) int) handPlan {
+	plan := handPlan{dealt: map[int]bool{}, button: -1, smallBlind: -1, bigBlind: -1}
+	waiting := map[int]bool{}
+	regulars := 0
+	for pos, p := range r.Players {
+		if stackOf(p) <= 0 || p.SittingOut {
+			continue
+		}
+		if p.WaitingForHand {
+			waiting[pos] = true
+			continue
+		}
+		plan.dealt[pos] = true
+		regulars++
+	}
+
+	if r.BigBlindSeat < 0 || regulars < 2 {
+		for pos := range waiting {
+			plan.dealt[pos] = true
+		}
+		plan.seatFresh(r)
+		return plan
+	}
+
+	buttonSeat, sbSeat := r.SmallBlindSeat, r.BigBlindSeat
+	plan.bigBlind = seatedAfter(r, sbSeat, func(pos int) bool { return plan.dealt[pos] || waiting[pos] })
+	plan.dealt[plan.bigBlind] = true
+	plan.bbSeat = r.Players[plan.bigBlind].Seat
+	for pos := range waiting {
+		p := r.Players[pos]
+		if plan.dealt[pos] || !p.PostBlind || p.Seat == buttonSeat || p.Seat == sbSeat {
+			continue
+		}
+		if buttonSeat != sbSeat && seatBetween(p.Seat, buttonSeat, sbSeat) {
+			continue
+		}
+		plan.dealt[pos] = true
+		plan.posts = append(plan.posts, domain.BlindPost{UserID: p.UserID, Amount: r.OpenBetMin})
+	}
+
+	for pos, p := range r.Players {
+		if !p.SittingOut || stackOf(p) <= 0 {
+			continue
+		}
+		if seatBetween(p.Seat, sbSeat, plan.bbSeat) {
+			plan.missedBig = append(plan.missedBig, pos)
+		}
+	}
+
+	if len(plan.dealt) == 2 {
+		// Heads-up the button posts the small blind.
+		for pos := range plan.dealt {
+			if pos != plan.bigBlind {
+				plan.button, plan.smallBlind = pos, pos
+			}
+		}
+		plan.buttonSeat = r.Players[plan.button].Seat
+		plan.sbSeat = plan.buttonSeat
+		plan.posts = nil
+		return plan
+	}
+
+	if !seatBetween(plan.bbSeat, sbSeat, buttonSeat) {
+		// The big blind went past the button: the table has changed too much
+		// to move the blinds on, so start a fresh orbit.
+		plan.seatFresh(r)
+		return plan
+	}
+	plan.buttonSeat, plan.sbSeat = buttonSeat, sbSeat
+	for pos := range plan.dealt {
+		switch r.Players[pos].Seat {
+		case buttonSeat:
+			plan.button = pos
+		case sbSeat:
+			plan.smallBlind = pos
+		}
+	}
+	if plan.smallBlind < 0 {
+		for pos, p := range r.Players {
+			if p.Seat == sbSeat && p.SittingOut && stackOf(p) > 0 {
+				plan.missedSmall = append(plan.missedSmall, pos)
+			}
+		}
+	}
+	for pos := range plan.dealt {
+		p := r.Players[pos]
+		if pos == plan.bigBlind || (!p.MissedBigBlind && !p.MissedSmallBlind) {
+			continue
+		}
+		post := domain.BlindPost{UserID: p.UserID}
+		if p.MissedBigBlind {
+			post.Amount = r.OpenBetMin
+		}
+		if p.MissedSmallBlind {
+			post.Dead = domain.SmallBlindFor(r.OpenBetMin)
+		}
+		plan.posts = append(plan.posts, post)
+	}
+	return plan
+}
+
+func (plan *handPlan) seatFresh(r *Room) {
+	plan.button, plan.smallBlind, plan.bigBlind = -1, -1, -1
+	if len(plan.dealt) < 2 {
+		return
+	}
+	isDealt := func(pos int) bool { return plan.dealt[pos] }
+	plan.button = seatedAfter(r, r.ButtonSeat, isDealt)
+	plan.smallBlind = plan.button
+	if len(plan.dealt) > 2 {
+		plan.smallBlind = seatedAfter(r, r.Players[plan.button].Seat, isDealt)
+	}
+	plan.bigBlind = seatedAfter(r, r.Players[plan.smallBlind].Seat, isDealt)
+	plan.buttonSeat = r.Players[plan.button].Seat
+	plan.sbSeat = r.Players[plan.smallBlind].Seat
+	plan.bbSeat = r.Players[plan.bigBlind].Seat
+}
+
+func (m *MemoryStore) buildGameFromRoom(r *Room, stacks map[string]int) (*domain.GameState, error) {
+	stackOf := func(p RoomPlayer) int {
+		if stacks != nil {
+			if v, ok := stacks[p.UserID]; ok {
+				return v
+			}
+		}
+		return p.Stack
+	}
+	plan := planHand(r, stackOf)
+
+	gps := make([]*domain.GamePlayer, 0, len(plan.dealt))
+	gamePos := map[int]int{}
+	for pos, p := range r.Players {
+		if !plan.dealt[pos] {
+			continue
+		}
+		gamePos[pos] = len(gps)
+		gps = append(gps, &domain.GamePlayer{
+			UserID:    p.UserID,
+			Username:  p.Username,
+			IsAI:      p.IsAI,
+			AIManaged: p.AIManaged,
+			SeatIndex: p.Seat,
+			Stack:     stackOf(p),
+		})
+	}
+	if len(gps) < 2 {
+		return domain.NewGame(gps, 0, r.OpenBetMin, r.BetMin, r.gameOptions())
+	}
+
+	// A dead button still orders the action: the first player after the
+	// empty seat acts first after the flop.
+	dealerPos := len(gps) - 1
+	if plan.button >= 0 {
+		dealerPos = gamePos[plan.button]
+	} else {
+		for i, gp := range gps {
+			if gp.SeatIndex < plan.buttonSeat {
+				dealerPos = i
+			}
+		}
+	}
+	blinds := &domain.BlindPositions{SmallBlind: -1, BigBlind: gamePos[plan.bigBlind], DeadButton: plan.button < 0}
+	if plan.smallBlind >= 0 {
+		blinds.SmallBlind = gamePos[plan.smallBlind]
+	}
+	opts := r.gameOptions()
+	opts.Posts = plan.posts
+	opts.Blinds = blinds
+	bomb := bombPotLocked(r)
+	if bomb != nil {
+		applyBombPot(&opts, bomb)
+	}
+	g, err := domain.NewGame(gps, dealerPos, r.OpenBetMin, r.BetMin, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.NextBombPot = nil
+
+	for pos := range plan.dealt {
+		p := &r.Players[pos]
+		p.WaitingForHand = false
+		p.PostBlind = false
+		if bomb == nil {
+			p.MissedSmallBlind = false
+			p.MissedBigBlind = false
+		}
+	}
+	for _, pos := range plan.missedSmall {
+		r.Players[pos].MissedSmallBlind = true
+	}
+	for _, pos := range plan.missedBig {
+		r.Players[pos].MissedBigBlind = true
+	}
+	r.ButtonSeat, r.SmallBlindSeat, r.BigBlindSeat = plan.buttonSeat, plan.sbSeat, plan.bbSeat
+	return g, nil
+}
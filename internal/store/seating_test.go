@@ -0,0 +1,187 @@
+package store
+
+import (
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+type handSeats struct {
+	button, smallBlind, bigBlind int
+	deadButton, deadSmallBlind   bool
+}
+
+func seatsOf(r *Room) handSeats {
+	g := r.Game
+	return handSeats{
+		button:         r.ButtonSeat,
+		smallBlind:     r.SmallBlindSeat,
+		bigBlind:       g.Players[g.BigBlindPos].SeatIndex,
+		deadButton:     g.DeadButton,
+		deadSmallBlind: g.SmallBlindPos < 0,
+	}
+}
+
+func bustSeat(t *testing.T, s *MemoryStore, roomID string, seat int) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.rooms[roomID]
+	for i := range r.Players {
+		if r.Players[i].Seat != seat {
+			continue
+		}
+		r.Players[i].Stack = 0
+		for _, gp := range r.Game.Players {
+			if gp.UserID == r.Players[i].UserID {
+				gp.Stack = 0
+			}
+		}
+		return
+	}
+	t.Fatalf("no player in seat %d", seat)
+}
+
+func TestStore_DeadButtonMovesBlindsWithBusts(t *testing.T) {
+	cases := []struct {
+		name    string
+		players int
+		// busts lists the seats that go broke right before the given hand.
+		busts map[int][]int
+		want  map[int]handSeats
+	}{
+		{name: "heads-up", players: 2, want: map[int]handSeats{
+			2: {button: 1, smallBlind: 1, bigBlind: 0},
+		}},
+		{name: "3 players big blind busts", players: 3, busts: map[int][]int{2: {2}}, want: map[int]handSeats{
+			2: {button: 1, smallBlind: 1, bigBlind: 0},
+		}},
+		{name: "3 players button busts", players: 3, busts: map[int][]int{2: {0}}, want: map[int]handSeats{
+			2: {button: 2, smallBlind: 2, bigBlind: 1},
+		}},
+		{name: "4 players big blind busts", players: 4, busts: map[int][]int{2: {2}}, want: map[int]handSeats{
+			2: {button: 1, smallBlind: 2, bigBlind: 3, deadSmallBlind: true},
+			3: {button: 2, smallBlind: 3, bigBlind: 0, deadButton: true},
+		}},
+		{name: "5 players small blind busts", players: 5, busts: map[int][]int{2: {1}}, want: map[int]handSeats{
+			2: {button: 1, smallBlind: 2, bigBlind: 3, deadButton: true},
+		}},
+		{name: "6 players two busts at once", players: 6, busts: map[int][]int{2: {2, 3}}, want: map[int]handSeats{
+			2: {button: 1, smallBlind: 2, bigBlind: 4, deadSmallBlind: true},
+			3: {button: 2, smallBlind: 4, bigBlind: 5, deadButton: true},
+		}},
+		{name: "7 players spread busts", players: 7, busts: map[int][]int{2: {1}, 4: {5}, 6: {0}}},
+		{name: "8 players three busts", players: 8, busts: map[int][]int{3: {3, 4, 5}}},
+		{name: "9 players down to heads-up", players: 9, busts: map[int][]int{2: {2}, 3: {4}, 5: {7, 8}, 7: {0, 1, 3}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _, owner, r := startRoom(t, RoomRules{}, tc.players)
+			prev := seatsOf(r)
+			first := handSeats{button: 0, smallBlind: 1, bigBlind: 2}
+			if tc.players == 2 {
+				first = handSeats{button: 0, smallBlind: 0, bigBlind: 1}
+			}
+			if prev != first {
+				t.Fatalf("hand 1: got %+v, want %+v", prev, first)
+			}
+			for hand := 2; hand <= 2*tc.players+4; hand++ {
+				r = foldCurrentHand(t, s, r)
+				for _, seat := range tc.busts[hand] {
+					bustSeat(t, s, r.RoomID, seat)
+				}
+				var err error
+				if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+					t.Fatalf("hand %d: %v", hand, err)
+				}
+				got := seatsOf(r)
+				if want, ok := tc.want[hand]; ok && got != want {
+					t.Fatalf("hand %d: got %+v, want %+v", hand, got, want)
+				}
+
+				dealt := map[int]bool{}
+				for _, gp := range r.Game.Players {
+					dealt[gp.SeatIndex] = true
+				}
+				nextBB := -1
+				for i := 1; i <= tc.players; i++ {
+					if seat := (prev.bigBlind + i) % tc.players; dealt[seat] {
+						nextBB = seat
+						break
+					}
+				}
+				if got.bigBlind != nextBB {
+					t.Fatalf("hand %d: big blind went to seat %d, want the next seat in %d (prev %+v)", hand, got.bigBlind, nextBB, prev)
+				}
+				if len(r.Game.Players) == 2 {
+					if got.deadButton || got.deadSmallBlind || got.button != got.smallBlind {
+						t.Fatalf("hand %d: heads-up button must post the small blind, got %+v", hand, got)
+					}
+				} else {
+					if got.button != prev.smallBlind || got.smallBlind != prev.bigBlind {
+						t.Fatalf("hand %d: button and small blind must follow last hand's blinds, got %+v after %+v", hand, got, prev)
+					}
+					if got.deadButton == dealt[got.button] || got.deadSmallBlind == dealt[got.smallBlind] {
+						t.Fatalf("hand %d: dead positions do not match the seats dealt in, got %+v", hand, got)
+					}
+				}
+				prev = got
+			}
+		})
+	}
+}
+
+func TestStore_MissedBlindsArePostedOnReturn(t *testing.T) {
+	s, _, owner, r := startRoom(t, RoomRules{}, 5)
+	// Hand 1: button 0, small blind 1, big blind 2. The big blind sits out, so
+	// the next small blind is dead, and seat 3 sits out as the big blind
+	// passes it on to seat 4.
+	bigBlind, away := r.Players[2].UserID, r.Players[3].UserID
+	for _, uid := range []string{bigBlind, away} {
+		if _, err := s.SitOut(r.RoomID, uid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r = foldCurrentHand(t, s, r)
+	var err error
+	if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Players[2].MissedSmallBlind || r.Players[2].MissedBigBlind {
+		t.Fatalf("expected seat 2 to owe the small blind, got %+v", r.Players[2])
+	}
+	if !r.Players[3].MissedBigBlind || r.Players[3].MissedSmallBlind {
+		t.Fatalf("expected seat 3 to owe the big blind, got %+v", r.Players[3])
+	}
+
+	for _, uid := range []string{bigBlind, away} {
+		if _, err := s.SitIn(r.RoomID, uid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(r.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	posted := map[string]int{}
+	for _, l := range r.Game.ActionLogs {
+		if l.Action == "post" || l.Action == "dead_blind" {
+			posted[l.UserID+":"+l.Action] = l.Amount
+		}
+	}
+	if posted[bigBlind+":dead_blind"] != 5 || posted[bigBlind+":post"] != 0 {
+		t.Fatalf("expected seat 2 to post a dead small blind, got %v", posted)
+	}
+	if posted[away+":post"] != 10 {
+		t.Fatalf("expected seat 3 to post a live big blind, got %v", posted)
+	}
+	if r.Players[2].MissedSmallBlind || r.Players[3].MissedBigBlind {
+		t.Fatalf("expected missed blinds cleared once posted")
+	}
+	if r.Game.DeadMoney != 5 {
+		t.Fatalf("expected the dead small blind in the pot, got %d", r.Game.DeadMoney)
+	}
+	if r.Game.Stage != domain.StagePreflop {
+		t.Fatalf("expected a fresh hand, got %s", r.Game.Stage)
+	}
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+// SitOut keeps the player's seat and stack but leaves them out of every hand
+// dealt from now on. A hand they are already in is played to the end.
+func (m *MemoryStore) SitOut(roomID, userID string) (*Room, error) {
+	return m.setSittingOut(roomID, userID, true)
+}
+
+// SitIn deals a sitting-out player back in from the next hand.
+func (m *MemoryStore) SitIn(roomID, userID string) (*Room, error) {
+	return m.setSittingOut(roomID, userID, false)
+}
+
+func (m *MemoryStore) setSittingOut(roomID, userID string, sittingOut bool) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	if isSpectator(r, userID) {
+		return nil, errors.New("spectator is read-only")
+	}
+	idx := playerIndex(r, userID)
+	if idx < 0 {
+		return nil, errors.New("user not in room")
+	}
+	if r.Players[idx].IsAI {
+		return nil, errors.New("ai player cannot sit out")
+	}
+	if sittingOut && r.Tournament != nil {
+		return nil, errors.New("cannot sit out of a tournament")
+	}
+	if r.Players[idx].SittingOut == sittingOut {
+		return r, nil
+	}
+
+	r.Players[idx].SittingOut = sittingOut
+	r.Players[idx].SitOutHands = 0
+	r.ChipRefreshVote = nil
+	r.StateVersion++
+	r.UpdatedAtUnix = time.Now().Unix()
+	m.roomsVersion++
+	return r, nil
+}
+
+// SetPostBlind lets a player waiting for their first hand choose between
+// posting a big blind to be dealt in next hand and waiting for the big blind
+// to reach them.
+func (m *MemoryStore) SetPostBlind(roomID, userID string, post bool) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rooms[roomID]
+	if !ok {
+		return nil, errors.New("room not found")
+	}
+	if isSpectator(r, userID) {
+		return nil, errors.New("spectator is read-only")
+	}
+	idx := playerIndex(r, userID)
+	if idx < 0 {
+		return nil, errors.New("user not in room")
+	}
+	if !r.Players[idx].WaitingForHand {
+		return nil, errors.New("not waiting for next hand")
+	}
+	if r.Players[idx].PostBlind == post {
+		return r, nil
+	}
+
+	r.Players[idx].PostBlind = post
+	r.StateVersion++
+	r.UpdatedAtUnix = time.Now().Unix()
+	m.roomsVersion++
+	return r, nil
+}
+
+// The last human at the table is never removed so the room stays open.
+func (m *MemoryStore) removeIdleSittersLocked(r *Room) bool {
+	limit := r.SitOutHands
+	if limit <= 0 {
+		limit = DefaultSitOutHands
+	}
+	removed := false
+	for i := len(r.Players) - 1; i >= 0; i-- {
+		p := r.Players[i]
+		if !p.SittingOut || p.SitOutHands < limit || countHumans(r.Players) <= 1 {
+			continue
+		}
+		removeSeatLocked(r, i)
+		if r.TimeBanks != nil {
+			delete(r.TimeBanks, p.UserID)
+		}
+		if r.OwnerUserID == p.UserID {
+			r.OwnerUserID = firstHumanOwner(r.Players)
+		}
+		removed = true
+	}
+	return removed
+}
+
+func countSitOutHands(r *Room) {
+	for i := range r.Players {
+		if r.Players[i].SittingOut {
+			r.Players[i].SitOutHands++
+		}
+	}
+}
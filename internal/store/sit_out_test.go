@@ -0,0 +1,217 @@
+package store
+
+import (
+	"testing"
+
+	"texas_yu/internal/domain"
+)
+
+func foldCurrentHand(t *testing.T, s *MemoryStore, r *Room) *Room {
+	t.Helper()
+	for r.Game.Stage != domain.StageFinished {
+		turn := r.Game.Players[r.Game.TurnPos].UserID
+		folded, err := s.ApplyAction(r.RoomID, turn, "", "fold", 0, r.StateVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = folded
+	}
+	return r
+}
+
+func TestStore_SitOutKeepsSeatUntilLimit(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	idle := s.CreateSession("idle")
+	room := s.CreateRoom(owner, "sit-out", 10, 10, RoomRules{SitOutHands: 2})
+	for _, sess := range []*Session{guest, idle} {
+		if _, err := s.JoinRoom(room.RoomID, sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.SitOut(room.RoomID, idle.UserID); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Game.Players) != 2 {
+		t.Fatalf("expected the sitting-out player to be skipped, got %d players", len(r.Game.Players))
+	}
+	idx := playerIndex(r, idle.UserID)
+	if idx < 0 || r.Players[idx].Stack != DefaultPlayerStack || r.Players[idx].SitOutHands != 1 {
+		t.Fatalf("expected seat and stack kept with one hand missed, got %+v", r.Players)
+	}
+
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if idx := playerIndex(r, idle.UserID); idx < 0 || r.Players[idx].SitOutHands != 2 {
+		t.Fatalf("expected the player still seated after two hands, got %+v", r.Players)
+	}
+
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if playerIndex(r, idle.UserID) >= 0 || len(r.Players) != 2 {
+		t.Fatalf("expected the player removed after sitting out the limit, got %+v", r.Players)
+	}
+}
+
+func TestStore_SitInDealsBackInNextHand(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	guest := s.CreateSession("guest")
+	room := s.CreateRoom(owner, "sit-in", 10, 10)
+	if _, err := s.JoinRoom(room.RoomID, guest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SitOut(room.RoomID, guest.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartGame(room.RoomID, owner.UserID); err == nil {
+		t.Fatalf("expected start to fail with only one player dealt in")
+	}
+
+	r, err := s.SitIn(room.RoomID, guest.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Players[1].SittingOut || r.Players[1].SitOutHands != 0 {
+		t.Fatalf("expected guest back in, got %+v", r.Players[1])
+	}
+	if r, err = s.StartGame(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Game.Players) != 2 {
+		t.Fatalf("expected both players dealt in, got %d", len(r.Game.Players))
+	}
+
+	if _, err := s.SitOut(room.RoomID, "nobody"); err == nil || err.Error() != "user not in room" {
+		t.Fatalf("expected user not in room, got %v", err)
+	}
+}
+
+func dealtIn(r *Room, userID string) bool {
+	for _, gp := range r.Game.Players {
+		if gp.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStore_LateJoinerWaitsForBigBlind(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "late", 10, 10)
+	var leaver *Session
+	for _, name := range []string{"p1", "p2", "p3"} {
+		sess := s.CreateSession(name)
+		if leaver == nil {
+			leaver = sess
+		}
+		if _, err := s.JoinRoom(room.RoomID, sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The small blind leaves mid-hand and a newcomer takes the empty seat.
+	if _, err := s.LeaveRoom(room.RoomID, leaver.UserID); err != nil {
+		t.Fatal(err)
+	}
+	late := s.CreateSession("late")
+	if r, err = s.JoinRoom(room.RoomID, late); err != nil {
+		t.Fatalf("expected joining a running table to work, got %v", err)
+	}
+	idx := playerIndex(r, late.UserID)
+	if r.Players[idx].Seat != 1 || !r.Players[idx].WaitingForHand || dealtIn(r, late.UserID) {
+		t.Fatalf("expected the newcomer waiting in the free seat, got %+v", r.Players[idx])
+	}
+
+	// The button moves onto the newcomer's seat, so it is dead and they wait.
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if dealtIn(r, late.UserID) || !r.Game.DeadButton {
+		t.Fatalf("expected a dead button with the newcomer waiting")
+	}
+
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if dealtIn(r, late.UserID) {
+		t.Fatalf("expected the newcomer to wait until the big blind reaches them")
+	}
+
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if !dealtIn(r, late.UserID) || r.Players[playerIndex(r, late.UserID)].WaitingForHand {
+		t.Fatalf("expected the newcomer dealt in once the big blind reached them")
+	}
+	if bb := r.Game.Players[r.Game.BigBlindPos].UserID; bb != late.UserID {
+		t.Fatalf("expected the newcomer in the big blind, got %s", bb)
+	}
+}
+
+func TestStore_LateJoinerCanPostBigBlind(t *testing.T) {
+	s := NewMemoryStore()
+	owner := s.CreateSession("owner")
+	room := s.CreateRoom(owner, "post", 10, 10)
+	for _, name := range []string{"p1", "p2", "p3"} {
+		if _, err := s.JoinRoom(room.RoomID, s.CreateSession(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := s.StartGame(room.RoomID, owner.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poster := s.CreateSession("poster")
+	waiter := s.CreateSession("waiter")
+	for _, sess := range []*Session{poster, waiter} {
+		if _, err := s.JoinRoom(room.RoomID, sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.SetPostBlind(room.RoomID, poster.UserID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetPostBlind(room.RoomID, owner.UserID, true); err == nil || err.Error() != "not waiting for next hand" {
+		t.Fatalf("expected seated players to be rejected, got %v", err)
+	}
+
+	r, _ = s.GetRoom(room.RoomID)
+	r = foldCurrentHand(t, s, r)
+	if r, err = s.NextHand(room.RoomID, owner.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if !dealtIn(r, poster.UserID) || dealtIn(r, waiter.UserID) {
+		t.Fatalf("expected only the poster dealt in, got %+v", r.Game.Players)
+	}
+	posted := false
+	for _, l := range r.Game.ActionLogs {
+		if l.UserID == poster.UserID && l.Action == "post" && l.Amount == 10 {
+			posted = true
+		}
+	}
+	if !posted {
+		t.Fatalf("expected the poster to post a big blind, got %+v", r.Game.ActionLogs)
+	}
+	if idx := playerIndex(r, poster.UserID); r.Players[idx].WaitingForHand || r.Players[idx].PostBlind {
+		t.Fatalf("expected the poster to be a regular now, got %+v", r.Players[idx])
+	}
+}
@@ -0,0 +1,380 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+// DefaultHandPauseSeconds is how long a tournament table waits after a hand
+// before dealing the next one when the rules leave HandPauseSeconds at zero.
+const DefaultHandPauseSeconds = 3
+
+var errTournamentStarted = errors.New("tournament already started")
+
+// BlindLevel is one step of a tournament blind schedule. The small blind is
+// half the big blind and the minimum bet is one big blind.
+type BlindLevel struct {
+	BigBlind int `json:"bigBlind"`
+	Ante     int `json:"ante"`
+}
+
+// TournamentRules turn a room into a single-table tournament: everyone
+// starts with StartingStack, blinds follow Levels, busted players are out
+// and hands are dealt automatically until one player holds every chip.
+type TournamentRules struct {
+	StartingStack int          `json:"startingStack"`
+	Levels        []BlindLevel `json:"levels"`
+	// A level lasts LevelMinutes minutes or LevelHands hands, whichever ends
+	// first; zero turns that limit off. The last level never ends.
+	LevelMinutes int `json:"levelMinutes"`
+	LevelHands   int `json:"levelHands"`
+	// AnteMode is how level antes are paid, per player unless set.
+	AnteMode domain.AnteMode `json:"anteMode"`
+	// BuyIn times the number of entrants is the prize pool, shared by
+	// finishing place with the Payouts percentages, first place first.
+	BuyIn   int   `json:"buyIn"`
+	Payouts []int `json:"payouts"`
+	// HandPauseSeconds is the break between hands; 0 means
+	// DefaultHandPauseSeconds.
+	HandPauseSeconds int `json:"handPauseSeconds"`
+}
+
+// Validate reports the first problem with the rules, if any.
+func (t TournamentRules) Validate() error {
+	if t.StartingStack <= 0 {
+		return errors.New("starting stack must be positive")
+	}
+	if len(t.Levels) == 0 {
+		return errors.New("blind schedule needs at least one level")
+	}
+	for _, l := range t.Levels {
+		if l.BigBlind < 2 || l.Ante < 0 {
+			return errors.New("every level needs a big blind of at least 2 and no negative ante")
+		}
+	}
+	if t.LevelMinutes < 0 || t.LevelHands < 0 {
+		return errors.New("level length must not be negative")
+	}
+	if len(t.Levels) > 1 && t.LevelMinutes == 0 && t.LevelHands == 0 {
+		return errors.New("blind schedule needs a level length in minutes or hands")
+	}
+	if t.AnteMode != "" && (!domain.ValidAnteMode(t.AnteMode) || t.AnteMode == domain.AnteNone) {
+		return errors.New("invalid tournament ante mode")
+	}
+	if t.BuyIn < 0 || t.HandPauseSeconds < 0 {
+		return errors.New("buy-in and hand pause must not be negative")
+	}
+	total := 0
+	for _, pct := range t.Payouts {
+		if pct <= 0 {
+			return errors.New("payout percentages must be positive")
+		}
+		total += pct
+	}
+	if len(t.Payouts) > 0 && total != 100 {
+		return errors.New("payout percentages must add up to 100")
+	}
+	return nil
+}
+
+func (t TournamentRules) normalized() *TournamentRules {
+	if t.AnteMode == "" {
+		t.AnteMode = domain.AntePerPlayer
+	}
+	if len(t.Payouts) == 0 {
+		t.Payouts = []int{100}
+	}
+	if t.HandPauseSeconds <= 0 {
+		t.HandPauseSeconds = DefaultHandPauseSeconds
+	}
+	t.Levels = append([]BlindLevel(nil), t.Levels...)
+	t.Payouts = append([]int(nil), t.Payouts...)
+	return &t
+}
+
+// TournamentStatus is where a tournament room stands.
+type TournamentStatus string
+
+const (
+	TournamentRunning  TournamentStatus = "running"
+	TournamentFinished TournamentStatus = "finished"
+)
+
+// TournamentPlace is a player's finish: their place, prize and the hand they
+// went out in, 0 for the winner or a player who left between hands.
+type TournamentPlace struct {
+	UserID   string `json:"userId"`
+	Username string `json:"username"`
+	Place    int    `json:"place"`
+	Prize    int    `json:"prize"`
+	HandID   int64  `json:"handId,omitempty"`
+}
+
+// TournamentState is the progress of a tournament room. At the tables of a
+// multi-table tournament it mirrors the progress of the whole field.
+type TournamentState struct {
+	Status TournamentStatus `json:"status"`
+	// TournamentID and Table name the multi-table tournament the room is a
+	// table of and its table number; both are empty for a single table.
+	TournamentID string `json:"tournamentId,omitempty"`
+	Table        int    `json:"table,omitempty"`
+	// Level is the current blind level, counting from 1.
+	Level            int   `json:"level"`
+	BigBlind         int   `json:"bigBlind"`
+	Ante             int   `json:"ante"`
+	LevelStartedAtMs int64 `json:"levelStartedAtMs"`
+	// LevelHands counts the hands dealt at this level.
+	LevelHands int `json:"levelHands"`
+	// NextLevelAtMs is when the clock moves the blinds up, 0 when only
+	// hands do or this is the last level.
+	NextLevelAtMs int64 `json:"nextLevelAtMs"`
+	// NextHandAtMs is when the next hand is dealt, 0 while one is in play.
+	NextHandAtMs int64 `json:"nextHandAtMs"`
+	Entrants     int   `json:"entrants"`
+	PrizePool    int   `json:"prizePool"`
+	// Eliminations lists the players out so far in the order they busted;
+	// Results holds every place, first place first, once the tournament
+	// is finished.
+	Eliminations []TournamentPlace `json:"eliminations"`
+	Results      []TournamentPlace `json:"results,omitempty"`
+	settledHand  int64
+}
+
+func cloneTournament(t *TournamentState) *TournamentState {
+	if t == nil {
+		return nil
+	}
+	out := *t
+	out.Eliminations = append([]TournamentPlace(nil), t.Eliminations...)
+	if t.Results != nil {
+		out.Results = append([]TournamentPlace(nil), t.Results...)
+	}
+	return &out
+}
+
+func startTournamentLocked(r *Room, now time.Time) {
+	rules := r.TournamentRules
+	for i := range r.Players {
+		r.Players[i].Stack = rules.StartingStack
+		r.Players[i].SittingOut = false
+		r.Players[i].WaitingForHand = false
+	}
+	r.Tournament = &TournamentState{
+		Status:           TournamentRunning,
+		Level:            1,
+		LevelStartedAtMs: now.UnixMilli(),
+		Entrants:         len(r.Players),
+		PrizePool:        rules.BuyIn * len(r.Players),
+		Eliminations:     []TournamentPlace{},
+	}
+	setTournamentBlindsLocked(r)
+}
+
+func (m *MemoryStore) applyTournamentLevelLocked(r *Room) {
+	t := r.Tournament
+	nowMs := m.Now().UnixMilli()
+	if mt := m.tournaments[t.TournamentID]; mt != nil {
+		mt.State.advanceLevel(&mt.Rules, nowMs)
+		if t.Level != mt.State.Level {
+			t.LevelHands = 0
+		}
+		t.Level, t.LevelStartedAtMs = mt.State.Level, mt.State.LevelStartedAtMs
+	} else {
+		t.advanceLevel(r.TournamentRules, nowMs)
+	}
+	setTournamentBlindsLocked(r)
+}
+
+func (t *TournamentState) advanceLevel(rules *TournamentRules, nowMs int64) {
+	levelMs := int64(rules.LevelMinutes) * int64(time.Minute/time.Millisecond)
+	for t.Level < len(rules.Levels) {
+		if levelMs > 0 && nowMs >= t.LevelStartedAtMs+levelMs {
+			t.LevelStartedAtMs += levelMs
+		} else if rules.LevelHands > 0 && t.LevelHands >= rules.LevelHands {
+			t.LevelStartedAtMs = nowMs
+		} else {
+			break
+		}
+		t.Level++
+		t.LevelHands = 0
+	}
+	t.setLevel(rules)
+}
+
+func (t *TournamentState) setLevel(rules *TournamentRules) {
+	level := rules.Levels[t.Level-1]
+	t.BigBlind, t.Ante = level.BigBlind, level.Ante
+	t.NextLevelAtMs = 0
+	if rules.LevelMinutes > 0 && t.Level < len(rules.Levels) {
+		t.NextLevelAtMs = t.LevelStartedAtMs + int64(rules.LevelMinutes)*int64(time.Minute/time.Millisecond)
+	}
+}
+
+func setTournamentBlindsLocked(r *Room) {
+	rules, t := r.TournamentRules, r.Tournament
+	t.setLevel(rules)
+	level := rules.Levels[t.Level-1]
+	r.OpenBetMin, r.BetMin = level.BigBlind, level.BigBlind
+	r.Ante = level.Ante
+	r.AnteMode = domain.AnteNone
+	if level.Ante > 0 {
+		r.AnteMode = rules.AnteMode
+	}
+	if r.FixedLimit {
+		r.SmallBet, r.BigBet = level.BigBlind, level.BigBlind*2
+	}
+}
+
+// Players busting in the same hand place by the stacks they started it with.
+func (m *MemoryStore) settleTournamentHandLocked(r *Room) {
+	t := r.Tournament
+	if t == nil || t.Status != TournamentRunning || r.Game == nil || r.Game.Stage != domain.StageFinished || t.settledHand == r.HandCounter {
+		return
+	}
+	t.settledHand = r.HandCounter
+	started := map[string]int{}
+	if len(r.Game.Events) > 0 && r.Game.Events[0].Table != nil {
+		for _, seat := range r.Game.Events[0].Table.Seats {
+			started[seat.UserID] = seat.Stack
+		}
+	}
+	var busted []RoomPlayer
+	for _, gp := range r.Game.Players {
+		idx := playerIndex(r, gp.UserID)
+		if idx < 0 {
+			continue
+		}
+		r.Players[idx].Stack = gp.Stack
+		if gp.Stack <= 0 {
+			busted = append(busted, r.Players[idx])
+		}
+	}
+	sort.SliceStable(busted, func(i, j int) bool { return started[busted[i].UserID] < started[busted[j].UserID] })
+	for _, p := range busted {
+		m.eliminateLocked(r, p, r.HandCounter)
+		// A busted human stays on to watch the rest of the tournament.
+		if !p.IsAI {
+			r.Spectators = append(r.Spectators, RoomSpectator{UserID: p.UserID, Username: p.Username})
+		}
+	}
+	m.finishTournamentIfDecidedLocked(r)
+	if t.Status == TournamentRunning {
+		t.NextHandAtMs = m.Now().Add(time.Duration(r.TournamentRules.HandPauseSeconds) * time.Second).UnixMilli()
+	}
+}
+
+func (m *MemoryStore) eliminateLocked(r *Room, p RoomPlayer, handID int64) {
+	t := r.Tournament
+	if mt := m.tournaments[t.TournamentID]; mt != nil {
+		t = mt.State
+	}
+	t.Eliminations = append(t.Eliminations, TournamentPlace{
+		UserID:   p.UserID,
+		Username: p.Username,
+		Place:    t.Entrants - len(t.Eliminations),
+		HandID:   handID,
+	})
+	idx := playerIndex(r, p.UserID)
+	if idx < 0 {
+		return
+	}
+	removeSeatLocked(r, idx)
+	if r.OwnerUserID == p.UserID {
+		if owner := firstHumanOwner(r.Players); owner != "" {
+			r.OwnerUserID = owner
+		}
+	}
+}
+
+func (m *MemoryStore) finishTournamentIfDecidedLocked(r *Room) {
+	if mt := m.tournaments[r.Tournament.TournamentID]; mt != nil {
+		m.rebalanceTournamentLocked(mt)
+		return
+	}
+	if r.Tournament.Status != TournamentRunning || len(r.Players) > 1 {
+		return
+	}
+	finishTournamentLocked(r.Tournament, r.TournamentRules, r.Players)
+}
+
+func finishTournamentLocked(t *TournamentState, rules *TournamentRules, left []RoomPlayer) {
+	results := make([]TournamentPlace, 0, t.Entrants)
+	for _, p := range left {
+		results = append(results, TournamentPlace{UserID: p.UserID, Username: p.Username, Place: 1})
+	}
+	for i := len(t.Eliminations) - 1; i >= 0; i-- {
+		results = append(results, t.Eliminations[i])
+	}
+	prizes := tournamentPrizes(t.PrizePool, rules.Payouts, len(results))
+	for i := range results {
+		if i < len(prizes) {
+			results[i].Prize = prizes[i]
+		}
+	}
+	for _, res := range results {
+		for i := range t.Eliminations {
+			if t.Eliminations[i].UserID == res.UserID {
+				t.Eliminations[i].Prize = res.Prize
+			}
+		}
+	}
+	t.Results = results
+	t.Status = TournamentFinished
+	t.NextHandAtMs = 0
+	t.NextLevelAtMs = 0
+}
+
+// Chips lost to rounding go to first place.
+func tournamentPrizes(pool int, payouts []int, players int) []int {
+	if len(payouts) > players {
+		payouts = payouts[:players]
+	}
+	total := 0
+	for _, pct := range payouts {
+		total += pct
+	}
+	if total == 0 {
+		return nil
+	}
+	prizes := make([]int, len(payouts))
+	paid := 0
+	for i, pct := range payouts {
+		prizes[i] = pool * pct / total
+		paid += prizes[i]
+	}
+	if len(prizes) > 0 {
+		prizes[0] += pool - paid
+	}
+	return prizes
+}
+
+func (t *TournamentState) handDue(nowMs int64) bool {
+	return t.Status == TournamentRunning && t.NextHandAtMs != 0 && nowMs >= t.NextHandAtMs
+}
+
+func (m *MemoryStore) dealTournamentHands() {
+	nowMs := m.Now().UnixMilli()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.startDueTournamentsLocked(nowMs)
+	for _, mt := range m.tournaments {
+		if mt.State.Status == TournamentRunning {
+			mt.State.advanceLevel(&mt.Rules, nowMs)
+		}
+	}
+	for _, r := range m.rooms {
+		t := r.Tournament
+		if t == nil || !t.handDue(nowMs) {
+			continue
+		}
+		if r.Game == nil || r.Game.Stage != domain.StageFinished {
+			continue
+		}
+		if err := m.dealNextHandLocked(r); err != nil {
+			t.NextHandAtMs = 0
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"texas_yu/internal/domain"
+)
+
+// shoveCurrentHand has every player move all in until the hand is over.
+func shoveCurrentHand(t *testing.T, s *MemoryStore, r *Room) *Room {
+	t.Helper()
+	for r.Game.Stage != domain.StageFinished {
+		turn := r.Game.Players[r.Game.TurnPos].UserID
+		next, err := s.ApplyAction(r.RoomID, turn, "", "allin", 0, r.StateVersion)
+		if err != nil {
+			if next, err = s.ApplyAction(r.RoomID, turn, "", "call", 0, r.StateVersion); err != nil {
+				t.Fatal(err)
+			}
+		}
+		r = next
+	}
+	return r
+}
+
+func TestStore_TournamentPlaysDownToAWinner(t *testing.T) {
+	s, clock, _, r := startRoom(t, RoomRules{TournamentRules: &TournamentRules{
+		StartingStack: 100,
+		Levels:        []BlindLevel{{BigBlind: 10}, {BigBlind: 20, Ante: 5}},
+		LevelHands:    1,
+		BuyIn:         10,
+		Payouts:       []int{70, 30},
+	}}, 3)
+	if r.Tournament.Entrants != 3 || r.Tournament.PrizePool != 30 || r.Game.Players[0].Stack+r.Game.Players[0].Contributed != 100 {
+		t.Fatalf("expected three entrants with 100 chips each, got %+v", r.Tournament)
+	}
+
+	for hands := 1; ; hands++ {
+		if hands > 50 {
+			t.Fatalf("expected the tournament to end")
+		}
+		r = shoveCurrentHand(t, s, r)
+		total := 0
+		for _, p := range r.Players {
+			if p.Stack <= 0 {
+				t.Fatalf("expected busted players to lose their seat, got %+v", p)
+			}
+			total += p.Stack
+		}
+		if total != 300 {
+			t.Fatalf("expected the remaining players to hold all 300 chips, got %d", total)
+		}
+		if r.Tournament.Status == TournamentFinished {
+			break
+		}
+		if want := clock.Now().Add(DefaultHandPauseSeconds * time.Second).UnixMilli(); r.Tournament.NextHandAtMs != want {
+			t.Fatalf("expected the next hand scheduled at %d, got %d", want, r.Tournament.NextHandAtMs)
+		}
+		s.dealTournamentHands()
+		if cur, _ := s.GetRoom(r.RoomID); cur.HandCounter != int64(hands) {
+			t.Fatalf("expected no deal before the break is over")
+		}
+		clock.Advance(DefaultHandPauseSeconds * time.Second)
+		s.dealTournamentHands()
+		r, _ = s.GetRoom(r.RoomID)
+		if r.HandCounter != int64(hands+1) || r.Game.Stage == domain.StageFinished {
+			t.Fatalf("expected hand %d dealt automatically, got hand %d", hands+1, r.HandCounter)
+		}
+		if r.Tournament.Level != 2 || r.OpenBetMin != 20 || r.Game.Ante != 5 {
+			t.Fatalf("expected level 2 blinds after one hand, level=%d bb=%d ante=%d", r.Tournament.Level, r.OpenBetMin, r.Game.Ante)
+		}
+	}
+
+	res := r.Tournament.Results
+	if len(res) != 3 || len(r.Tournament.Eliminations) != 2 {
+		t.Fatalf("expected three places and two eliminations, got %+v", r.Tournament)
+	}
+	for i, want := range []int{21, 9, 0} {
+		if res[i].Place != i+1 || res[i].Prize != want {
+			t.Fatalf("place %d: expected prize %d, got %+v", i+1, want, res[i])
+		}
+	}
+	if res[0].UserID != r.Players[0].UserID || res[2].UserID != r.Tournament.Eliminations[0].UserID {
+		t.Fatalf("expected results in finishing order, got %+v", res)
+	}
+	if len(r.Spectators) != 2 {
+		t.Fatalf("expected busted players to stay on as spectators, got %+v", r.Spectators)
+	}
+	if _, err := s.NextHand(r.RoomID, r.Players[0].UserID); err == nil {
+		t.Fatalf("expected no hands after the tournament is over")
+	}
+}
+
+func TestStore_TournamentNextHandWaitsForTheBreak(t *testing.T) {
+	s, clock, _, r := startRoom(t, RoomRules{TournamentRules: &TournamentRules{
+		StartingStack: 1000,
+		Levels:        []BlindLevel{{BigBlind: 10}},
+		LevelHands:    5,
+	}}, 2)
+	r = foldCurrentHand(t, s, r)
+	hand := r.HandCounter
+	if _, err := s.NextHand(r.RoomID, r.OwnerUserID); err == nil {
+		t.Fatalf("expected the owner not to deal during the break between hands")
+	}
+	clock.Advance(DefaultHandPauseSeconds * time.Second)
+	next, err := s.NextHand(r.RoomID, r.OwnerUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.HandCounter != hand+1 {
+		t.Fatalf("expected the next hand dealt once the break is over, got hand %d", next.HandCounter)
+	}
+	s.dealTournamentHands()
+	if cur, _ := s.GetRoom(r.RoomID); cur.HandCounter != next.HandCounter {
+		t.Fatalf("expected the scheduler not to deal the same hand again")
+	}
+}
+
+func TestStore_TournamentLevelsFollowTheClock(t *testing.T) {
+	s, clock, _, r := startRoom(t, RoomRules{TournamentRules: &TournamentRules{
+		StartingStack: 1000,
+		Levels:        []BlindLevel{{BigBlind: 10}, {BigBlind: 20}, {BigBlind: 40, Ante: 5}},
+		LevelMinutes:  1,
+	}}, 2)
+	if r.Tournament.NextLevelAtMs != clock.Now().Add(time.Minute).UnixMilli() {
+		t.Fatalf("expected the next level a minute out, got %+v", r.Tournament)
+	}
+	r = foldCurrentHand(t, s, r)
+
+	// Two and a half minutes later the schedule has passed two levels.
+	clock.Advance(150 * time.Second)
+	s.dealTournamentHands()
+	r, _ = s.GetRoom(r.RoomID)
+	if r.Tournament.Level != 3 || r.Tournament.BigBlind != 40 || r.Game.Ante != 5 || r.AnteMode != domain.AntePerPlayer {
+		t.Fatalf("expected level 3 with a per-player ante, got %+v ante mode %s", r.Tournament, r.AnteMode)
+	}
+	if r.Tournament.NextLevelAtMs != 0 {
+		t.Fatalf("expected the last level to last, got %d", r.Tournament.NextLevelAtMs)
+	}
+}
+
+func TestStore_TournamentLeaverFinishesLast(t *testing.T) {
+	s, _, _, r := startRoom(t, RoomRules{TournamentRules: &TournamentRules{StartingStack: 500, Levels: []BlindLevel{{BigBlind: 10}}}}, 3)
+	leaver := r.Players[2]
+	if _, err := s.LeaveRoom(r.RoomID, leaver.UserID); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = s.GetRoom(r.RoomID)
+	if got := r.Tournament.Eliminations; len(got) != 1 || got[0].UserID != leaver.UserID || got[0].Place != 3 {
+		t.Fatalf("expected the leaver out in third, got %+v", got)
+	}
+	if _, err := s.JoinRoom(r.RoomID, s.CreateSession("late")); err == nil {
+		t.Fatalf("expected a running tournament to refuse new players")
+	}
+	if _, err := s.SitOut(r.RoomID, r.Players[0].UserID); err == nil {
+		t.Fatalf("expected tournament players to be unable to sit out")
+	}
+}
+
+func TestTournamentRules_Validate(t *testing.T) {
+	good := TournamentRules{StartingStack: 100, Levels: []BlindLevel{{BigBlind: 10}, {BigBlind: 20}}, LevelHands: 5, Payouts: []int{60, 40}}
+	if err := good.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	bad := []func(*TournamentRules){
+		func(r *TournamentRules) { r.StartingStack = 0 },
+		func(r *TournamentRules) { r.Levels = nil },
+		func(r *TournamentRules) { r.Levels = []BlindLevel{{BigBlind: 1}} },
+		func(r *TournamentRules) { r.LevelHands = 0 },
+		func(r *TournamentRules) { r.Payouts = []int{60, 30} },
+		func(r *TournamentRules) { r.AnteMode = domain.AnteNone },
+	}
+	for i, mutate := range bad {
+		rules := good
+		mutate(&rules)
+		if err := rules.Validate(); err == nil {
+			t.Fatalf("case %d: expected an error for %+v", i, rules)
+		}
+	}
+	if got := tournamentPrizes(100, []int{50, 30, 20}, 2); got[0] != 63 || got[1] != 37 {
+		t.Fatalf("expected unpaid places folded into the rest, got %v", got)
+	}
+}
@@ -30,6 +30,10 @@ const ACTION_TEXT = {
   leave: "离开房间",
   small_blind: "小盲",
   big_blind: "大盲",
+  ante: "前注",
+  straddle: "抓头",
+  run_it: "选择发牌次数",
+  runout_agreed: "约定发牌次数",
 };
 
 const STAGE_TEXT = {
@@ -64,6 +68,7 @@ const REVEAL_TEXT = {
   1: "亮第一张",
   2: "亮第二张",
   3: "全亮",
+  15: "全亮",
 };
 
 const CHIP_REFRESH_RESULT_TEXT = {
@@ -193,6 +198,11 @@ function updateRevealControls(data) {
   controls.style.display = canReveal ? "flex" : "none";
   hint.style.display = canReveal ? "block" : "none";
 
+  const holeCount = me && Array.isArray(me.holeCards) ? me.holeCards.length : 2;
+  controls.querySelectorAll("button[data-reveal-all]").forEach((btn) => {
+    btn.dataset.reveal = String((1 << Math.max(2, holeCount)) - 1);
+  });
+
   controls.querySelectorAll("button[data-reveal]").forEach((btn) => {
     const mask = Number(btn.dataset.reveal);
     btn.disabled = !canReveal;
@@ -200,6 +210,16 @@ function updateRevealControls(data) {
   });
 }
 
+function updateRunoutControls(data) {
+  const controls = document.getElementById("runout-controls");
+  const hint = document.getElementById("runout-hint");
+  if (!controls || !hint) return;
+  const me = getCurrentPlayer(data);
+  const canRunIt = !isSpectatorMode() && !!(me && me.canRunIt);
+  controls.style.display = canRunIt ? "flex" : "none";
+  hint.style.display = canRunIt ? "block" : "none";
+}
+
 function updateActionButtons(data) {
   const buttons = {
     check: document.querySelector('button[data-action="check"]'),
@@ -264,7 +284,7 @@ function updateActionButtons(data) {
     return;
   }
 
-  const canAllIn = !!(me.isTurn && !me.folded && typeof me.stack === "number" && me.stack > 0);
+  const canAllIn = !!me.canAllIn;
 
   buttons.check.disabled = !me.canCheck;
   buttons.call.disabled = !me.canCall;
@@ -287,14 +307,18 @@ function updateActionButtons(data) {
       betAmountInput.placeholder = `≥${me.minRaise}`;
       if (!betAmountInput.value) betAmountInput.value = me.minRaise;
     }
-    betAmountInput.max = me.stack;
+    betAmountInput.max = me.maxRaise || me.stack;
+    // Limit bets have one fixed size set by the server.
+    const fixedLimit = data.game.betting === "fixed_limit";
+    betAmountInput.readOnly = fixedLimit;
+    if (fixedLimit && (me.canBet || me.canRaise)) betAmountInput.value = me.canBet ? me.minBet : me.minRaise;
     betAmountInput.disabled = !me.canBet && !me.canRaise;
   }
 
   if (buttons.check) buttons.check.title = me.canCheck ? "执行过牌" : "当前不可过牌";
   if (buttons.call) buttons.call.title = me.canCall ? `跟注 ${me.callAmount}` : "当前不可跟注";
   if (buttons.bet) buttons.bet.title = me.canBet ? `下注（最低 ${me.minBet}）` : "当前不可下注";
-  if (buttons.raise) buttons.raise.title = me.canRaise ? `加注（最低 ${me.minRaise}）` : "当前不可加注";
+  if (buttons.raise) buttons.raise.title = me.canRaise ? `加注（最低 ${me.minRaise}${me.maxRaise && me.maxRaise < me.stack ? `，最高 ${me.maxRaise}` : ""}）` : "当前不可加注";
   if (buttons.allin) buttons.allin.title = canAllIn ? `梭哈（全下 ${me.stack}）` : "当前不可梭哈";
   if (buttons.fold) buttons.fold.title = me.canFold ? "执行弃牌" : "当前不可弃牌";
 
@@ -491,6 +515,48 @@ function updateAIManagedButton(data) {
   btn.title = enabled ? "点击后恢复手动操作" : "开启后由 AI 自动执行你的回合动作";
 }
 
+function updateSitOutButton(data) {
+  const btn = document.getElementById("btn-sit-out");
+  if (!btn) return;
+
+  const me = (data.roomPlayers || []).find((p) => p.userId === currentUserId);
+  if (isSpectatorMode() || !me || me.isAi) {
+    btn.style.display = "none";
+    btn.disabled = true;
+    btn.dataset.sittingOut = "0";
+    return;
+  }
+
+  const sittingOut = !!me.sittingOut;
+  btn.style.display = "inline-block";
+  btn.disabled = false;
+  btn.dataset.sittingOut = sittingOut ? "1" : "0";
+  btn.textContent = sittingOut ? "回到座位" : "暂时离座";
+  btn.title = sittingOut
+    ? "下一局开始重新发牌给你"
+    : `保留座位和筹码，从下一局起不再发牌；连续离座 ${data.sitOutHands || 0} 局后自动让出座位`;
+}
+
+function updatePostBlindButton(data) {
+  const btn = document.getElementById("btn-post-blind");
+  if (!btn) return;
+
+  const me = (data.roomPlayers || []).find((p) => p.userId === currentUserId);
+  if (isSpectatorMode() || !me || !me.waitingForHand) {
+    btn.style.display = "none";
+    btn.disabled = true;
+    btn.dataset.enabled = "0";
+    return;
+  }
+
+  const enabled = !!me.postBlind;
+  btn.style.display = "inline-block";
+  btn.disabled = false;
+  btn.dataset.enabled = enabled ? "1" : "0";
+  btn.textContent = enabled ? "改为等待大盲" : "补大盲入局";
+  btn.title = enabled ? "下一局补一个大盲直接入局，点击改为等大盲轮到自己" : "当前等待大盲轮到自己再入局，点击改为下一局补大盲入局";
+}
+
 function updateMyStack(data) {
   const el = document.getElementById("my-stack");
   if (!el) return;
@@ -568,7 +634,10 @@ function renderState(data) {
     updateMyStack(data);
     updateOwnerActions(data);
     updateAIManagedButton(data);
+    updateSitOutButton(data);
+    updatePostBlindButton(data);
     updateRevealControls(data);
+    updateRunoutControls(data);
     updateActionButtons(data);
     renderHandLog(data);
     updateChipRefreshHint(data);
@@ -593,13 +662,29 @@ function renderState(data) {
           aiManaged: player.aiManaged,
         }))
         .sort((a, b) => Number(a.seat || 0) - Number(b.seat || 0));
-  const dealerUserId = gamePlayers[g.dealerPos] ? gamePlayers[g.dealerPos].userId : "";
+  const dealerUserId = !g.deadButton && gamePlayers[g.dealerPos] ? gamePlayers[g.dealerPos].userId : "";
   const smallBlindUserId = gamePlayers[g.smallBlindPos] ? gamePlayers[g.smallBlindPos].userId : "";
   const bigBlindUserId = gamePlayers[g.bigBlindPos] ? gamePlayers[g.bigBlindPos].userId : "";
   const stageClass = g.stage === "finished" ? " finished" : "";
-  const communityHtml = (g.communityCards || []).length
+  const runouts = g.runouts || [];
+  let communityHtml = (g.communityCards || []).length
     ? (g.communityCards || []).map((c) => cardHtml(c)).join("")
     : '<span class="hint">等待发牌</span>';
+  if (runouts.length > 1) {
+    communityHtml = runouts
+      .map((run, idx) => {
+        const winners = [...new Set((run.pots || []).flatMap((pot) => pot.winnerUserIds || []))]
+          .map((id) => {
+            const p = gamePlayers.find((pl) => pl.userId === id);
+            return p ? p.username : id;
+          })
+          .join("、");
+        return `<div class="runout-board"><span class="meta-label">第${idx + 1}次</span>${(run.board || []).map((c) => cardHtml(c)).join("")}<span class="hint">${winners ? ` → ${winners}` : ""}</span></div>`;
+      })
+      .join("");
+  } else if (g.runoutPending) {
+    communityHtml += '<span class="hint">等待全下玩家选择发牌次数</span>';
+  }
 
   let resultHtml = "";
   if (g.result) {
@@ -613,14 +698,35 @@ function renderState(data) {
     resultHtml = `<div class="result-banner">${reason} — 赢家：${winners}</div>`;
   }
 
+  const pots = g.pots || [];
+  let potsHtml = "";
+  if (pots.length > 1 || (pots.length === 1 && (pots[0].winnerUserIds || []).length > 0)) {
+    potsHtml = `<div class="pot-breakdown">${pots
+      .map((pot, idx) => {
+        const label = idx === 0 ? "主池" : `边池${idx}`;
+        const winners = (pot.winnerUserIds || [])
+          .map((id) => {
+            const p = gamePlayers.find((pl) => pl.userId === id);
+            return p ? p.username : id;
+          })
+          .join("、");
+        const winnerText = winners ? ` → ${winners}` : ` (${(pot.eligibleUserIds || []).length}人)`;
+        return `<span class="pot-chip">${label} ${pot.amount}${winnerText}</span>`;
+      })
+      .join("")}</div>`;
+  }
+
   gameMeta.innerHTML = `
     <div class="game-meta-grid">
       <div><span class="meta-label">房间</span><div class="meta-value">${data.roomName}</div></div>
       <div><span class="meta-label">阶段</span><div class="meta-value"><span class="stage-badge${stageClass}">${toStageText(g.stage)}</span></div></div>
     </div>
     <div class="pot-display"><span class="pot-label">底池</span><br/>${g.pot}</div>
+    ${potsHtml}
     <div class="community-cards">${communityHtml}</div>
     ${resultHtml}
+    ${actionClockHtml(data, gamePlayers)}
+    ${g.commitment ? `<div class="hint fairness-line" title="${g.commitment}">发牌承诺 ${g.commitment.slice(0, 16)}…${g.seed ? `｜种子 <span title="${g.seed}">${g.seed.slice(0, 16)}…</span>` : ""}</div>` : ""}
   `;
 
   document.getElementById("players").innerHTML = tablePlayers
@@ -641,7 +747,13 @@ function renderState(data) {
       if (roomPlayer.userId === bigBlindUserId) badges.push('<span class="badge badge-bb">BB</span>');
       if (isTurn) badges.push('<span class="badge badge-turn">行动中</span>');
       if (isFolded) badges.push('<span class="badge badge-folded">已弃牌</span>');
-      if (!inCurrentHand) badges.push('<span class="badge badge-sitout">未参局</span>');
+      if (roomPlayer.waitingForHand) badges.push(`<span class="badge badge-sitout">${roomPlayer.postBlind ? "补盲待入局" : "等待大盲"}</span>`);
+      else if (roomPlayer.sittingOut) badges.push(`<span class="badge badge-sitout">离座${roomPlayer.sitOutHands ? ` ${roomPlayer.sitOutHands}局` : ""}</span>`);
+      else if (!inCurrentHand) badges.push('<span class="badge badge-sitout">未参局</span>');
+      if (roomPlayer.missedBigBlind || roomPlayer.missedSmallBlind) {
+        const owed = [roomPlayer.missedSmallBlind ? "小盲" : "", roomPlayer.missedBigBlind ? "大盲" : ""].filter(Boolean).join("+");
+        badges.push(`<span class="badge badge-sitout" title="回到座位后补交">欠${owed}</span>`);
+      }
       if (roomPlayer.isAi) badges.push('<span class="badge badge-ai">AI</span>');
       if (roomPlayer.aiManaged) badges.push('<span class="badge badge-ai-managed">AI托管中</span>');
       badges.push(chipRefreshStatusBadge(data, roomPlayer));
@@ -674,9 +786,12 @@ function renderState(data) {
   renderAIList(data);
   renderActiveQuickChatBubbles();
   updateRevealControls(data);
+  updateRunoutControls(data);
   updateMyStack(data);
   updateOwnerActions(data);
   updateAIManagedButton(data);
+  updateSitOutButton(data);
+  updatePostBlindButton(data);
   updateActionButtons(data);
   renderHandLog(data);
   updateChipRefreshHint(data);
@@ -699,6 +814,18 @@ function cardText(c) {
   return `${rankText}${suitText}`;
 }
 
+function actionClockHtml(data, gamePlayers) {
+  const clock = data.actionClock;
+  if (!clock) return "";
+  const now = Number(data.serverTimeMs) || Date.now();
+  const p = gamePlayers.find((pl) => pl.userId === clock.userId);
+  const name = p ? p.username : clock.userId;
+  const left = Math.max(0, Math.ceil((clock.deadlineMs - now) / 1000));
+  const bankLeft = Math.max(0, Math.ceil((clock.bankDeadlineMs - Math.max(now, clock.deadlineMs)) / 1000));
+  const text = left > 0 ? `${left}s` : `时间银行 ${bankLeft}s`;
+  return `<div class="hint action-clock">⏱ ${name} 剩余 ${text}${left > 0 && bankLeft > 0 ? `（时间银行 ${bankLeft}s）` : ""}</div>`;
+}
+
 function cardHtml(c) {
   if (!c) return '<span class="poker-card hidden-card"></span>';
   const suits = ["♣", "♦", "♥", "♠"];
@@ -1070,6 +1197,32 @@ async function doAction(type) {
   }
 }
 
+async function doRunIt(runs) {
+  if (isSpectatorMode()) {
+    logLine("观战模式不可执行操作");
+    return;
+  }
+  try {
+    await api(`/api/v1/rooms/${roomId}/actions`, {
+      method: "POST",
+      body: {
+        actionId: `${Date.now()}-${Math.random().toString(16).slice(2)}`,
+        type: "run_it",
+        amount: Number(runs),
+        expectedVersion: stateVersion,
+      },
+    });
+    logLine(`已选择发 ${Number(runs)} 次`);
+    await loadState();
+  } catch (err) {
+    if (err && err.status === 404) {
+      stopPollingAndBackToRooms();
+      return;
+    }
+    logLine(`选择发牌次数失败：${err.message}`);
+  }
+}
+
 async function doReveal(mask) {
   if (isSpectatorMode()) {
     logLine("观战模式不可设置亮牌");
@@ -1200,6 +1353,56 @@ async function toggleAIManaged() {
   }
 }
 
+async function toggleSitOut() {
+  const btn = document.getElementById("btn-sit-out");
+  if (!btn) return;
+  const sitIn = btn.dataset.sittingOut === "1";
+  try {
+    await api(`/api/v1/rooms/${roomId}/${sitIn ? "sit-in" : "sit-out"}`, { method: "POST", body: {} });
+    logLine(sitIn ? "已回到座位，下一局开始参与" : "已暂时离座，下一局起不再发牌");
+    await loadState();
+  } catch (err) {
+    logLine(`${sitIn ? "回到座位" : "离座"}失败：${err.message}`);
+  }
+}
+
+async function togglePostBlind() {
+  const btn = document.getElementById("btn-post-blind");
+  if (!btn) return;
+  const nextEnabled = btn.dataset.enabled !== "1";
+  try {
+    await api(`/api/v1/rooms/${roomId}/post-blind`, { method: "POST", body: { enabled: nextEnabled } });
+    logLine(nextEnabled ? "下一局将补大盲入局" : "将等待大盲轮到自己再入局");
+    await loadState();
+  } catch (err) {
+    logLine(`切换入局方式失败：${err.message}`);
+  }
+}
+
+async function exportHandHistory() {
+  try {
+    const resp = await fetch(`${API_BASE}/api/v1/rooms/${roomId}/hand-history`, {
+      headers: { "X-User-Id": getUserId() },
+    });
+    if (!resp.ok) {
+      const data = await resp.json().catch(() => ({}));
+      throw new Error(data.error || `HTTP ${resp.status}`);
+    }
+    const text = await resp.text();
+    if (!text) {
+      logLine("本房间还没有已结束的手牌");
+      return;
+    }
+    const link = document.createElement("a");
+    link.href = URL.createObjectURL(new Blob([text], { type: "text/plain" }));
+    link.download = `${roomId}-hands.txt`;
+    link.click();
+    URL.revokeObjectURL(link.href);
+  } catch (err) {
+    logLine(`导出手牌历史失败：${err.message}`);
+  }
+}
+
 window.removeAI = async function removeAI(aiUserId) {
   if (!aiUserId) return;
   if (isSpectatorMode()) {
@@ -1243,12 +1446,20 @@ function resetPolling(ms) {
   document.querySelectorAll("button[data-reveal]").forEach((btn) => {
     btn.addEventListener("click", () => doReveal(btn.dataset.reveal));
   });
+  document.querySelectorAll("button[data-run-it]").forEach((btn) => {
+    btn.addEventListener("click", () => doRunIt(btn.dataset.runIt));
+  });
 
   document.getElementById("btn-start-game").addEventListener("click", startGame);
   document.getElementById("btn-next-hand").addEventListener("click", nextHand);
   document.getElementById("btn-refresh-stack").addEventListener("click", startChipRefreshVote);
   const btnAIManaged = document.getElementById("btn-ai-managed");
   if (btnAIManaged) btnAIManaged.addEventListener("click", toggleAIManaged);
+  const btnSitOut = document.getElementById("btn-sit-out");
+  if (btnSitOut) btnSitOut.addEventListener("click", toggleSitOut);
+  const btnPostBlind = document.getElementById("btn-post-blind");
+  if (btnPostBlind) btnPostBlind.addEventListener("click", togglePostBlind);
+  document.getElementById("btn-export-history").addEventListener("click", exportHandHistory);
   document.getElementById("btn-leave-room").addEventListener("click", leaveRoom);
   const btnAddAI = document.getElementById("btn-add-ai");
   if (btnAddAI) btnAddAI.addEventListener("click", addAI);
@@ -1256,6 +1467,8 @@ function resetPolling(ms) {
   document.getElementById("btn-next-hand").style.display = "none";
   document.getElementById("btn-refresh-stack").style.display = "none";
   if (btnAIManaged) btnAIManaged.style.display = "none";
+  if (btnSitOut) btnSitOut.style.display = "none";
+  if (btnPostBlind) btnPostBlind.style.display = "none";
 
   await initQuickChatConfig();
   resetQuickChatPolling();
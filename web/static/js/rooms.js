@@ -47,7 +47,7 @@
         <div class="room-item">
           <div>
             <strong>${r.name}</strong>
-            <div class="hint">${roomPopulationText(r.players || [])} · ${roomStatusText(r.status)} · 开局≥${r.openBetMin || 10} · 加注≥${r.betMin || 10}</div>
+            <div class="hint">${roomPopulationText(r.players || [])} · ${roomStatusText(r.status)} · 开局≥${r.openBetMin || 10} · 加注≥${r.betMin || 10}${roomRulesText(r)}</div>
           </div>
           <div class="actions">
             <button onclick="joinRoom('${r.roomId}')">进入</button>
@@ -59,6 +59,19 @@
       .join("");
   }
 
+  function roomRulesText(r) {
+    const parts = [];
+    if (r.variant === "plo") parts.push("PLO");
+    if (r.variant === "shortdeck") parts.push("短牌");
+    if (r.fixedLimit) parts.push(`限注${r.smallBet}/${r.bigBet}`);
+    if (r.anteMode === "per_player") parts.push(`前注${r.ante}`);
+    if (r.anteMode === "big_blind") parts.push(`大盲前注${r.ante || r.openBetMin || 10}`);
+    if (r.straddle) parts.push("抓头");
+    if (r.runItTwice) parts.push("可发两次");
+    if (r.actionSeconds) parts.push(`限时${r.actionSeconds}s${r.timeBankSeconds ? `+${r.timeBankSeconds}s` : ""}`);
+    return parts.length ? ` · ${parts.join(" · ")}` : "";
+  }
+
   window.joinRoom = async function joinRoom(roomId) {
     try {
       await api(`/api/v1/rooms/${roomId}/join`, { method: "POST", body: {} });
@@ -82,10 +95,18 @@
     const name = document.getElementById("room-name").value.trim() || "房间";
     const openBetMin = Number(document.getElementById("open-bet-min").value) || 10;
     const betMin = Number(document.getElementById("bet-min").value) || 10;
+    const variant = document.getElementById("variant").value || "holdem";
+    const anteMode = document.getElementById("ante-mode").value || "none";
+    const ante = Number(document.getElementById("ante").value) || 0;
+    const straddle = document.getElementById("straddle").checked;
+    const fixedLimit = document.getElementById("fixed-limit").checked;
+    const runItTwice = document.getElementById("run-it-twice").checked;
+    const actionSeconds = Number(document.getElementById("action-seconds").value) || 0;
+    const timeBankSeconds = actionSeconds > 0 ? Number(document.getElementById("time-bank-seconds").value) || 0 : 0;
     try {
       const room = await api("/api/v1/rooms", {
         method: "POST",
-        body: { name, openBetMin, betMin },
+        body: { name, openBetMin, betMin, variant, anteMode, ante, straddle, fixedLimit, runItTwice, actionSeconds, timeBankSeconds },
       });
       location.href = `/game.html?roomId=${room.roomId}`;
     } catch (err) {